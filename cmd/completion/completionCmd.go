@@ -0,0 +1,147 @@
+// Package completion is the shell completion of todo: the completion
+// command and the functions suggesting IDs of tasks and rewards.
+package completion
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/cmd/security"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+func CompletionCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "completion <bash|zsh|fish|powershell>",
+		Short: "Generate the completion script for a shell",
+		Long: `Generate the completion script for bash, zsh, fish or PowerShell. Besides commands
+and flags it completes IDs of tasks and rewards, e.g. 'todo complete <TAB>' offers open tasks.
+
+  bash:        source <(todo completion bash)
+               or save it to /etc/bash_completion.d/todo
+  zsh:         todo completion zsh > "${fpath[1]}/_todo"
+  fish:        todo completion fish > ~/.config/fish/completions/todo.fish
+  PowerShell:  todo completion powershell | Out-String | Invoke-Expression`,
+		Args:                  cobra.ExactArgs(1),
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		DisableFlagsInUseLine: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			root := cmd.Root()
+			var err error
+			switch args[0] {
+			case "bash":
+				err = root.GenBashCompletionV2(os.Stdout, true)
+			case "zsh":
+				err = root.GenZshCompletion(os.Stdout)
+			case "fish":
+				err = root.GenFishCompletion(os.Stdout, true)
+			case "powershell":
+				err = root.GenPowerShellCompletionWithDesc(os.Stdout)
+			default:
+				err = fmt.Errorf("unknown shell %q, use bash, zsh, fish or powershell", args[0])
+			}
+			if err != nil {
+				logger.Error("could not generate completion script", err)
+			}
+		},
+	}
+}
+
+// TaskIDs completes the first argument with IDs of the tasks accepted by
+// filter, described by their text. A nil filter accepts all tasks.
+func TaskIDs(cfg *config.Config, filter func(task models.Task) bool) cobra.CompletionFunc {
+	return taskIDs(cfg, filter, true)
+}
+
+// TaskIDList is TaskIDs for commands taking several IDs: every argument is
+// completed, IDs given before are skipped.
+func TaskIDList(cfg *config.Config, filter func(task models.Task) bool) cobra.CompletionFunc {
+	return taskIDs(cfg, filter, false)
+}
+
+func taskIDs(cfg *config.Config, filter func(task models.Task) bool, firstOnly bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if firstOnly && len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		if err := prepare(cmd, cfg); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		given := make(map[string]bool)
+		for _, arg := range args {
+			given[arg] = true
+		}
+
+		var completions []cobra.Completion
+		for _, task := range todoList.Tasks {
+			if given[strconv.Itoa(task.ID)] {
+				continue
+			}
+			if filter == nil || filter(task) {
+				completions = append(completions, cobra.CompletionWithDesc(strconv.Itoa(task.ID), task.Text))
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// RewardIDs completes the first argument with IDs of the rewards accepted
+// by filter, described by their description and price. The filter gets the
+// points which can be spent on the reward too. A nil filter accepts all rewards.
+func RewardIDs(cfg *config.Config, filter func(reward models.Reward, balance int) bool) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		if err := prepare(cmd, cfg); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		var completions []cobra.Completion
+		for _, reward := range rewardSystem.Rewards {
+			if filter == nil || filter(reward, utils.SpendablePoints(rewardSystem, reward)) {
+				description := fmt.Sprintf("%s (%d points)", reward.Description, reward.PriceOfReward)
+				completions = append(completions, cobra.CompletionWithDesc(strconv.Itoa(reward.ID), description))
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// ListNames completes names of the lists, for --list and 'lists use'.
+func ListNames(cfg *config.Config) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return cfg.ListNames(), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// prepare switches to the list given with --list on the completed line and
+// makes encrypted files readable without a prompt. The root command does
+// not see the flags of the completed line.
+func prepare(cmd *cobra.Command, cfg *config.Config) error {
+	if list, err := cmd.Flags().GetString("list"); err == nil && list != "" && list != cfg.List {
+		if err := cfg.UseList(list); err != nil {
+			return err
+		}
+	}
+	if cfg.Encryption.Enabled {
+		storage.SetSecretProvider(security.NonInteractiveSecretProvider(cfg))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package completion
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func TestTaskAndRewardIDs(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			TodoFile:   filepath.Join(dir, "todo.json"),
+			RewardFile: filepath.Join(dir, "rewards.json"),
+		},
+	}
+	todoList := &models.TodoList{
+		Tasks: []models.Task{
+			{ID: 1, Text: "Buy milk"},
+			{ID: 3, Text: "Walk", IsComplete: true},
+		},
+		NextID: 4,
+	}
+	rewardSystem := &models.RewardSystem{
+		Rewards: []models.Reward{
+			{ID: 1, Description: "Tea", PriceOfReward: 10},
+			{ID: 2, Description: "Cinema", PriceOfReward: 50},
+		},
+		UserPoints: 20,
+		NextID:     3,
+	}
+	if err := storage.Save(cfg.Storage.TodoFile, todoList); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if err := storage.Save(cfg.Storage.RewardFile, rewardSystem); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	cmd := &cobra.Command{Use: "test"}
+	tests := []struct {
+		name string
+		fn   cobra.CompletionFunc
+		args []string
+		want []string
+	}{
+		{"open tasks", TaskIDs(cfg, func(task models.Task) bool { return !task.IsComplete }), nil, []string{"1\tBuy milk"}},
+		{"all tasks", TaskIDs(cfg, nil), nil, []string{"1\tBuy milk", "3\tWalk"}},
+		{"second argument", TaskIDs(cfg, nil), []string{"1"}, nil},
+		{"affordable rewards", RewardIDs(cfg, func(reward models.Reward, balance int) bool {
+			return reward.PriceOfReward <= balance
+		}), nil, []string{"1\tTea (10 points)"}},
+		{"all rewards", RewardIDs(cfg, nil), nil, []string{"1\tTea (10 points)", "2\tCinema (50 points)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, directive := tt.fn(cmd, tt.args, "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if directive != cobra.ShellCompDirectiveNoFileComp {
+				t.Errorf("Expected no file completion, got directive %d", directive)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package interactive
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/shell"
+	"github.com/svetsed/todo_cli_app/internal/tui"
+)
+
+func TuiCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tui",
+		Short: "Work with tasks and rewards in a full-screen terminal UI",
+		Long: "Open a full-screen UI with the tasks and the reward shop of the list.\n" +
+			"Move with the arrows or j/k, switch between tasks and rewards with Tab,\n" +
+			"complete with Space, buy with b, add with a, edit with e and p, set the due date with t,\n" +
+			"delete with d, filter with /, undo with u and quit with q.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			m, err := tui.New(cfg)
+			if err != nil {
+				logger.Error("could not load data", err)
+				return
+			}
+			if err := tui.Run(m, os.Stdin, os.Stdout); err != nil {
+				logger.Error("tui failed", err)
+				return
+			}
+		},
+	}
+}
+
+// ShellCmd runs the shell with the commands built by commands, the ones of
+// the CLI.
+func ShellCmd(cfg *config.Config, commands func(cfg *config.Config) []*cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "shell",
+		Short: "Run commands one by one on data loaded once and save them together",
+		Long: "Load the list once and read commands of the CLI line by line, e.g. add, list, complete,\n" +
+			"edit, delete, buy-reward or approve, with the same arguments and flags.\n" +
+			"Changes are kept aside until 'commit' writes them all at once; 'rollback' drops them.\n" +
+			"Up and down arrows go through the history, Tab completes commands and IDs.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			s, err := shell.New(cfg, os.Stdout, commands)
+			if err != nil {
+				logger.Error("could not load data", err)
+				return
+			}
+			defer s.Close()
+			if err := s.Run(&tui.LineEditor{}, os.Stdin); err != nil {
+				logger.Error("shell failed", err)
+				return
+			}
+		},
+	}
+}
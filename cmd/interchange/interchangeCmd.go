@@ -0,0 +1,446 @@
+package interchange
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/formats"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+const (
+	formatTodoTxt  = "todotxt"
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+	formatICal     = "ics"
+	formatTW       = "taskwarrior"
+)
+
+var (
+	taskFormats   = []string{formatTodoTxt, formatCSV, formatMarkdown, formatICal, formatTW}
+	rewardFormats = []string{formatCSV, formatMarkdown}
+
+	formatByExt = map[string]string{
+		".txt":      formatTodoTxt,
+		".csv":      formatCSV,
+		".md":       formatMarkdown,
+		".markdown": formatMarkdown,
+		".ics":      formatICal,
+		".json":     formatTW,
+	}
+)
+
+func ExportCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "export [flags]",
+		Short: "Write tasks in another format to stdout or to a file",
+		Long:  "Write tasks in another format to stdout or to a file.\nSupported formats: " + strings.Join(taskFormats, ", "),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, format, err := exportFlags(cmd, taskFormats)
+			if err != nil {
+				logger.Error("could not parse flags of export command", err)
+				return
+			}
+
+			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+			if err != nil {
+				logger.Error("export command failed", err)
+				return
+			}
+
+			err = export(cmd, output, func(w io.Writer) error {
+				return writeTasks(w, format, todoList.Tasks)
+			})
+			if err != nil {
+				logger.Error("failed to export tasks", err, slog.String("format", format))
+				return
+			}
+
+			if output != "" {
+				logger.Info("tasks exported", slog.String("file", output), slog.Int("count", len(todoList.Tasks)))
+				fmt.Printf("Exported %d tasks to %s\n", len(todoList.Tasks), output)
+			}
+		},
+	}
+}
+
+func ExportRewardCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reward [flags]",
+		Short: "Write rewards in another format to stdout or to a file",
+		Long:  "Write rewards in another format to stdout or to a file.\nSupported formats: " + strings.Join(rewardFormats, ", "),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			output, format, err := exportFlags(cmd, rewardFormats)
+			if err != nil {
+				logger.Error("could not parse flags of export reward command", err)
+				return
+			}
+
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("export reward command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.UpdateIsAvailableRewards()
+
+			err = export(cmd, output, func(w io.Writer) error {
+				return writeRewards(w, format, r.RSystem.Rewards)
+			})
+			if err != nil {
+				logger.Error("failed to export rewards", err, slog.String("format", format))
+				return
+			}
+
+			if output != "" {
+				logger.Info("rewards exported", slog.String("file", output), slog.Int("count", len(r.RSystem.Rewards)))
+				fmt.Printf("Exported %d rewards to %s\n", len(r.RSystem.Rewards), output)
+			}
+		},
+	}
+}
+
+func ImportCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file> [flags]",
+		Short: "Add tasks from a file in another format to the list",
+		Long: "Add tasks from a file in another format to the list. Imported tasks get new IDs.\n" +
+			"Tasks with the same text as an existing one are skipped unless --keep-duplicates is given.\n" +
+			"Supported formats: " + strings.Join(taskFormats, ", "),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := args[0]
+			format, dryRun, keepDuplicates, err := importFlags(cmd, path, taskFormats)
+			if err != nil {
+				logger.Error("could not parse flags of import command", err)
+				return
+			}
+
+			var imported []models.Task
+			err = readFile(path, func(r io.Reader) (err error) {
+				imported, err = readTasks(r, format, cfg.Defaults.TaskPoints)
+				return err
+			})
+			if err != nil {
+				logger.Error("failed to read tasks", err, slog.String("file", path), slog.String("format", format))
+				return
+			}
+
+			// Tasks that came from another app with a UID update the task
+			// imported with it before instead of being added again.
+			// Completing or reopening an updated task changes the balance, so
+			// both files are locked.
+			var items []importItem
+			err = storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				h := &handlers.TaskHandler{Todo: todoList}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				items = make([]importItem, 0, len(imported))
+				for _, task := range imported {
+					item := importItem{text: task.Text, points: task.TaskPoints}
+					if i := h.IndexByUID(task.UID); i != -1 {
+						if err := h.Replace(i, task, r); err != nil {
+							return fmt.Errorf("failed to update task %d: %w", h.Todo.Tasks[i].ID, err)
+						}
+						item.action = actionUpdate
+					} else {
+						item.action = duplicateAction(h.HasTask(task.Text), keepDuplicates)
+						if item.action != actionSkip {
+							h.Put(task)
+						}
+					}
+					items = append(items, item)
+				}
+				if dryRun {
+					return nil
+				}
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after import: %w", err)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after import: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("import command failed", err)
+				return
+			}
+
+			added, updated, skipped := countImport(items)
+			if dryRun {
+				printPreview(cmd.OutOrStdout(), items)
+				fmt.Printf("Would import %d tasks, update %d, skip %d duplicates\n", added, updated, skipped)
+				return
+			}
+
+			logger.Info("tasks imported", slog.String("file", path), slog.Int("added", added), slog.Int("updated", updated))
+			fmt.Printf("Imported %d tasks from %s, updated %d, skipped %d duplicates\n", added, path, updated, skipped)
+		},
+	}
+}
+
+func ImportRewardCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reward <file> [flags]",
+		Short: "Add rewards from a file in another format",
+		Long: "Add rewards from a file in another format. Imported rewards get new IDs.\n" +
+			"Rewards with the same description as an existing one are skipped unless --keep-duplicates is given.\n" +
+			"Supported formats: " + strings.Join(rewardFormats, ", "),
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			path := args[0]
+			format, dryRun, keepDuplicates, err := importFlags(cmd, path, rewardFormats)
+			if err != nil {
+				logger.Error("could not parse flags of import reward command", err)
+				return
+			}
+
+			var imported []models.Reward
+			err = readFile(path, func(r io.Reader) (err error) {
+				imported, err = readRewards(r, format, cfg.Defaults.RewardPrice)
+				return err
+			})
+			if err != nil {
+				logger.Error("failed to read rewards", err, slog.String("file", path), slog.String("format", format))
+				return
+			}
+
+			var items []importItem
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				items = make([]importItem, 0, len(imported))
+				for _, reward := range imported {
+					item := importItem{text: reward.Description, points: reward.PriceOfReward}
+					item.action = duplicateAction(r.HasReward(reward.Description), keepDuplicates)
+					if item.action != actionSkip {
+						r.AddReward(reward.Description, reward.PriceOfReward)
+					}
+					items = append(items, item)
+				}
+				if dryRun {
+					return nil
+				}
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save rewards after import: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("import reward command failed", err)
+				return
+			}
+
+			added, _, skipped := countImport(items)
+			if dryRun {
+				printPreview(cmd.OutOrStdout(), items)
+				fmt.Printf("Would import %d rewards, skip %d duplicates\n", added, skipped)
+				return
+			}
+
+			logger.Info("rewards imported", slog.String("file", path), slog.Int("count", added))
+			fmt.Printf("Imported %d rewards from %s, skipped %d duplicates\n", added, path, skipped)
+		},
+	}
+}
+
+const (
+	actionAdd       = "add"
+	actionDuplicate = "add (duplicate)"
+	actionSkip      = "skip (duplicate)"
+	actionUpdate    = "update"
+)
+
+// importItem is one line of the --dry-run preview.
+type importItem struct {
+	action string
+	text   string
+	points int
+}
+
+func duplicateAction(duplicate, keepDuplicates bool) string {
+	switch {
+	case !duplicate:
+		return actionAdd
+	case keepDuplicates:
+		return actionDuplicate
+	}
+	return actionSkip
+}
+
+func countImport(items []importItem) (added, updated, skipped int) {
+	for _, item := range items {
+		switch item.action {
+		case actionSkip:
+			skipped++
+		case actionUpdate:
+			updated++
+		default:
+			added++
+		}
+	}
+	return added, updated, skipped
+}
+
+func printPreview(writer io.Writer, items []importItem) {
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Action\tText\tPoints\n")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", item.action, item.text, item.points)
+	}
+	w.Flush()
+}
+
+func exportFlags(cmd *cobra.Command, supported []string) (output, format string, err error) {
+	if output, err = cmd.Flags().GetString("output"); err != nil {
+		return "", "", err
+	}
+	format, err = formatFlag(cmd, output, supported)
+	return output, format, err
+}
+
+func importFlags(cmd *cobra.Command, path string, supported []string) (format string, dryRun, keepDuplicates bool, err error) {
+	if format, err = formatFlag(cmd, path, supported); err != nil {
+		return "", false, false, err
+	}
+	if dryRun, err = cmd.Flags().GetBool("dry-run"); err != nil {
+		return "", false, false, err
+	}
+	if keepDuplicates, err = cmd.Flags().GetBool("keep-duplicates"); err != nil {
+		return "", false, false, err
+	}
+	return format, dryRun, keepDuplicates, nil
+}
+
+// formatFlag returns the --format flag or guesses the format from the file
+// extension. Without a file the first supported format is used.
+func formatFlag(cmd *cobra.Command, path string, supported []string) (string, error) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return "", err
+	}
+
+	if format == "" {
+		if path == "" {
+			return supported[0], nil
+		}
+		var ok bool
+		if format, ok = formatByExt[strings.ToLower(filepath.Ext(path))]; !ok {
+			return "", fmt.Errorf("could not guess format of %s, use --format", path)
+		}
+	}
+
+	for _, s := range supported {
+		if format == s {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(supported, ", "))
+}
+
+// export writes to the output file or to stdout if there is no file.
+func export(cmd *cobra.Command, output string, write func(io.Writer) error) error {
+	if output == "" {
+		return write(cmd.OutOrStdout())
+	}
+
+	file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readFile(path string, read func(io.Reader) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return read(file)
+}
+
+func writeTasks(w io.Writer, format string, tasks []models.Task) error {
+	switch format {
+	case formatTodoTxt:
+		return formats.WriteTodoTxt(w, tasks)
+	case formatCSV:
+		return formats.WriteTasksCSV(w, tasks)
+	case formatMarkdown:
+		return formats.WriteTasksMarkdown(w, tasks)
+	case formatICal:
+		return formats.WriteTasksICal(w, tasks)
+	case formatTW:
+		return formats.WriteTasksTaskwarrior(w, tasks)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func readTasks(r io.Reader, format string, defaultPoints int) ([]models.Task, error) {
+	switch format {
+	case formatTodoTxt:
+		return formats.ParseTodoTxt(r, defaultPoints)
+	case formatCSV:
+		return formats.ParseTasksCSV(r, defaultPoints)
+	case formatMarkdown:
+		return formats.ParseTasksMarkdown(r, defaultPoints)
+	case formatICal:
+		return formats.ParseTasksICal(r, defaultPoints)
+	case formatTW:
+		return formats.ParseTasksTaskwarrior(r, defaultPoints)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func writeRewards(w io.Writer, format string, rewards []models.Reward) error {
+	switch format {
+	case formatCSV:
+		return formats.WriteRewardsCSV(w, rewards)
+	case formatMarkdown:
+		return formats.WriteRewardsMarkdown(w, rewards)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func readRewards(r io.Reader, format string, defaultPrice int) ([]models.Reward, error) {
+	switch format {
+	case formatCSV:
+		return formats.ParseRewardsCSV(r, defaultPrice)
+	case formatMarkdown:
+		return formats.ParseRewardsMarkdown(r, defaultPrice)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
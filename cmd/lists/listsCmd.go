@@ -0,0 +1,85 @@
+package lists
+
+import (
+	"fmt"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+func ListsCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "lists",
+		Short: "Show all lists and which one is used now",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Current\tList\tTodo file\tReward file\n")
+			for _, name := range cfg.ListNames() {
+				storage, err := cfg.ListStorage(name)
+				if err != nil {
+					logger.Error("could not resolve list files", err, slog.String("list", name))
+					continue
+				}
+
+				mark := " "
+				if name == cfg.List {
+					mark = "*"
+				}
+				fmt.Fprintf(w, "   %s\t%s\t%s\t%s\n", mark, name, storage.TodoFile, storage.RewardFile)
+			}
+			w.Flush()
+		},
+	}
+}
+
+func AddListCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name>",
+		Short: "Create a new list with its own tasks and rewards",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if err := config.ValidateListName(name); err != nil {
+				logger.Error("incorrect list name", err, slog.String("command", "lists add"))
+				return
+			}
+			if cfg.HasList(name) {
+				logger.Error("could not add list", fmt.Errorf("list %q already exists", name))
+				return
+			}
+
+			if err := cfg.AddList(name); err != nil {
+				logger.Error("failed to save config file", err)
+			} else {
+				logger.Info("list has been added", slog.String("list", name))
+				fmt.Printf("Added list: %s (switch to it with 'todo lists use %s')\n", name, name)
+			}
+		},
+	}
+}
+
+func UseListCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "use <name>",
+		Short: "Make the list current for all next commands",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			name := args[0]
+			if !cfg.HasList(name) {
+				logger.Error("could not switch list", fmt.Errorf("unknown list %q", name))
+				return
+			}
+
+			if err := cfg.Set("current_list", name); err != nil {
+				logger.Error("failed to save config file", err)
+			} else {
+				logger.Info("current list has been changed", slog.String("list", name))
+				fmt.Printf("Now current list: %s\n", name)
+			}
+		},
+	}
+}
@@ -0,0 +1,108 @@
+package rewards
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+func GoalCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "goal",
+		Short: "Show the progress of the savings goal",
+		Long: "Save points for an expensive reward: after 'todo goal set <ID>' incoming points are\n" +
+			"reserved for it and can not be spent on other rewards until 'todo goal release'.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("goal command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.ShowGoal(cmd.OutOrStdout())
+		},
+	}
+}
+
+func GoalSetCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <ID>",
+		Short: "Save incoming points for the reward",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var reward models.Reward
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+				if err := r.SetGoal(rewardIndexElem); err != nil {
+					return err
+				}
+				reward = r.RSystem.Rewards[rewardIndexElem]
+
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
+			if err != nil {
+				logger.Error("goal set command failed", err)
+				return
+			}
+
+			logger.Info("goal has been set", slog.Int("reward_id", reward.ID))
+			fmt.Fprintf(cmd.OutOrStdout(), "Saving for reward %d. %s (%d points), incoming points are reserved for it\n",
+				reward.ID, reward.Description, reward.PriceOfReward)
+		},
+	}
+}
+
+func GoalReleaseCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "release",
+		Short: "Drop the goal, its reserved points can be spent again",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var goal models.Goal
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				if goal, err = r.ReleaseGoal(); err != nil {
+					return err
+				}
+				r.UpdateIsAvailableRewards()
+
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
+			if err != nil {
+				logger.Error("goal release command failed", err)
+				return
+			}
+
+			logger.Info("goal has been released", slog.Int("reward_id", goal.RewardID))
+			fmt.Fprintf(cmd.OutOrStdout(), "Goal released, %d reserved points can be spent again\n", goal.Reserved)
+		},
+	}
+}
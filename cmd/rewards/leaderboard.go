@@ -0,0 +1,30 @@
+package rewards
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+func LeaderboardCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "leaderboard",
+		Short: "Show the users of the list by XP",
+		Long: "Show everybody who shares the list: XP, the points they have now and the completed\n" +
+			"tasks. Tasks are assigned with 'todo add --assign <user>', who you are is set by\n" +
+			"'todo config set user <name>' or TODO_USER.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("leaderboard command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.ShowLeaderboard(cmd.OutOrStdout())
+		},
+	}
+}
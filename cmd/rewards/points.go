@@ -0,0 +1,129 @@
+package rewards
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func PointsCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "points [flags]",
+		Short: "Show the balance of points and how it changed",
+		Long: "Show the balance and the last changes of it: points for tasks, bought rewards,\n" +
+			"bonuses and penalties. Penalties for overdue tasks are charged here too, they are\n" +
+			"set in the config, e.g. 'todo config set penalties.overdue 10'.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			last := 20
+			if flag := cmd.Flags().Lookup("last"); flag != nil {
+				last, _ = cmd.Flags().GetInt("last")
+			}
+			if last < 0 {
+				logger.Error("incorrect count of entries", fmt.Errorf("--last must not be negative"))
+				return
+			}
+
+			var r *handlers.RewardHandler
+			err := storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				h := &handlers.TaskHandler{Todo: todoList}
+				r = &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				if tasks, _ := h.PenalizeOverdue(r, time.Now()); tasks == 0 {
+					return nil
+				}
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after the penalties: %w", err)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after the penalties: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("points command failed", err)
+				return
+			}
+
+			for _, notice := range r.Notices {
+				fmt.Fprintln(cmd.OutOrStdout(), notice)
+			}
+			r.ShowPoints(cmd.OutOrStdout(), last)
+		},
+	}
+}
+
+func PointsGiveCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "give <user> <amount> [flags]",
+		Short: "Give your points to another user",
+		Long: "Give your points to another user. A transfer of more than approval.transfers_above\n" +
+			"points waits for an approver, 'todo approve <request-id>', with the points held.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			amount, err := strconv.Atoi(args[1])
+			if err != nil {
+				logger.Error("incorrect count of points", fmt.Errorf("amount must be a number: %w", err))
+				return
+			}
+			note := ""
+			if flag := cmd.Flags().Lookup("note"); flag != nil {
+				note = strings.TrimSpace(flag.Value.String())
+			}
+
+			var (
+				r       *handlers.RewardHandler
+				request models.Request
+				pending bool
+			)
+			// Both balances are in the reward file, so one update moves the
+			// points at once.
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r = &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				if request, pending, err = r.Give(args[0], amount, note); err != nil {
+					return err
+				}
+				r.UpdateIsAvailableRewards()
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
+			if err != nil {
+				logger.Error("points give command failed", err)
+				return
+			}
+
+			if pending {
+				logger.Info("transfer waits for approval", slog.Int("request_id", request.ID), slog.Int("points", amount))
+				fmt.Fprintf(cmd.OutOrStdout(), "Request %d: %d points to %s wait for approval, the points are held\n",
+					request.ID, amount, request.To)
+				return
+			}
+			logger.Info("points were given", slog.String("to", request.To), slog.Int("points", amount))
+			fmt.Fprintf(cmd.OutOrStdout(), "Gave %d points to %s\n", amount, request.To)
+			fmt.Fprintf(cmd.OutOrStdout(), "Now your balance: %d\n", r.RSystem.UserPoints)
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package rewards
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+func ProfileCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "profile",
+		Short: "Show the level, the XP and the achievements",
+		Long: "XP is earned together with points for completed tasks and streak bonuses, but it is\n" +
+			"never spent on rewards. It gives the level, achievements are unlocked along the way.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("profile command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.ShowProfile(cmd.OutOrStdout(), time.Now())
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package rewards
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func RequestsCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "requests",
+		Short: "Show the pending requests for rewards and transfers",
+		Long: "Show the requests waiting for an approver: rewards marked with --requires-approval and\n" +
+			"transfers above approval.transfers_above. Their points are held until\n" +
+			"'todo approve <request-id>' or 'todo deny <request-id>'.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("requests command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.ShowRequests(cmd.OutOrStdout())
+		},
+	}
+}
+
+func ApproveCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "approve <request-id>",
+		Short: "Approve a pending request of another user",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request, err := decideRequest(cfg, args[0], (*handlers.RewardHandler).Approve)
+			if err != nil {
+				logger.Error("approve command failed", err)
+				return
+			}
+
+			logger.Info("request was approved", slog.Int("request_id", request.ID))
+			fmt.Fprintf(cmd.OutOrStdout(), "Request %d of %s approved: %s\n", request.ID, request.From, handlers.DescribeRequest(request))
+		},
+	}
+}
+
+func DenyCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "deny <request-id>",
+		Short: "Deny a pending request, its points go back",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			request, err := decideRequest(cfg, args[0], (*handlers.RewardHandler).Deny)
+			if err != nil {
+				logger.Error("deny command failed", err)
+				return
+			}
+
+			logger.Info("request was denied", slog.Int("request_id", request.ID))
+			fmt.Fprintf(cmd.OutOrStdout(), "Request %d denied, %d points went back to %s\n", request.ID, request.Points, request.From)
+		},
+	}
+}
+
+// decideRequest approves or denies the request with the ID and saves the
+// balances of both users at once.
+func decideRequest(cfg *config.Config, idString string, decide func(*handlers.RewardHandler, int) (models.Request, error)) (models.Request, error) {
+	id, err := strconv.Atoi(idString)
+	if err != nil || id < 1 {
+		return models.Request{}, fmt.Errorf("incorrect request id %s", idString)
+	}
+
+	var request models.Request
+	err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+		if err != nil {
+			return err
+		}
+
+		r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+		if request, err = decide(r, id); err != nil {
+			return err
+		}
+		r.UpdateIsAvailableRewards()
+		return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+	})
+	return request, err
+}
@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/svetsed/todo_cli_app/internal/config"
 	"github.com/svetsed/todo_cli_app/internal/handlers"
 	"github.com/svetsed/todo_cli_app/internal/loaders"
 	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
 	"github.com/svetsed/todo_cli_app/internal/storage"
 	"github.com/svetsed/todo_cli_app/internal/utils"
 )
@@ -21,14 +23,6 @@ func AddRewardCmd(cfg *config.Config) *cobra.Command {
 		Long:  "Add a new reward with optional points, what you will receive after completing the task",
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("add reward command failed", err)
-				return
-			}
-
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
-
 			var price int = cfg.Defaults.RewardPrice
 			if cmd.Flags().Changed("price") {
 				if priceTmp, err := cmd.Flags().GetInt("price"); err != nil {
@@ -44,14 +38,55 @@ func AddRewardCmd(cfg *config.Config) *cobra.Command {
 
 			desrc := strings.Join(args, " ")
 
-			r.AddReward(desrc, price)
+			stock, restock, err := readStockFlags(cmd)
+			if err != nil {
+				logger.Error("could not parse stock flags in add reward command", err)
+				return
+			}
 
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after reward addition", err, slog.String("file", cfg.Storage.RewardFile))
-			} else {
-				logger.Info("added new reward", slog.Int("id", r.RSystem.NextID-1))
-				fmt.Printf("Added reward: %d. %s (with price %d points)\n", r.RSystem.NextID-1, desrc, price)
+			cooldown, limit, period, err := readLimitFlags(cmd)
+			if err != nil {
+				logger.Error("could not parse limit flags in add reward command", err)
+				return
+			}
+
+			var id int
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				r.AddReward(desrc, price)
+				if cooldown != "" || limit > 0 {
+					r.SetLimits(len(r.RSystem.Rewards)-1, cooldown, limit, period)
+				}
+				if stock >= 0 {
+					r.SetStock(len(r.RSystem.Rewards)-1, stock, restock)
+				}
+				if singleUse := cmd.Flags().Lookup("single-use"); singleUse != nil && singleUse.Value.String() == "true" {
+					r.RSystem.Rewards[len(r.RSystem.Rewards)-1].SingleUse = true
+				}
+				if approval := cmd.Flags().Lookup("requires-approval"); approval != nil && approval.Value.String() == "true" {
+					if err := r.SetRequiresApproval(len(r.RSystem.Rewards)-1, true); err != nil {
+						return err
+					}
+				}
+				id = r.RSystem.Rewards[len(r.RSystem.Rewards)-1].ID
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after reward addition: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("add reward command failed", err)
+				return
 			}
+
+			logger.Info("added new reward", slog.Int("id", id))
+			fmt.Printf("Added reward: %d. %s (with price %d points)\n", id, desrc, price)
 		},
 	}
 }
@@ -61,20 +96,39 @@ func ListRewardCmd(cfg *config.Config) *cobra.Command {
 		Use:   "reward",
 		Short: "Show all reward and your balance of points",
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
 			if err != nil {
 				logger.Error("list reward command failed", err)
 				return
 			}
 
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			if uuidFlag := cmd.Flags().Lookup("uuid"); uuidFlag != nil && uuidFlag.Value.String() == "true" {
+				r.ListRewardUIDs(cmd.OutOrStdout())
+				return
+			}
 
-			needSave := r.RSystem.IsUserPointsUpdate
+			needSave := r.Restock(time.Now()) || r.RSystem.IsUserPointsUpdate
 			r.ListReward(cmd.OutOrStdout())
-			if needSave {
-				if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-					logger.Error("failed to save reward file after show all reward", err, slog.String("file", cfg.Storage.RewardFile))
+			if !needSave {
+				return
+			}
+			// The restock is saved on the data as it is now, the list was
+			// shown without holding the lock.
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
 				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				if r.Restock(time.Now()) || r.RSystem.IsUserPointsUpdate {
+					r.UpdateIsAvailableRewards()
+					return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("failed to save reward file after show all reward", err, slog.String("file", cfg.Storage.RewardFile))
 			}
 		},
 	}
@@ -87,42 +141,63 @@ func BuyRewardCmd(cfg *config.Config) *cobra.Command {
 		Long:  "Buy the existing reward, if your balance of points more or equals the price of reward",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("buy-reward command failed", err)
-				return
-			}
+			var (
+				r       *handlers.RewardHandler
+				id      int
+				reward  models.Reward
+				request models.Request
+			)
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
 
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
+				r = &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
 
-			id, err := utils.ValidateID(args[0], r.RSystem.NextID-1)
-			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "buy-reward"))
-				return
-			}
+				if id, err = utils.ValidateID(args[0], r.RSystem.Rewards); err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
 
-			var rewardIndexElem int
-			if i, err := utils.CheckExistItem(id, r.RSystem.Rewards); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "buy-reward"))
-				return
-			} else {
-				rewardIndexElem = i
-			}
+				// The points for a reward which needs approval are held until
+				// an approver decides.
+				if r.NeedsApproval(rewardIndexElem) {
+					if request, err = r.RequestReward(rewardIndexElem); err != nil {
+						fmt.Printf("The reward is not yet available: %v\n", err)
+						return fmt.Errorf("the reward is not yet available: %w", err)
+					}
+				} else if reward, err = r.BuyRewards(rewardIndexElem); err != nil {
+					fmt.Printf("The reward is not yet available: %v\n", err)
+					return fmt.Errorf("the reward is not yet available: %w", err)
+				}
 
-			if err := r.BuyRewards(rewardIndexElem); err != nil {
-				logger.Error("the reward is not yet available", err)
-				fmt.Printf("The reward is not yet available: %v\n", err)
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after buying reward: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("buy-reward command failed", err)
 				return
 			}
 
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after buying reward", err, slog.String("file", cfg.Storage.RewardFile))
+			if request.ID != 0 {
+				logger.Info("reward waits for approval", slog.Int("reward_id", id), slog.Int("request_id", request.ID))
+				fmt.Printf("Request %d: %s waits for approval, %d points are held\n", request.ID, request.Reward, request.Points)
+				fmt.Printf("Now your balance: %d\n", r.RSystem.UserPoints)
 				return
 			}
 
 			fmt.Println("Good Job! Here is your reward! Enjoy!")
-			fmt.Printf("Receive reward: %s\n", r.RSystem.Rewards[rewardIndexElem].Description)
+			fmt.Printf("Receive reward: %s\n", reward.Description)
 			fmt.Printf("Now your balance: %d\n", r.RSystem.UserPoints)
+			for _, notice := range r.Notices {
+				fmt.Println(notice)
+			}
 			logger.Info("receive reward", slog.Int("reward_id", id), slog.Int("balance", r.RSystem.UserPoints))
 
 		},
@@ -135,87 +210,332 @@ func EditRewardDescrCmd(cfg *config.Config) *cobra.Command {
 		Short: "Edits the description of an existing reward",
 		Args:  cobra.MinimumNArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
+			newDescr := strings.Join(args[1:], " ")
+
+			var reward models.Reward
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+
+				r.EditDesrcRewards(rewardIndexElem, newDescr)
+				reward = r.RSystem.Rewards[rewardIndexElem]
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after editing description of an existing reward: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
 				logger.Error("edit redescr command failed", err)
 				return
 			}
 
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
+			logger.Info("desription of reward has been changed", slog.Int("id", reward.ID))
+			fmt.Printf("Description of reward has been changed: %d. %s with price %d points\n", reward.ID, reward.Description, reward.PriceOfReward)
+		},
+	}
+}
 
-			id, err := utils.ValidateID(args[0], r.RSystem.NextID-1)
+func EditRewardPriceCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reprice <ID> <new price>",
+		Short: "Edits the price of an existing reward",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			newPrice, err := utils.ValidatePointsOrPrice(args[1])
 			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "edit redesrc"))
+				logger.Error("incorrect number for price of reward", err, slog.String("command", "edit reprice"))
 				return
 			}
 
-			var rewardIndexElem int
-			if i, err := utils.CheckExistItem(id, r.RSystem.Rewards); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "edit redesrc"))
+			var reward models.Reward
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+
+				r.EditPriceRewards(rewardIndexElem, newPrice)
+				reward = r.RSystem.Rewards[rewardIndexElem]
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after editing price of an existing reward: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("edit reprice command failed", err)
 				return
-			} else {
-				rewardIndexElem = i
 			}
 
-			newDescr := strings.Join(args[1:], " ")
+			logger.Info("price of reward has been changed", slog.Int("reward_id", reward.ID))
+			fmt.Printf("Price of reward has been changed: %d. %s with new price %d points\n", reward.ID, reward.Description, reward.PriceOfReward)
+		},
+	}
+}
+
+func EditRewardStockCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "stock <ID> <count|unlimited> [flags]",
+		Short: "Limits how many times the reward can be bought",
+		Long: "Set how many times the reward can still be bought, 'unlimited' removes the limit.\n" +
+			"With --restock week or month the stock is filled up again every week or month.",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			var (
+				r       *handlers.RewardHandler
+				reward  models.Reward
+				stock   = -1
+				restock string
+			)
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+				r = &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+
+				if args[1] != "unlimited" {
+					if stock, err = utils.ValidatePointsOrPrice(args[1]); err != nil {
+						return fmt.Errorf("incorrect stock of reward: %w", err)
+					}
+					if _, restock, err = readStockFlags(cmd); err != nil {
+						return err
+					}
+					if !cmd.Flags().Changed("restock") {
+						restock = r.RSystem.Rewards[rewardIndexElem].Restock
+					}
+				}
+				r.SetStock(rewardIndexElem, stock, restock)
+				if cmd.Flags().Changed("single-use") {
+					r.RSystem.Rewards[rewardIndexElem].SingleUse, _ = cmd.Flags().GetBool("single-use")
+				}
+				reward = r.RSystem.Rewards[rewardIndexElem]
+
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
+			if err != nil {
+				logger.Error("edit stock command failed", err)
+				return
+			}
 
-			r.EditDesrcRewards(rewardIndexElem, newDescr)
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after editing description of an existing reward", err, slog.String("file", cfg.Storage.RewardFile))
+			logger.Info("stock of reward has been changed", slog.Int("reward_id", reward.ID))
+			if stock < 0 {
+				fmt.Printf("Reward %d. %s can be bought without limit\n", reward.ID, reward.Description)
+			} else if restock != "" {
+				fmt.Printf("Stock of reward %d. %s: %d, filled up every %s\n", reward.ID, reward.Description, stock, restock)
 			} else {
-				logger.Info("desription of reward has been changed", slog.Int("id", id))
-				fmt.Printf("Description of reward has been changed: %d. %s with price %d points\n", id, r.RSystem.Rewards[rewardIndexElem].Description, r.RSystem.Rewards[rewardIndexElem].PriceOfReward)
+				fmt.Printf("Stock of reward %d. %s: %d\n", reward.ID, reward.Description, stock)
 			}
 		},
 	}
 }
 
-func EditRewardPriceCmd(cfg *config.Config) *cobra.Command {
+func EditRewardApprovalCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "reprice <ID> <new price>",
-		Short: "Edits the price of an existing reward",
+		Use:   "approval <ID> <on|off>",
+		Short: "Set whether buying the reward needs approval",
+		Long:  "With approval on, buy-reward makes a request and holds the points until an approver\nruns 'todo approve <request-id>' or 'todo deny <request-id>'.",
 		Args:  cobra.ExactArgs(2),
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("edit reprice command failed", err)
+			var on bool
+			switch strings.ToLower(args[1]) {
+			case "on":
+				on = true
+			case "off":
+			default:
+				logger.Error("edit approval command failed", fmt.Errorf("approval must be on or off, got %q", args[1]))
 				return
 			}
 
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
+			var reward models.Reward
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+				if err := r.SetRequiresApproval(rewardIndexElem, on); err != nil {
+					return err
+				}
+				reward = r.RSystem.Rewards[rewardIndexElem]
 
-			id, err := utils.ValidateID(args[0], r.RSystem.NextID-1)
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
 			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "edit reprice"))
+				logger.Error("edit approval command failed", err)
 				return
 			}
 
-			var rewardIndexElem int
-			if i, err := utils.CheckExistItem(id, r.RSystem.Rewards); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "edit reprice"))
-				return
+			logger.Info("approval of reward has been changed", slog.Int("reward_id", reward.ID), slog.Bool("requires_approval", on))
+			if on {
+				fmt.Printf("Reward %d. %s needs approval now\n", reward.ID, reward.Description)
 			} else {
-				rewardIndexElem = i
+				fmt.Printf("Reward %d. %s can be bought without approval\n", reward.ID, reward.Description)
 			}
+		},
+	}
+}
 
-			newPrice, err := utils.ValidatePointsOrPrice(args[1])
+// readStockFlags reads --stock and --restock. The stock is -1 when it is
+// not given, a restock needs a stock.
+func readStockFlags(cmd *cobra.Command) (int, string, error) {
+	stock := -1
+	if flag := cmd.Flags().Lookup("stock"); flag != nil && flag.Changed {
+		value, err := utils.ValidatePointsOrPrice(flag.Value.String())
+		if err != nil {
+			return -1, "", fmt.Errorf("incorrect stock of reward: %w", err)
+		}
+		stock = value
+	}
+
+	var restock string
+	if flag := cmd.Flags().Lookup("restock"); flag != nil && flag.Changed && flag.Value.String() != "none" {
+		period, err := utils.ParsePeriod(flag.Value.String())
+		if err != nil {
+			return -1, "", err
+		}
+		if period == utils.PeriodDay {
+			return -1, "", fmt.Errorf("rewards are restocked every week or month")
+		}
+		if stock < 0 && cmd.Flags().Lookup("stock") != nil {
+			return -1, "", fmt.Errorf("--restock needs --stock")
+		}
+		restock = period
+	}
+	return stock, restock, nil
+}
+
+func EditRewardLimitsCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "limits <ID> [flags]",
+		Short: "Sets the cooldown and the purchase limit of the reward",
+		Long: "Set how long to wait after a purchase with --cooldown (e.g. 12h or 1d) and how many\n" +
+			"times the reward can be bought per day, week or month with --limit (e.g. 2/week).\n" +
+			"'none' removes the cooldown or the limit.",
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			var reward models.Reward
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+
+				id, err := utils.ValidateID(args[0], r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+
+				cooldown, limit, period, err := readLimitFlags(cmd)
+				if err != nil {
+					return err
+				}
+				current := r.RSystem.Rewards[rewardIndexElem]
+				if !cmd.Flags().Changed("cooldown") {
+					cooldown = current.Cooldown
+				}
+				if !cmd.Flags().Changed("limit") {
+					limit, period = current.Limit, current.LimitPeriod
+				}
+				r.SetLimits(rewardIndexElem, cooldown, limit, period)
+				reward = r.RSystem.Rewards[rewardIndexElem]
+
+				return tx.Save(cfg.Storage.RewardFile, r.RSystem)
+			})
 			if err != nil {
-				logger.Error("incorrect number for price of reward", err, slog.String("command", "edit reprice"))
+				logger.Error("edit limits command failed", err)
 				return
 			}
 
-			r.EditPriceRewards(rewardIndexElem, newPrice)
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after editing price of an existing reward", err, slog.String("file", cfg.Storage.RewardFile))
-			} else {
-				logger.Info("price of reward has been changed", slog.Int("reward_id", id))
-				fmt.Printf("Price of reward has been changed: %d. %s with new price %d points\n", id, r.RSystem.Rewards[rewardIndexElem].Description, r.RSystem.Rewards[rewardIndexElem].PriceOfReward)
+			logger.Info("limits of reward have been changed", slog.Int("reward_id", reward.ID))
+			if reward.Cooldown == "" && reward.Limit == 0 {
+				fmt.Printf("Reward %d. %s has no cooldown and no limit\n", reward.ID, reward.Description)
+				return
 			}
+			fmt.Printf("Limits of reward %d. %s:", reward.ID, reward.Description)
+			if reward.Cooldown != "" {
+				fmt.Printf(" cooldown %s", reward.Cooldown)
+			}
+			if reward.Limit > 0 {
+				fmt.Printf(" at most %d per %s", reward.Limit, reward.LimitPeriod)
+			}
+			fmt.Println()
 		},
 	}
 }
 
-func EditRewardPriceByDefaultCmd() *cobra.Command {
+// readLimitFlags reads --cooldown and --limit, 'none' removes them.
+func readLimitFlags(cmd *cobra.Command) (string, int, string, error) {
+	var cooldown string
+	if flag := cmd.Flags().Lookup("cooldown"); flag != nil && flag.Changed && flag.Value.String() != "none" {
+		if _, err := utils.ParseCooldown(flag.Value.String()); err != nil {
+			return "", 0, "", err
+		}
+		cooldown = flag.Value.String()
+	}
+
+	var (
+		limit  int
+		period string
+	)
+	if flag := cmd.Flags().Lookup("limit"); flag != nil && flag.Changed && flag.Value.String() != "none" {
+		var err error
+		if limit, period, err = utils.ParseLimit(flag.Value.String()); err != nil {
+			return "", 0, "", err
+		}
+	}
+	return cooldown, limit, period, nil
+}
+
+func EditRewardPriceByDefaultCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "repricedef <new price>",
 		Short: "Edits the default price, when you add reward",
@@ -227,9 +547,7 @@ func EditRewardPriceByDefaultCmd() *cobra.Command {
 				return
 			}
 
-			config.EditPriceOfRewardByDefault(newPrice)
-
-			if err := config.SaveConfig(); err != nil {
+			if err := cfg.Set("defaults.reward_price", newPrice); err != nil {
 				logger.Error("failed to save config file", err)
 			} else {
 				logger.Info("price of reward by default has been changed", slog.Int("new price by default", newPrice))
@@ -245,36 +563,31 @@ func DeleteRewardCmd(cfg *config.Config) *cobra.Command {
 		Short: "Delete the reward from list",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
+			forceFlag, err := cmd.Flags().GetBool("force")
 			if err != nil {
-				logger.Error("delete reward command failed", err)
+				logger.Error("could not parse force flag", err, slog.String("flag", "--force"), slog.String("command", "delete reward"))
 				return
 			}
 
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
-
-			id, err := utils.ValidateID(args[0], r.RSystem.NextID-1)
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
 			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "delete reward"))
+				logger.Error("delete reward command failed", err)
 				return
 			}
 
-			var rewardIndexElem int
-			if i, err := utils.CheckExistItem(id, r.RSystem.Rewards); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "delete reward"))
+			id, err := utils.ValidateID(args[0], rewardSystem.Rewards)
+			if err != nil {
+				logger.Error("catch error when checking id", err, slog.String("command", "delete reward"))
 				return
-			} else {
-				rewardIndexElem = i
 			}
-
-			forceFlag, err := cmd.Flags().GetBool("force")
+			rewardIndexElem, err := utils.CheckExistItem(id, rewardSystem.Rewards)
 			if err != nil {
-				logger.Error("could not parse force flag", err, slog.String("flag", "--force"), slog.String("command", "delete reward"))
+				logger.Error("catch error when searching reward by id", err, slog.String("command", "delete reward"))
 				return
 			}
 
 			if !forceFlag {
-				fmt.Printf("You want to delete reward: %d. %s\n", id, r.RSystem.Rewards[rewardIndexElem].Description)
+				fmt.Printf("You want to delete reward: %d. %s\n", id, rewardSystem.Rewards[rewardIndexElem].Description)
 				fmt.Print("Are you sure (y/n): ")
 				var confirm string
 				fmt.Scanln(&confirm)
@@ -284,14 +597,34 @@ func DeleteRewardCmd(cfg *config.Config) *cobra.Command {
 					return
 				}
 			}
-			r.DeleteReward(rewardIndexElem)
 
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after deleting reward", err, slog.String("file", cfg.Storage.RewardFile))
-			} else {
-				logger.Info("the reward was deleted", slog.Int("reward_id", id))
-				fmt.Printf("The reward %d was deleted!\n", id)
+			// The answer is waited for without holding the lock, so the
+			// reward is looked up again in the data as it is now.
+			err = storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				rewardIndexElem, err := utils.CheckExistItem(id, r.RSystem.Rewards)
+				if err != nil {
+					return fmt.Errorf("catch error when searching reward by id: %w", err)
+				}
+				r.DeleteReward(rewardIndexElem)
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after deleting reward: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("delete reward command failed", err)
+				return
 			}
+
+			logger.Info("the reward was deleted", slog.Int("reward_id", id))
+			fmt.Printf("The reward %d was deleted!\n", id)
 		},
 	}
 }
@@ -301,14 +634,6 @@ func ClearRewardCmd(cfg *config.Config) *cobra.Command {
 		Use:   "reward",
 		Short: "Remove all rewards without restore with confirmation",
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("clear reward command failed", err)
-				return
-			}
-
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
-
 			fmt.Print("The rewards cannot be restored!\nAre you sure you want to delete ALL rewards? (y/n): ")
 			var confirm string
 			fmt.Scanln(&confirm)
@@ -318,14 +643,26 @@ func ClearRewardCmd(cfg *config.Config) *cobra.Command {
 				return
 			}
 
-			r.ClearAllRewards()
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
 
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after clearing all rewards", err, slog.String("file", cfg.Storage.RewardFile))
-			} else {
-				fmt.Println("All rewards have been removed!")
-				logger.Info("all rewards have been removed by user")
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				r.ClearAllRewards()
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after clearing all rewards: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("clear reward command failed", err)
+				return
 			}
+			fmt.Println("All rewards have been removed!")
+			logger.Info("all rewards have been removed by user")
 		},
 	}
 }
@@ -335,14 +672,6 @@ func ResetPointsCmd(cfg *config.Config) *cobra.Command {
 		Use:   "resetp",
 		Short: "Reset to zero all your points without restore with confirmation",
 		Run: func(cmd *cobra.Command, args []string) {
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("resetp command failed", err)
-				return
-			}
-
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
-
 			fmt.Print("The count of points cannot be restored!\nAre you sure you want to reset to zero your points? (y/n): ")
 			var confirm string
 			fmt.Scanln(&confirm)
@@ -352,14 +681,27 @@ func ResetPointsCmd(cfg *config.Config) *cobra.Command {
 				return
 			}
 
-			r.ResetPoints()
+			var r *handlers.RewardHandler
+			err := storage.Update([]string{cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
 
-			if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-				logger.Error("failed to save reward file after reseting points to zero", err, slog.String("file", cfg.Storage.RewardFile))
-			} else {
-				fmt.Printf("Now your balance of points: %d\n!", r.RSystem.UserPoints)
-				logger.Info("User reset to zero all your points")
+				r = &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				r.ResetPoints()
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after reseting points to zero: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("resetp command failed", err)
+				return
 			}
+			fmt.Printf("Now your balance of points: %d\n!", r.RSystem.UserPoints)
+			logger.Info("User reset to zero all your points")
 		},
 	}
 }
@@ -25,12 +25,27 @@ func executeCommand(cmd *cobra.Command, args ...string) (string, error) {
 	return strings.TrimSpace(out.String()), err
 }
 
+// TestMain keeps config.LoadConfig away from the real config and data
+// directories of the user.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "todo-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestIntegration_AddRewardCmd_SuccessfullyAddsReward(t *testing.T) {
 	logger.Init(slog.LevelDebug, io.Discard)
 
 	tempDir := t.TempDir()
 	rewardFile := filepath.Join(tempDir, "test_rewards.json")
-	t.Setenv("STORAGE_REWARD_FILE", rewardFile)
+	t.Setenv("TODO_STORAGE_REWARD_FILE", rewardFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -90,7 +105,7 @@ func TestIntegration_ListRewardCmd_ShowsRewards(t *testing.T) {
 
 	tempDir := t.TempDir()
 	rewardFile := filepath.Join(tempDir, "test_rewards.json")
-	t.Setenv("STORAGE_REWARD_FILE", rewardFile)
+	t.Setenv("TODO_STORAGE_REWARD_FILE", rewardFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -134,7 +149,7 @@ func TestIntegration_BuyRewardCmd_SuccessfulPurchase(t *testing.T) {
 
 	tempDir := t.TempDir()
 	rewardFile := filepath.Join(tempDir, "test_rewards.json")
-	t.Setenv("STORAGE_REWARD_FILE", rewardFile)
+	t.Setenv("TODO_STORAGE_REWARD_FILE", rewardFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -184,7 +199,7 @@ func TestIntegration_DeleteRewardCmd_SuccessfulDelete(t *testing.T) {
 
 	tempDir := t.TempDir()
 	rewardFile := filepath.Join(tempDir, "test_rewards.json")
-	t.Setenv("STORAGE_REWARD_FILE", rewardFile)
+	t.Setenv("TODO_STORAGE_REWARD_FILE", rewardFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -0,0 +1,42 @@
+package rewards
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+func StreakCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "streak [flags]",
+		Short: "Show the current and the best streak of days with completed tasks",
+		Long: "Show the streak of days in a row with at least streak.min_tasks completed tasks and\n" +
+			"a calendar of the recent weeks. Bonus points for milestones are set in the config,\n" +
+			"e.g. 'todo config set streak.bonuses.7 50'.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			weeks := 5
+			if flag := cmd.Flags().Lookup("weeks"); flag != nil {
+				weeks, _ = cmd.Flags().GetInt("weeks")
+			}
+			if weeks < 1 {
+				logger.Error("incorrect count of weeks", fmt.Errorf("--weeks must be at least 1"))
+				return
+			}
+
+			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+			if err != nil {
+				logger.Error("streak command failed", err)
+				return
+			}
+
+			r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+			r.ShowStreak(cmd.OutOrStdout(), time.Now(), weeks)
+		},
+	}
+}
@@ -1,45 +1,139 @@
 package cmd
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/cmd/completion"
+	"github.com/svetsed/todo_cli_app/cmd/interactive"
+	"github.com/svetsed/todo_cli_app/cmd/interchange"
+	"github.com/svetsed/todo_cli_app/cmd/lists"
 	"github.com/svetsed/todo_cli_app/cmd/rewards"
+	"github.com/svetsed/todo_cli_app/cmd/security"
+	"github.com/svetsed/todo_cli_app/cmd/server"
+	"github.com/svetsed/todo_cli_app/cmd/settings"
 	"github.com/svetsed/todo_cli_app/cmd/tasks"
 	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/utils"
 )
 
 func RootCmd(cfg *config.Config) *cobra.Command {
-	rootCmd := &cobra.Command{Use: "todo", Short: "A todo list for the terminal"}
+	rootCmd := &cobra.Command{
+		Use:   "todo",
+		Short: "A todo list for the terminal",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Commands that inspect or repair the config must run with a broken one.
+			repairing := settings.AllowsInvalidConfig(cmd)
+			if !repairing {
+				if err := cfg.Validate(); err != nil {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("%w (see 'todo config validate')", err)
+				}
+			}
+
+			list, err := cmd.Flags().GetString("list")
+			if err != nil {
+				return err
+			}
+			if list == "" {
+				list = cfg.CurrentList
+			}
+			if err := cfg.UseList(list); err != nil && !repairing {
+				cmd.SilenceUsage = true
+				return err
+			}
+			return nil
+		},
+	}
+	rootCmd.PersistentFlags().String("list", "", "Name of the list to work with (default is the current list)")
+	_ = rootCmd.RegisterFlagCompletionFunc("list", completion.ListNames(cfg))
+	rootCmd.AddCommand(Commands(cfg)...)
+
+	return rootCmd
+}
+
+// Commands builds the commands of todo with their flags and completion. The
+// shell builds them again for every line it runs, so it has the same
+// grammar as the CLI.
+func Commands(cfg *config.Config) []*cobra.Command {
+	openTasks := completion.TaskIDList(cfg, func(task models.Task) bool { return !task.IsComplete })
+	completedTasks := completion.TaskIDList(cfg, func(task models.Task) bool { return task.IsComplete })
+	allTasks := completion.TaskIDs(cfg, nil)
+	affordableRewards := completion.RewardIDs(cfg, func(reward models.Reward, balance int) bool {
+		now := time.Now()
+		return reward.PriceOfReward <= balance && utils.InStock(reward) && !utils.NextAvailable(reward, now).After(now)
+	})
+	allRewards := completion.RewardIDs(cfg, nil)
 
 	completeCmd := tasks.CompleteCmd(cfg)
+	completeCmd.ValidArgsFunction = openTasks
 	completeCmd.Flags().BoolP("delete", "d", false, "Delete task after completion")
 	completeCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation (only with -d)")
+	tasks.AddBulkFlags(completeCmd)
 
 	deleteRewardCmd := rewards.DeleteRewardCmd(cfg)
+	deleteRewardCmd.ValidArgsFunction = allRewards
 	deleteRewardCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation")
 	deleteCmd := tasks.DeleteCmd(cfg)
+	deleteCmd.ValidArgsFunction = completion.TaskIDList(cfg, nil)
 	deleteCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation")
+	tasks.AddBulkFlags(deleteCmd)
 	deleteCmd.AddCommand(deleteRewardCmd)
 
 	addRewardCmd := rewards.AddRewardCmd(cfg)
 	addRewardCmd.Flags().IntP("price", "p", 0, "Price in points for the reward")
+	addRewardCmd.Flags().Int("stock", 0, "How many times the reward can be bought (default without limit)")
+	addRewardCmd.Flags().String("restock", "", "Fill the stock up again every week or month")
+	addRewardCmd.Flags().Bool("single-use", false, "Remove the reward after it is bought")
+	addRewardCmd.Flags().Bool("requires-approval", false, "Buying the reward makes a request for an approver")
+	_ = addRewardCmd.RegisterFlagCompletionFunc("restock", cobra.FixedCompletions([]string{"week", "month"}, cobra.ShellCompDirectiveNoFileComp))
+	addRewardCmd.Flags().String("cooldown", "", "Time to wait after a purchase, e.g. 12h or 1d")
+	addRewardCmd.Flags().String("limit", "", "How many times the reward can be bought per period, e.g. 2/week")
 	addCmd := tasks.AddCmd(cfg)
 	addCmd.Flags().IntP("points", "p", 0, "Counts of points, what you will receive after completing the task")
+	addCmd.Flags().String("assign", "", "The user who receives the points for the task")
+	addCmd.Flags().String("due", "", "Due date of the task, e.g. 2025-03-01, today, tomorrow or +3d")
 	addCmd.AddCommand(addRewardCmd)
 
 	listRewardCmd := rewards.ListRewardCmd(cfg)
 	listCmd := tasks.ListCmd(cfg)
 	listCmd.Flags().BoolP("points", "p", false, "Show info about points, what you can receive for the task")
+	listCmd.Flags().Bool("uuid", false, "Show the permanent UUIDs of the tasks")
+	listCmd.Flags().Bool("mine", false, "Show only the tasks assigned to you")
+	listRewardCmd.Flags().Bool("uuid", false, "Show the permanent UUIDs of the rewards")
 	listCmd.AddCommand(listRewardCmd)
 
-	editRewardPriceByDefault := rewards.EditRewardPriceByDefaultCmd()
+	editRewardPriceByDefault := rewards.EditRewardPriceByDefaultCmd(cfg)
 	editRewardDescrCmd := rewards.EditRewardDescrCmd(cfg)
+	editRewardDescrCmd.ValidArgsFunction = allRewards
 	editRewardPriceCmd := rewards.EditRewardPriceCmd(cfg)
-	editTaskPointsByDefault := tasks.EditTaskPointsByDefaultCmd()
+	editRewardPriceCmd.ValidArgsFunction = allRewards
+	editRewardStockCmd := rewards.EditRewardStockCmd(cfg)
+	editRewardStockCmd.ValidArgsFunction = allRewards
+	editRewardStockCmd.Flags().String("restock", "", "Fill the stock up again every week or month (none to stop)")
+	editRewardStockCmd.Flags().Bool("single-use", false, "Remove the reward after it is bought")
+	editRewardLimitsCmd := rewards.EditRewardLimitsCmd(cfg)
+	editRewardLimitsCmd.ValidArgsFunction = allRewards
+	editRewardLimitsCmd.Flags().String("cooldown", "", "Time to wait after a purchase, e.g. 12h or 1d (none to remove)")
+	editRewardLimitsCmd.Flags().String("limit", "", "How many times the reward can be bought per period, e.g. 2/week (none to remove)")
+	_ = editRewardStockCmd.RegisterFlagCompletionFunc("restock", cobra.FixedCompletions([]string{"week", "month", "none"}, cobra.ShellCompDirectiveNoFileComp))
+	editRewardApprovalCmd := rewards.EditRewardApprovalCmd(cfg)
+	editRewardApprovalCmd.ValidArgsFunction = allRewards
+	editTaskPointsByDefault := tasks.EditTaskPointsByDefaultCmd(cfg)
 	editTaskPoints := tasks.EditTaskPointsCmd(cfg)
+	editTaskPoints.ValidArgsFunction = allTasks
+	tasks.AddBulkFlags(editTaskPoints)
 	editCmd := tasks.EditCmd(cfg)
+	editCmd.ValidArgsFunction = allTasks
+	editCmd.Flags().String("due", "", "New due date of the task, e.g. 2025-03-01 or +3d (none to remove)")
 	editCmd.AddCommand(
 		editRewardDescrCmd,
 		editRewardPriceCmd,
+		editRewardStockCmd,
+		editRewardLimitsCmd,
+		editRewardApprovalCmd,
 		editRewardPriceByDefault,
 		editTaskPointsByDefault,
 		editTaskPoints,
@@ -49,18 +143,112 @@ func RootCmd(cfg *config.Config) *cobra.Command {
 	clearCmd := tasks.ClearCmd(cfg)
 	clearCmd.AddCommand(clearRewardCmd)
 
-	rootCmd.AddCommand(
+	encryptCmd := security.EncryptCmd(cfg)
+	encryptCmd.Flags().String("key-file", "", "Use the content of this file as the secret instead of a passphrase")
+	decryptCmd := security.DecryptCmd(cfg)
+	decryptCmd.Flags().String("key-file", "", "Use the content of this file as the secret instead of a passphrase")
+
+	listsCmd := lists.ListsCmd(cfg)
+	useListCmd := lists.UseListCmd(cfg)
+	useListCmd.ValidArgsFunction = completion.ListNames(cfg)
+	listsCmd.AddCommand(
+		lists.AddListCmd(cfg),
+		useListCmd,
+	)
+
+	configCmd := settings.ConfigCmd()
+	configCmd.AddCommand(
+		settings.ConfigGetCmd(cfg),
+		settings.ConfigSetCmd(cfg),
+		settings.ConfigUnsetCmd(cfg),
+		settings.ConfigListCmd(cfg),
+		settings.ConfigPathCmd(cfg),
+		settings.ConfigEditCmd(cfg),
+		settings.ConfigValidateCmd(cfg),
+	)
+
+	initCmd := settings.InitCmd(cfg)
+	initCmd.Flags().Bool("local", false, "Create a project-local .todo/ directory in the current directory")
+	initCmd.Flags().BoolP("force", "f", false, "Overwrite an existing config file")
+
+	moveCmd := tasks.MoveCmd(cfg)
+	moveCmd.ValidArgsFunction = allTasks
+	moveCmd.Flags().String("to", "", "Name of the list where the task moves")
+	_ = moveCmd.MarkFlagRequired("to")
+	_ = moveCmd.RegisterFlagCompletionFunc("to", completion.ListNames(cfg))
+
+	exportRewardCmd := interchange.ExportRewardCmd(cfg)
+	exportRewardCmd.Flags().String("format", "", "Format of the output: csv, markdown (default is guessed from --output)")
+	exportRewardCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	exportCmd := interchange.ExportCmd(cfg)
+	exportCmd.Flags().String("format", "", "Format of the output: todotxt, csv, markdown, ics, taskwarrior (default is guessed from --output)")
+	exportCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	exportCmd.AddCommand(exportRewardCmd)
+
+	importRewardCmd := interchange.ImportRewardCmd(cfg)
+	importRewardCmd.Flags().String("format", "", "Format of the file: csv, markdown (default is guessed from the extension)")
+	importRewardCmd.Flags().Bool("dry-run", false, "Show what would be imported without saving")
+	importRewardCmd.Flags().Bool("keep-duplicates", false, "Import rewards even if one with the same description exists")
+	importCmd := interchange.ImportCmd(cfg)
+	importCmd.Flags().String("format", "", "Format of the file: todotxt, csv, markdown, ics, taskwarrior (default is guessed from the extension)")
+	importCmd.Flags().Bool("dry-run", false, "Show what would be imported without saving")
+	importCmd.Flags().Bool("keep-duplicates", false, "Import tasks even if one with the same text exists")
+	importCmd.AddCommand(importRewardCmd)
+
+	notCompletedCmd := tasks.NotCompletedCmd(cfg)
+	notCompletedCmd.ValidArgsFunction = completedTasks
+	tasks.AddBulkFlags(notCompletedCmd)
+	buyRewardCmd := rewards.BuyRewardCmd(cfg)
+	buyRewardCmd.ValidArgsFunction = affordableRewards
+
+	serveCmd := server.ServeCmd(cfg)
+	serveCmd.Flags().String("addr", "", "Address to listen on (default is server.addr from the config)")
+
+	goalSetCmd := rewards.GoalSetCmd(cfg)
+	goalSetCmd.ValidArgsFunction = allRewards
+	goalCmd := rewards.GoalCmd(cfg)
+	goalCmd.AddCommand(goalSetCmd, rewards.GoalReleaseCmd(cfg))
+
+	streakCmd := rewards.StreakCmd(cfg)
+	streakCmd.Flags().Int("weeks", 5, "How many recent weeks the calendar shows")
+
+	pointsCmd := rewards.PointsCmd(cfg)
+	pointsCmd.Flags().Int("last", 20, "How many recent changes of the balance to show, 0 shows all")
+	pointsGiveCmd := rewards.PointsGiveCmd(cfg)
+	pointsGiveCmd.Flags().String("note", "", "Why the points are given, kept in the history")
+	pointsCmd.AddCommand(pointsGiveCmd)
+
+	return []*cobra.Command{
 		addCmd,
 		completeCmd,
 		deleteCmd,
 		listCmd,
 		editCmd,
 		clearCmd,
-		tasks.NotCompletedCmd(cfg),
+		notCompletedCmd,
 		tasks.CancelLastDeleteCmd(cfg),
-		rewards.BuyRewardCmd(cfg),
+		buyRewardCmd,
 		rewards.ResetPointsCmd(cfg),
-	)
-
-	return rootCmd
+		pointsCmd,
+		goalCmd,
+		streakCmd,
+		rewards.ProfileCmd(cfg),
+		rewards.LeaderboardCmd(cfg),
+		rewards.RequestsCmd(cfg),
+		rewards.ApproveCmd(cfg),
+		rewards.DenyCmd(cfg),
+		encryptCmd,
+		decryptCmd,
+		settings.WhereCmd(cfg),
+		listsCmd,
+		moveCmd,
+		configCmd,
+		initCmd,
+		exportCmd,
+		importCmd,
+		serveCmd,
+		interactive.TuiCmd(cfg),
+		interactive.ShellCmd(cfg, Commands),
+		completion.CompletionCmd(),
+	}
 }
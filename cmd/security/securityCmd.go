@@ -0,0 +1,218 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"golang.org/x/term"
+)
+
+const passphraseEnv = "TODO_PASSPHRASE"
+
+// SecretProvider returns the secret source for encrypted data files: the key
+// file from the config if set, otherwise a passphrase from TODO_PASSPHRASE or
+// the terminal.
+func SecretProvider(cfg *config.Config) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return readSecret(cfg.Encryption.KeyFile, false)
+	}
+}
+
+// NonInteractiveSecretProvider is SecretProvider that never asks for a
+// passphrase. Shell completion uses it: the terminal belongs to the shell.
+func NonInteractiveSecretProvider(cfg *config.Config) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if cfg.Encryption.KeyFile != "" {
+			return storage.SecretFromKeyFile(cfg.Encryption.KeyFile)
+		}
+		if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+			return []byte(passphrase), nil
+		}
+		return nil, fmt.Errorf("no passphrase: set %s or use a key file", passphraseEnv)
+	}
+}
+
+func EncryptCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt [flags]",
+		Short: "Encrypt the data files with a passphrase or a key file",
+		Long: "Encrypt the todo and reward files with AES-256-GCM. The key is derived with Argon2id " +
+			"from a key file (--key-file) or from a passphrase (" + passphraseEnv + " or prompt)",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			keyFile, err := keyFileFlag(cmd, cfg)
+			if err != nil {
+				logger.Error("could not parse key-file flag", err, slog.String("flag", "--key-file"), slog.String("command", "encrypt"))
+				return
+			}
+
+			secret, err := readSecret(keyFile, true)
+			if err != nil {
+				logger.Error("could not get encryption secret", err, slog.String("command", "encrypt"))
+				return
+			}
+
+			if !convertDataFiles(cfg, "Encrypted", func(file string) error {
+				return storage.EncryptFile(file, secret)
+			}, storage.ErrAlreadyEncrypted) {
+				return
+			}
+
+			if err := editEncryption(cfg, true, keyFile); err != nil {
+				logger.Error("failed to save config file", err)
+				return
+			}
+			logger.Info("data files were encrypted")
+			fmt.Println("Encryption is enabled!")
+		},
+	}
+}
+
+func DecryptCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt [flags]",
+		Short: "Decrypt the data files back into plain JSON",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			keyFile, err := keyFileFlag(cmd, cfg)
+			if err != nil {
+				logger.Error("could not parse key-file flag", err, slog.String("flag", "--key-file"), slog.String("command", "decrypt"))
+				return
+			}
+
+			secret, err := readSecret(keyFile, false)
+			if err != nil {
+				logger.Error("could not get encryption secret", err, slog.String("command", "decrypt"))
+				return
+			}
+
+			if !convertDataFiles(cfg, "Decrypted", func(file string) error {
+				return storage.DecryptFile(file, secret)
+			}, storage.ErrNotEncrypted) {
+				return
+			}
+
+			if err := editEncryption(cfg, false, ""); err != nil {
+				logger.Error("failed to save config file", err)
+				return
+			}
+			logger.Info("data files were decrypted")
+			fmt.Println("Encryption is disabled!")
+		},
+	}
+}
+
+// convertDataFiles applies convert to every existing data file of every
+// list, since the encryption setting is the same for all of them. Files that
+// are already in the wanted state (skipErr) are left alone, so the commands
+// can be re-run safely after a partial failure.
+func convertDataFiles(cfg *config.Config, verb string, convert func(file string) error, skipErr error) bool {
+	ok := true
+	for _, file := range dataFiles(cfg) {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := convert(file); err != nil {
+			if errors.Is(err, skipErr) {
+				fmt.Printf("Skipped %s: %v\n", file, err)
+				continue
+			}
+			logger.Error("failed to convert data file", err, slog.String("file", file))
+			ok = false
+			continue
+		}
+		fmt.Printf("%s: %s\n", verb, file)
+	}
+	return ok
+}
+
+// dataFiles returns the data files of all lists, each once: lists may share
+// a file.
+func dataFiles(cfg *config.Config) []string {
+	var files []string
+	for _, name := range cfg.ListNames() {
+		storage, err := cfg.ListStorage(name)
+		if err != nil {
+			continue
+		}
+		for _, file := range []string{storage.TodoFile, storage.RewardFile} {
+			if !slices.Contains(files, file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+func editEncryption(cfg *config.Config, enabled bool, keyFile string) error {
+	return cfg.Edit(func(f *config.File) error {
+		if err := f.Set("encryption.enabled", enabled); err != nil {
+			return err
+		}
+		return f.Set("encryption.key_file", keyFile)
+	})
+}
+
+func keyFileFlag(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	keyFile := cfg.Encryption.KeyFile
+	if cmd.Flags().Changed("key-file") {
+		var err error
+		if keyFile, err = cmd.Flags().GetString("key-file"); err != nil {
+			return "", err
+		}
+	}
+
+	if keyFile == "" {
+		return "", nil
+	}
+	// The config may be read from another directory later, so keep the path absolute.
+	return filepath.Abs(keyFile)
+}
+
+func readSecret(keyFile string, confirm bool) ([]byte, error) {
+	if keyFile != "" {
+		return storage.SecretFromKeyFile(keyFile)
+	}
+
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no passphrase: set %s or use a key file", passphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, "Repeat passphrase: ")
+		repeated, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if string(repeated) != string(passphrase) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	return passphrase, nil
+}
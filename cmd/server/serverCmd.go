@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/server"
+)
+
+func ServeCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve [flags]",
+		Short: "Serve the list over a local JSON REST API and a web page",
+		Long: "Serve tasks, rewards and the balance of the list over a JSON REST API and a web page at /.\n" +
+			"The API is described at /api/openapi.json. If server.token is set in the config,\n" +
+			"requests need the header 'Authorization: Bearer <token>'.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			addr, err := cmd.Flags().GetString("addr")
+			if err != nil {
+				logger.Error("could not parse addr flag", err, slog.String("flag", "--addr"), slog.String("command", "serve"))
+				return
+			}
+			if addr == "" {
+				addr = cfg.Server.Addr
+			}
+			// The server answers only to the name it listens on.
+			cfg.Server.Addr = addr
+
+			if err := server.CheckAddr(addr, cfg.Server.Token); err != nil {
+				logger.Error("could not start server", err)
+				return
+			}
+
+			srv := server.New(cfg)
+			httpServer := &http.Server{
+				Addr:              addr,
+				Handler:           srv,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			httpServer.RegisterOnShutdown(srv.Close)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := httpServer.Shutdown(shutdownCtx); err != nil {
+					logger.Error("failed to stop server", err)
+				}
+			}()
+
+			logger.Info("server started", slog.String("addr", addr), slog.String("list", cfg.List))
+			fmt.Printf("Serving list '%s' on http://%s (API description: /api/openapi.json)\n", cfg.List, addr)
+
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("server failed", err, slog.String("addr", addr))
+				return
+			}
+			fmt.Println("Server stopped")
+		},
+	}
+}
@@ -0,0 +1,322 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+// allowInvalidConfig marks commands that must work while the config is
+// invalid, otherwise it could not be repaired from the command line.
+const allowInvalidConfig = "allowInvalidConfig"
+
+var repairAnnotation = map[string]string{allowInvalidConfig: "true"}
+
+// AllowsInvalidConfig reports whether cmd or one of its parents may run with
+// an invalid config.
+func AllowsInvalidConfig(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[allowInvalidConfig] == "true" || c.Name() == "help" {
+			return true
+		}
+	}
+	return false
+}
+
+func WhereCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "where",
+		Short:       "Show where the config and the data files are located",
+		Args:        cobra.NoArgs,
+		Annotations: repairAnnotation,
+		Run: func(cmd *cobra.Command, args []string) {
+			project := cfg.Paths.ProjectDir
+			if project == "" {
+				project = "(none)"
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Config file:\t%s%s\n", cfg.Paths.ConfigFile, missingMark(cfg.Paths.ConfigFile))
+			fmt.Fprintf(w, "Data directory:\t%s\n", cfg.Paths.DataDir)
+			fmt.Fprintf(w, "Project directory:\t%s\n", project)
+			fmt.Fprintf(w, "Todo file:\t%s%s\n", cfg.Storage.TodoFile, missingMark(cfg.Storage.TodoFile))
+			fmt.Fprintf(w, "Reward file:\t%s%s\n", cfg.Storage.RewardFile, missingMark(cfg.Storage.RewardFile))
+			w.Flush()
+		},
+	}
+}
+
+func missingMark(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return " (not created yet)"
+	}
+	return ""
+}
+
+func ConfigCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:         "config",
+		Short:       "View and change settings",
+		Annotations: repairAnnotation,
+		Long: "View and change settings. Every key can be overridden by an environment variable " +
+			"with the prefix " + config.EnvPrefix + "_, e.g. defaults.task_points by " + config.EnvName("defaults.task_points"),
+	}
+}
+
+func ConfigGetCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Show the effective value of a key or of a whole section",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			settings, err := cfg.Settings()
+			if err != nil {
+				logger.Error("config get command failed", err)
+				return
+			}
+
+			key := strings.ToLower(args[0])
+			found := false
+			for _, setting := range settings {
+				if setting.Key == key {
+					fmt.Fprintln(cmd.OutOrStdout(), formatValue(setting.Value))
+					return
+				}
+				if strings.HasPrefix(setting.Key, key+".") {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s = %s\n", setting.Key, formatValue(setting.Value))
+					found = true
+				}
+			}
+
+			if !found {
+				logger.Error("config get command failed", fmt.Errorf("key %q is not set and has no default", key))
+			}
+		},
+	}
+}
+
+func ConfigSetCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Write a value into the config file",
+		Long:  "Write a value into the config file. Lists are given comma separated. Known keys:\n  " + strings.Join(config.KnownKeys(), "\n  "),
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			key := strings.ToLower(args[0])
+			value, err := config.ParseValue(key, args[1])
+			if err != nil {
+				logger.Error("incorrect config value", err, slog.String("command", "config set"))
+				return
+			}
+
+			if err := cfg.Set(key, value); err != nil {
+				logger.Error("failed to save config file", err)
+			} else {
+				logger.Info("config value has been changed", slog.String("key", key))
+				fmt.Printf("%s = %s\n", key, formatValue(value))
+				if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+					fmt.Printf("Note: %s is set and overrides this value\n", config.EnvName(key))
+				}
+			}
+		},
+	}
+}
+
+func ConfigUnsetCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a key or a whole section from the config file",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			key := strings.ToLower(args[0])
+			removed := false
+			err := cfg.Edit(func(f *config.File) error {
+				removed = f.Unset(key)
+				return nil
+			})
+			if err != nil {
+				logger.Error("failed to save config file", err)
+				return
+			}
+
+			if !removed {
+				fmt.Printf("%s was not set in the config file\n", key)
+				return
+			}
+			logger.Info("config value has been removed", slog.String("key", key))
+			fmt.Printf("%s was removed, the default value is used now\n", key)
+		},
+	}
+}
+
+func ConfigListCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Show all settings with their values and where they come from",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			settings, err := cfg.Settings()
+			if err != nil {
+				logger.Error("config list command failed", err)
+				return
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintf(w, "Key\tValue\tSource\n")
+			for _, setting := range settings {
+				value := formatValue(setting.Value)
+				// Secrets are only shown by 'todo config get'.
+				if strings.HasSuffix(setting.Key, "token") && value != "" {
+					value = "(hidden)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, value, setting.Source)
+			}
+			w.Flush()
+		},
+	}
+}
+
+func ConfigPathCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "path",
+		Short: "Show the path of the config file",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), cfg.Paths.ConfigFile)
+		},
+	}
+}
+
+func ConfigEditCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $VISUAL or $EDITOR",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			editor := os.Getenv("VISUAL")
+			if editor == "" {
+				editor = os.Getenv("EDITOR")
+			}
+			if editor == "" {
+				editor = "vi"
+			}
+
+			if err := os.MkdirAll(filepath.Dir(cfg.Paths.ConfigFile), 0700); err != nil {
+				logger.Error("could not create config directory", err)
+				return
+			}
+
+			// The editor may be given with arguments, e.g. "code --wait".
+			editorArgs := strings.Fields(editor)
+			editorCmd := exec.Command(editorArgs[0], append(editorArgs[1:], cfg.Paths.ConfigFile)...)
+			editorCmd.Stdin = os.Stdin
+			editorCmd.Stdout = os.Stdout
+			editorCmd.Stderr = os.Stderr
+			if err := editorCmd.Run(); err != nil {
+				logger.Error("editor finished with error", err, slog.String("editor", editor))
+				return
+			}
+
+			f, err := config.OpenFile(cfg.Paths.ConfigFile)
+			if err != nil {
+				logger.Error("config file is broken after editing", err)
+				return
+			}
+			if unknown := f.UnknownKeys(); len(unknown) > 0 {
+				fmt.Printf("Warning: unknown keys in config file: %s\n", strings.Join(unknown, ", "))
+				return
+			}
+			fmt.Println("Config file has been saved!")
+		},
+	}
+}
+
+func ConfigValidateCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config values and the data directories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := cfg.Validate()
+			if err == nil {
+				fmt.Fprintln(cmd.OutOrStdout(), "Config is valid!")
+				return nil
+			}
+
+			var validationErrs config.ValidationErrors
+			if !errors.As(err, &validationErrs) {
+				return err
+			}
+			for _, validationErr := range validationErrs {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %v\n", validationErr)
+			}
+
+			cmd.SilenceUsage = true
+			return fmt.Errorf("config has %d problem(s)", len(validationErrs))
+		},
+	}
+}
+
+func InitCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:         "init [flags]",
+		Short:       "Create a config file with default values",
+		Long:        "Create a config file with default values in the config directory, or with --local a .todo/ directory with config and data in the current directory",
+		Args:        cobra.NoArgs,
+		Annotations: repairAnnotation,
+		Run: func(cmd *cobra.Command, args []string) {
+			localFlag, err := cmd.Flags().GetBool("local")
+			if err != nil {
+				logger.Error("could not parse local flag", err, slog.String("flag", "--local"), slog.String("command", "init"))
+				return
+			}
+			forceFlag, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				logger.Error("could not parse force flag", err, slog.String("flag", "--force"), slog.String("command", "init"))
+				return
+			}
+
+			configFile := cfg.Paths.ConfigFile
+			if localFlag {
+				wd, err := os.Getwd()
+				if err != nil {
+					logger.Error("could not get working directory", err)
+					return
+				}
+				configFile = filepath.Join(wd, config.ProjectDirName, filepath.Base(cfg.Paths.ConfigFile))
+			}
+
+			if _, err := os.Stat(configFile); err == nil && !forceFlag {
+				logger.Error("could not create config file", fmt.Errorf("%s already exists, use -f to overwrite it", configFile))
+				return
+			}
+
+			if err := config.InitConfig(configFile); err != nil {
+				logger.Error("failed to create config file", err)
+				return
+			}
+			logger.Info("config file has been created", slog.String("file", configFile))
+			fmt.Printf("Created config file: %s\n", configFile)
+		},
+	}
+}
+
+func formatValue(value any) string {
+	if items, ok := value.([]any); ok {
+		parts := make([]string, len(items))
+		for i, item := range items {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,163 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+// AddBulkFlags adds the flags of commands working on several tasks at once.
+func AddBulkFlags(cmd *cobra.Command) {
+	cmd.Flags().String("where", "", "Choose tasks by a filter instead of IDs: done, open, overdue, points>N, text~word, +project, @context, pri:A")
+	cmd.Flags().Bool("best-effort", false, "Change the tasks that can be changed and report the others (default is all or nothing)")
+	_ = cmd.RegisterFlagCompletionFunc("where", cobra.FixedCompletions(
+		[]string{"done", "open", "overdue", "points>", "points<", "text~", "pri:"},
+		cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace,
+	))
+}
+
+// bulkOptions are the values of the flags from AddBulkFlags. A command built
+// without them takes IDs only.
+type bulkOptions struct {
+	where      string
+	bestEffort bool
+}
+
+func readBulkOptions(cmd *cobra.Command) (bulkOptions, error) {
+	var (
+		opts bulkOptions
+		err  error
+	)
+	if cmd.Flags().Lookup("where") != nil {
+		if opts.where, err = cmd.Flags().GetString("where"); err != nil {
+			return opts, fmt.Errorf("could not parse where flag: %w", err)
+		}
+	}
+	if cmd.Flags().Lookup("best-effort") != nil {
+		if opts.bestEffort, err = cmd.Flags().GetBool("best-effort"); err != nil {
+			return opts, fmt.Errorf("could not parse best-effort flag: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// selectTasks returns the IDs given as lists and ranges ("3,5,8-12") or the
+// IDs of the tasks matching the filter.
+func selectTasks(h *handlers.TaskHandler, specs []string, where string) ([]int, error) {
+	if where == "" {
+		if len(specs) == 0 {
+			return nil, fmt.Errorf("give IDs of tasks or a filter with --where")
+		}
+		ids, err := utils.ParseIDList(specs, h.Todo.Tasks)
+		if err != nil {
+			return nil, fmt.Errorf("catch error when checking id: %w", err)
+		}
+		return ids, nil
+	}
+
+	if len(specs) > 0 {
+		return nil, fmt.Errorf("give IDs of tasks or a filter with --where, not both")
+	}
+	filter, err := utils.ParseWhere(where)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, task := range h.Todo.Tasks {
+		if filter(task) {
+			ids = append(ids, task.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no task matches the filter %q", where)
+	}
+	return ids, nil
+}
+
+// batchFailure is a task the batch could not change.
+type batchFailure struct {
+	id  int
+	err error
+}
+
+// batch is the result of applying a command to several tasks.
+type batch struct {
+	ids      []int
+	done     []int
+	failures []batchFailure
+}
+
+// isBulk tells whether the command must print a summary instead of the
+// messages about one task. Nothing done is reported by the summary too.
+func (b *batch) isBulk(opts bulkOptions) bool {
+	return len(b.ids) > 1 || opts.where != "" || len(b.done) == 0
+}
+
+// runBatch applies fn to every task. All or nothing: the first failure stops
+// the batch and the caller must not save. Best effort: failures are
+// collected and the rest is applied.
+func runBatch(ids []int, bestEffort bool, fn func(id int) error) (*batch, error) {
+	b := &batch{ids: ids}
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			if !bestEffort {
+				if len(ids) == 1 {
+					return nil, err
+				}
+				return nil, fmt.Errorf("task %d: %w (nothing was changed, use --best-effort to change the rest)", id, err)
+			}
+			b.failures = append(b.failures, batchFailure{id: id, err: err})
+			continue
+		}
+		b.done = append(b.done, id)
+	}
+	return b, nil
+}
+
+// printSummary prints e.g. "Completed 3 of 4 tasks: 1, 2, 5" and the reasons
+// of failures.
+func (b *batch) printSummary(action string) {
+	done := make([]string, len(b.done))
+	for i, id := range b.done {
+		done[i] = fmt.Sprint(id)
+	}
+	fmt.Printf("%s %d of %d tasks", action, len(b.done), len(b.ids))
+	if len(done) > 0 {
+		fmt.Printf(": %s", strings.Join(done, ", "))
+	}
+	fmt.Println()
+	for _, failure := range b.failures {
+		fmt.Printf("  task %d: %v\n", failure.id, failure.err)
+	}
+}
+
+// confirmDeletion asks before deleting the tasks.
+func confirmDeletion(h *handlers.TaskHandler, ids []int) bool {
+	if len(ids) == 1 {
+		i, _ := utils.CheckExistItem(ids[0], h.Todo.Tasks)
+		fmt.Printf("You want to delete task: %s", utils.PrintInfoOfTask(ids[0], i, h.Todo.Tasks))
+	} else {
+		fmt.Printf("You want to delete %d tasks:\n", len(ids))
+		for _, id := range ids {
+			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err == nil {
+				fmt.Print("  " + utils.PrintInfoOfTask(id, i, h.Todo.Tasks))
+			}
+		}
+	}
+	fmt.Print("Are you sure (y/n): ")
+	var confirm string
+	fmt.Scanln(&confirm)
+	return strings.ToLower(confirm) == "y"
+}
+
+// deleteTasks deletes the tasks by ID, indexes move after every deletion.
+func deleteTasks(h *handlers.TaskHandler, ids []int) {
+	for _, id := range ids {
+		if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err == nil {
+			h.Delete(i)
+		}
+	}
+}
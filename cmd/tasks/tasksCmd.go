@@ -3,13 +3,16 @@ package tasks
 import (
 	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/svetsed/todo_cli_app/internal/config"
 	"github.com/svetsed/todo_cli_app/internal/handlers"
 	"github.com/svetsed/todo_cli_app/internal/loaders"
 	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/models"
 	"github.com/svetsed/todo_cli_app/internal/storage"
 	"github.com/svetsed/todo_cli_app/internal/utils"
 )
@@ -20,14 +23,6 @@ func AddCmd(cfg *config.Config) *cobra.Command {
 		Short: "Add a new task with optional points, what you will receive after completing the task",
 		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
-			if err != nil {
-				logger.Error("add command failed", err)
-				return
-			}
-
-			h := &handlers.TaskHandler{Todo: todoList}
-
 			var pointsCount int = cfg.Defaults.TaskPoints
 			if cmd.Flags().Changed("points") {
 				if pointsCountTmp, err := cmd.Flags().GetInt("points"); err != nil {
@@ -41,14 +36,70 @@ func AddCmd(cfg *config.Config) *cobra.Command {
 				}
 			}
 
+			var assignee string
+			if flag := cmd.Flags().Lookup("assign"); flag != nil && flag.Changed {
+				if assignee = utils.NormalizeUser(flag.Value.String()); assignee == "" {
+					logger.Error("incorrect using flags", fmt.Errorf("--assign needs a user name"), slog.String("command", "add"))
+					return
+				}
+				rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					logger.Error("could not load reward system in add command", err)
+					return
+				}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				if err := r.CheckUser(assignee); err != nil {
+					logger.Error("incorrect using flags", err, slog.String("command", "add"))
+					return
+				}
+			}
+
+			var due *time.Time
+			if flag := cmd.Flags().Lookup("due"); flag != nil && flag.Changed {
+				day, err := utils.ParseDue(flag.Value.String(), time.Now())
+				if err != nil {
+					logger.Error("incorrect using flags", err, slog.String("command", "add"))
+					return
+				}
+				due = &day
+			}
+
 			text := strings.Join(args, " ")
 
-			h.Add(text, pointsCount)
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list in add command", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				logger.Info("task added successfully", slog.Int("task_id", h.Todo.NextID-1))
-				fmt.Printf("Added task: [ ] %d. %s (%d points)\n", h.Todo.NextID-1, text, h.Todo.Tasks[len(h.Todo.Tasks)-1].TaskPoints)
+			var task models.Task
+			err := storage.Update([]string{cfg.Storage.TodoFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+
+				h := &handlers.TaskHandler{Todo: todoList}
+				h.Add(text, pointsCount)
+				if assignee != "" {
+					h.Assign(len(h.Todo.Tasks)-1, assignee)
+				}
+				if due != nil {
+					h.SetDue(len(h.Todo.Tasks)-1, due)
+				}
+				task = h.Todo.Tasks[len(h.Todo.Tasks)-1]
+
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list in add command: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("add command failed", err)
+				return
+			}
+
+			logger.Info("task added successfully", slog.Int("task_id", task.ID))
+			fmt.Printf("Added task: [ ] %d. %s (%d points)\n", task.ID, task.Text, task.TaskPoints)
+			if task.Assignee != "" {
+				fmt.Printf("Assigned to %s\n", task.Assignee)
+			}
+			if task.Due != nil {
+				fmt.Printf("Due: %s\n", task.Due.Format("2006-01-02"))
 			}
 		},
 	}
@@ -72,6 +123,21 @@ func ListCmd(cfg *config.Config) *cobra.Command {
 				logger.Error("could not parse points flag in list command: %v", err)
 				return
 			}
+			if mineFlag := cmd.Flags().Lookup("mine"); mineFlag != nil && mineFlag.Value.String() == "true" {
+				if cfg.User == "" {
+					logger.Error("list command failed", fmt.Errorf("no user is set, use 'todo config set user <name>' or TODO_USER"))
+					return
+				}
+				mine := &models.TodoList{NextID: h.Todo.NextID}
+				for _, i := range h.Mine(cfg.User) {
+					mine.Tasks = append(mine.Tasks, h.Todo.Tasks[i])
+				}
+				h = &handlers.TaskHandler{Todo: mine}
+			}
+			if uuidFlag := cmd.Flags().Lookup("uuid"); uuidFlag != nil && uuidFlag.Value.String() == "true" {
+				h.ListUIDs(cmd.OutOrStdout())
+				return
+			}
 			h.List(pointsFlag, cmd.OutOrStdout())
 		},
 	}
@@ -79,49 +145,11 @@ func ListCmd(cfg *config.Config) *cobra.Command {
 
 func CompleteCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "complete <ID> [flags]",
-		Short: "Mark the task as completed and/or delete this task",
-		Args:  cobra.ExactArgs(1),
+		Use:   "complete <IDs> [flags]",
+		Short: "Mark the tasks as completed and/or delete these tasks",
+		Long: "Mark the tasks as completed. Give one ID, lists and ranges of IDs like 3,5,8-12,\n" +
+			"or choose the tasks with --where. The whole batch is saved at once.",
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
-			if err != nil {
-				logger.Error("complete command failed", err)
-				return
-			}
-
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("complete command failed", err)
-				return
-			}
-
-			h := &handlers.TaskHandler{Todo: todoList}
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
-
-			id, err := utils.ValidateID(args[0], h.Todo.NextID-1)
-			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "complete"))
-				return
-			}
-
-			var taskIndexElem int
-			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "complete"))
-				return
-			} else {
-				taskIndexElem = i
-			}
-
-			if err := h.Complete(taskIndexElem); err != nil {
-				logger.Error("could not mark task as completed", err)
-				return
-			}
-
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after completing the task", err, slog.String("file", cfg.Storage.TodoFile))
-				return
-			}
-
 			deleteFlag, err := cmd.Flags().GetBool("delete")
 			if err != nil {
 				logger.Error("could not parse delete flag", err, slog.String("flag", "--delete"), slog.String("command", "complete"))
@@ -139,20 +167,100 @@ func CompleteCmd(cfg *config.Config) *cobra.Command {
 				return
 			}
 
-			if !h.Todo.Tasks[taskIndexElem].IsTaskPointsReceive {
-				r.UpdateUserPoints(h.Todo.Tasks[taskIndexElem].TaskPoints)
-				if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-					logger.Error("failed to save reward file after updating balance of points", err, slog.String("file", cfg.Storage.RewardFile), slog.String("command", "complete"))
-					return
+			opts, err := readBulkOptions(cmd)
+			if err != nil {
+				logger.Error("incorrect using flags", err, slog.String("command", "complete"))
+				return
+			}
+
+			var (
+				h       *handlers.TaskHandler
+				b       *batch
+				points  int
+				notices []string
+			)
+			// The tasks and the balance change together, so both files stay
+			// locked until they are saved.
+			err = storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
 				}
-				h.Todo.Tasks[taskIndexElem].IsTaskPointsReceive = true
-			}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
+
+				h = &handlers.TaskHandler{Todo: todoList}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
 
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after completing the task", err, slog.String("file", cfg.Storage.TodoFile))
+				ids, err := selectTasks(h, args, opts.where)
+				if err != nil {
+					return err
+				}
+				b, err = runBatch(ids, opts.bestEffort, func(id int) error {
+					taskIndexElem, err := utils.CheckExistItem(id, h.Todo.Tasks)
+					if err != nil {
+						return fmt.Errorf("catch error when searching task by id: %w", err)
+					}
+					received, err := h.CompleteWithPoints(taskIndexElem, r)
+					if err != nil {
+						return fmt.Errorf("could not mark task as completed: %w", err)
+					}
+					points += received
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if len(b.done) == 0 {
+					return nil
+				}
+				// Tasks which are still open or were completed too late pay
+				// for the passed due date.
+				h.PenalizeOverdue(r, time.Now())
+				notices = r.Notices
+
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after updating balance of points: %w", err)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after completing the task: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("complete command failed", err)
+				return
+			}
+			// Bonuses are told after the completed tasks.
+			defer func() {
+				for _, notice := range notices {
+					fmt.Println(notice)
+				}
+			}()
+
+			if b.isBulk(opts) {
+				b.printSummary("Completed")
+				fmt.Printf("Received %d points\n", points)
+				logger.Info("tasks were marked as completed", slog.Int("count", len(b.done)), slog.Int("points", points))
+				if deleteFlag && len(b.done) > 0 {
+					if !forceFlag && !confirmDeletion(h, b.done) {
+						logger.Info("the deletion was cancelled by user")
+						fmt.Println("The deletion was cancelled!")
+						return
+					}
+					if err := deleteCompleted(cfg, b.done); err != nil {
+						logger.Error("failed to delete completed tasks", err, slog.String("file", cfg.Storage.TodoFile))
+						return
+					}
+					fmt.Printf("%d completed tasks were deleted!\n", len(b.done))
+				}
 				return
 			}
 
+			id := b.done[0]
+			taskIndexElem, _ := utils.CheckExistItem(id, h.Todo.Tasks)
 			printingTask := utils.PrintInfoOfTask(id, taskIndexElem, h.Todo.Tasks)
 			if deleteFlag {
 				if !forceFlag {
@@ -167,10 +275,8 @@ func CompleteCmd(cfg *config.Config) *cobra.Command {
 					}
 				}
 
-				h.Delete(taskIndexElem)
-
-				if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-					logger.Error("failed to save todo list after deleting completed task", err, slog.String("file", cfg.Storage.TodoFile))
+				if err := deleteCompleted(cfg, b.done); err != nil {
+					logger.Error("failed to delete completed task", err, slog.String("file", cfg.Storage.TodoFile))
 					return
 				}
 				logger.Info("task has been completed and deleted", slog.Int("id", id))
@@ -185,60 +291,108 @@ func CompleteCmd(cfg *config.Config) *cobra.Command {
 	}
 }
 
+// deleteCompleted deletes the completed tasks by ID. The question before it
+// is answered without holding the lock, so the list is loaded again and a
+// task which is not completed any more stays.
+func deleteCompleted(cfg *config.Config, ids []int) error {
+	return storage.Update([]string{cfg.Storage.TodoFile}, func(tx *storage.Tx) error {
+		todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+		if err != nil {
+			return err
+		}
+
+		h := &handlers.TaskHandler{Todo: todoList}
+		var completed []int
+		for _, id := range ids {
+			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err == nil && h.Todo.Tasks[i].IsComplete {
+				completed = append(completed, id)
+			}
+		}
+		deleteTasks(h, completed)
+
+		if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+			return fmt.Errorf("failed to save todo list after deleting completed tasks: %w", err)
+		}
+		return nil
+	})
+}
+
 func NotCompletedCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "not-complete <ID>",
-		Short: "Mark the task as not completed",
-		Args:  cobra.ExactArgs(1),
+		Use:   "not-complete <IDs> [flags]",
+		Short: "Mark the tasks as not completed",
+		Long: "Mark the tasks as not completed and take back their points. Give one ID, lists and\n" +
+			"ranges of IDs like 3,5,8-12, or choose the tasks with --where.",
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+			opts, err := readBulkOptions(cmd)
 			if err != nil {
-				logger.Error("not-complete command failed", err)
+				logger.Error("incorrect using flags", err, slog.String("command", "not-complete"))
 				return
 			}
 
-			rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile)
-			if err != nil {
-				logger.Error("not-complete command failed", err)
-				return
-			}
+			var (
+				h      *handlers.TaskHandler
+				b      *batch
+				points int
+			)
+			err = storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
 
-			h := &handlers.TaskHandler{Todo: todoList}
-			r := &handlers.RewardHandler{RSystem: rewardSystem}
+				h = &handlers.TaskHandler{Todo: todoList}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
 
-			id, err := utils.ValidateID(args[0], h.Todo.NextID-1)
-			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "not-complete"))
-				return
-			}
-
-			var taskIndexElem int
-			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "not-complete"))
-				return
-			} else {
-				taskIndexElem = i
-			}
-
-			if err := h.NotCompleted(taskIndexElem); err != nil {
-				logger.Error("could not mark task as NOT completed", err)
-				return
-			}
+				ids, err := selectTasks(h, args, opts.where)
+				if err != nil {
+					return err
+				}
+				b, err = runBatch(ids, opts.bestEffort, func(id int) error {
+					taskIndexElem, err := utils.CheckExistItem(id, h.Todo.Tasks)
+					if err != nil {
+						return fmt.Errorf("catch error when searching task by id: %w", err)
+					}
+					taken, err := h.NotCompletedWithPoints(taskIndexElem, r)
+					if err != nil {
+						return fmt.Errorf("could not mark task as NOT completed: %w", err)
+					}
+					points += taken
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if len(b.done) == 0 {
+					return nil
+				}
 
-			if h.Todo.Tasks[taskIndexElem].IsTaskPointsReceive {
-				r.UpdateUserPoints(-(h.Todo.Tasks[taskIndexElem].TaskPoints))
-				if err := storage.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
-					logger.Error("failed to save reward file after updating balance of points", err, slog.String("file", cfg.Storage.RewardFile), slog.String("command", "not-complete"))
-					return
+				if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+					return fmt.Errorf("failed to save reward file after updating balance of points: %w", err)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list by not-complete command: %w", err)
 				}
-				h.Todo.Tasks[taskIndexElem].IsTaskPointsReceive = false
+				return nil
+			})
+			if err != nil {
+				logger.Error("not-complete command failed", err)
+				return
 			}
 
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list by not-complete command", err, slog.String("file", cfg.Storage.TodoFile))
+			if b.isBulk(opts) {
+				b.printSummary("Marked as not completed")
+				fmt.Printf("Taken back %d points\n", points)
+				logger.Info("tasks have been not completed", slog.Int("count", len(b.done)), slog.Int("points", points))
 				return
 			}
 
+			id := b.done[0]
+			taskIndexElem, _ := utils.CheckExistItem(id, h.Todo.Tasks)
 			logger.Info("task has been not completed", slog.Int("id", id))
 			fmt.Printf("Now task %d is not completed!\n", id)
 			fmt.Print(utils.PrintInfoOfTask(id, taskIndexElem, h.Todo.Tasks))
@@ -248,46 +402,82 @@ func NotCompletedCmd(cfg *config.Config) *cobra.Command {
 
 func EditCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "edit <ID> <task text>",
-		Short: "Edits the text of an existing task",
-		Args:  cobra.MinimumNArgs(2),
+		Use:   "edit <ID> [task text] [flags]",
+		Short: "Edits the text or the due date of an existing task",
+		Args:  cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
-			if err != nil {
-				logger.Error("edit command failed", err)
+			newText := strings.Join(args[1:], " ")
+
+			// --due none removes the due date.
+			var (
+				due       *time.Time
+				changeDue bool
+			)
+			if flag := cmd.Flags().Lookup("due"); flag != nil && flag.Changed {
+				changeDue = true
+				if value := flag.Value.String(); !strings.EqualFold(value, "none") {
+					day, err := utils.ParseDue(value, time.Now())
+					if err != nil {
+						logger.Error("incorrect using flags", err, slog.String("command", "edit"))
+						return
+					}
+					due = &day
+				}
+			}
+			if newText == "" && !changeDue {
+				logger.Error("incorrect using arguments", fmt.Errorf("give the new text of the task or --due"), slog.String("command", "edit"))
+				_ = cmd.Usage()
 				return
 			}
 
-			h := &handlers.TaskHandler{Todo: todoList}
+			var (
+				h             *handlers.TaskHandler
+				id            int
+				taskIndexElem int
+			)
+			err := storage.Update([]string{cfg.Storage.TodoFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
 
-			id, err := utils.ValidateID(args[0], h.Todo.NextID-1)
-			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "edit"))
-				return
-			}
+				h = &handlers.TaskHandler{Todo: todoList}
 
-			var taskIndexElem int
-			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "edit"))
+				if id, err = utils.ValidateID(args[0], h.Todo.Tasks); err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				if taskIndexElem, err = utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
+					return fmt.Errorf("catch error when searching task by id: %w", err)
+				}
+
+				if newText != "" {
+					h.Edit(taskIndexElem, newText)
+				}
+				if changeDue {
+					h.SetDue(taskIndexElem, due)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after editing text of task: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("edit command failed", err)
 				return
-			} else {
-				taskIndexElem = i
 			}
 
-			newText := strings.Join(args[1:], " ")
-
-			h.Edit(taskIndexElem, newText)
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after editing text of task", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				logger.Info("text of task has been changed", slog.Int("id", id))
-				fmt.Printf("Task is changed: %s", utils.PrintInfoOfTask(id, taskIndexElem, h.Todo.Tasks))
+			logger.Info("text of task has been changed", slog.Int("id", id))
+			fmt.Printf("Task is changed: %s", utils.PrintInfoOfTask(id, taskIndexElem, h.Todo.Tasks))
+			if changeDue && due != nil {
+				fmt.Printf("Due: %s\n", due.Format("2006-01-02"))
+			} else if changeDue {
+				fmt.Println("The due date was removed")
 			}
 		},
 	}
 }
 
-func EditTaskPointsByDefaultCmd() *cobra.Command {
+func EditTaskPointsByDefaultCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "pointsdef <new count of points>",
 		Short: "Edits the count of points, what set for tasks by default",
@@ -298,9 +488,7 @@ func EditTaskPointsByDefaultCmd() *cobra.Command {
 				logger.Error("incorrect number for count of points", err, slog.String("command", "edit task points by default"))
 				return
 			}
-			config.EditTaskPointsByDefault(newTaskPointsByDef)
-
-			if err := config.SaveConfig(); err != nil {
+			if err := cfg.Set("defaults.task_points", newTaskPointsByDef); err != nil {
 				logger.Error("failed to save config file", err)
 			} else {
 				logger.Info("count of points by default has been changed", slog.Int("new count of points", newTaskPointsByDef))
@@ -312,55 +500,97 @@ func EditTaskPointsByDefaultCmd() *cobra.Command {
 
 func EditTaskPointsCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "points <ID> <new count of points>",
-		Short: "Edits the count of points for existing task",
-		Args:  cobra.ExactArgs(2),
+		Use:   "points <IDs> <new count of points>",
+		Short: "Edits the count of points for existing tasks",
+		Long: "Edits the count of points for existing tasks. Give one ID, lists and ranges of IDs\n" +
+			"like 3,5,8-12, or choose the tasks with --where and give only the count.",
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+			opts, err := readBulkOptions(cmd)
 			if err != nil {
-				logger.Error("edit points command failed", err)
+				logger.Error("incorrect using flags", err, slog.String("command", "edit task points by id"))
 				return
 			}
 
-			h := &handlers.TaskHandler{Todo: todoList}
-
-			id, err := utils.ValidateID(args[0], h.Todo.NextID-1)
+			newTaskPoints, err := utils.ValidatePointsOrPrice(args[len(args)-1])
 			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "edit task points by id"))
+				logger.Error("incorrect number for count of points", err, slog.String("command", "edit task points by id"))
 				return
 			}
 
-			var taskIndexElem int
-			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "edit task points by id"))
-				return
-			} else {
-				taskIndexElem = i
-			}
+			var (
+				h *handlers.TaskHandler
+				b *batch
+			)
+			err = storage.Update([]string{cfg.Storage.TodoFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+
+				h = &handlers.TaskHandler{Todo: todoList}
 
-			newTaskPoints, err := utils.ValidatePointsOrPrice(args[1])
+				ids, err := selectTasks(h, args[:len(args)-1], opts.where)
+				if err != nil {
+					return err
+				}
+				b, err = runBatch(ids, opts.bestEffort, func(id int) error {
+					taskIndexElem, err := utils.CheckExistItem(id, h.Todo.Tasks)
+					if err != nil {
+						return fmt.Errorf("catch error when searching task by id: %w", err)
+					}
+					h.EditTaskPoints(taskIndexElem, newTaskPoints)
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if len(b.done) == 0 {
+					return nil
+				}
+
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after editing count of points in task: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
-				logger.Error("incorrect number for count of points", err, slog.String("command", "edit task points by id"))
+				logger.Error("edit points command failed", err)
 				return
 			}
 
-			h.EditTaskPoints(taskIndexElem, newTaskPoints)
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after editing count of points in task", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				logger.Info("count of points has been changed for task", slog.Int("id", id))
-				fmt.Printf("Count of points has been changed for task %d: %s (%d points)\n", id, h.Todo.Tasks[taskIndexElem].Text, h.Todo.Tasks[taskIndexElem].TaskPoints)
+			if b.isBulk(opts) {
+				b.printSummary(fmt.Sprintf("Set %d points for", newTaskPoints))
+				logger.Info("count of points has been changed for tasks", slog.Int("count", len(b.done)))
+				return
 			}
+
+			id := b.done[0]
+			taskIndexElem, _ := utils.CheckExistItem(id, h.Todo.Tasks)
+			logger.Info("count of points has been changed for task", slog.Int("id", id))
+			fmt.Printf("Count of points has been changed for task %d: %s (%d points)\n", id, h.Todo.Tasks[taskIndexElem].Text, h.Todo.Tasks[taskIndexElem].TaskPoints)
 		},
 	}
 }
 
 func DeleteCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
-		Use:   "delete <ID> [flags]",
-		Short: "Delete the task from list",
-		Args:  cobra.ExactArgs(1),
+		Use:   "delete <IDs> [flags]",
+		Short: "Delete the tasks from list",
+		Long: "Delete the tasks from list. Give one ID, lists and ranges of IDs like 3,5,8-12,\n" +
+			"or choose the tasks with --where.",
 		Run: func(cmd *cobra.Command, args []string) {
+			forceFlag, err := cmd.Flags().GetBool("force")
+			if err != nil {
+				logger.Error("could not parse force flag", err, slog.String("flag", "--force"), slog.String("command", "delete"))
+				return
+			}
+			opts, err := readBulkOptions(cmd)
+			if err != nil {
+				logger.Error("incorrect using flags", err, slog.String("command", "delete"))
+				return
+			}
+
 			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
 			if err != nil {
 				logger.Error("delete command failed", err)
@@ -369,44 +599,81 @@ func DeleteCmd(cfg *config.Config) *cobra.Command {
 
 			h := &handlers.TaskHandler{Todo: todoList}
 
-			id, err := utils.ValidateID(args[0], h.Todo.NextID-1)
+			ids, err := selectTasks(h, args, opts.where)
 			if err != nil {
-				logger.Error("catch error when checking id", err, slog.String("command", "delete"))
+				logger.Error("delete command failed", err)
 				return
 			}
 
-			var taskIndexElem int
-			if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
-				logger.Error("catch error when searching task by id", err, slog.String("command", "delete"))
+			// Missing tasks are found before asking, the answer is about the
+			// tasks that will really be deleted.
+			b, err := runBatch(ids, opts.bestEffort, func(id int) error {
+				if _, err := utils.CheckExistItem(id, h.Todo.Tasks); err != nil {
+					return fmt.Errorf("catch error when searching task by id: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("delete command failed", err)
+				return
+			}
+			if len(b.done) == 0 {
+				b.printSummary("Deleted")
 				return
-			} else {
-				taskIndexElem = i
 			}
 
-			forceFlag, err := cmd.Flags().GetBool("force")
-			if err != nil {
-				logger.Error("could not parse force flag", err, slog.String("flag", "--force"), slog.String("command", "delete"))
+			if !forceFlag && !confirmDeletion(h, b.done) {
+				fmt.Println("The deletion was cancelled!")
+				logger.Info("the deletion was cancelled by user")
 				return
 			}
-			if !forceFlag {
-				fmt.Printf("You want to delete task: %s", utils.PrintInfoOfTask(id, taskIndexElem, h.Todo.Tasks))
-				fmt.Print("Are you sure (y/n): ")
-				var confirm string
-				fmt.Scanln(&confirm)
-				if strings.ToLower(confirm) != "y" {
-					fmt.Println("The deletion was cancelled!")
-					logger.Info("the deletion was cancelled by user")
-					return
+
+			// Deleting uncompleted tasks may cost points, so the balance is
+			// saved together with the list.
+			penalty := 0
+			err = storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
 				}
-			}
-			h.Delete(taskIndexElem)
 
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list  after deleting task", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				fmt.Printf("Task %d was deleted!\n", id)
-				logger.Info("task was deleted", slog.Int("id", id))
+				h = &handlers.TaskHandler{Todo: todoList}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				for _, id := range b.done {
+					if i, err := utils.CheckExistItem(id, h.Todo.Tasks); err == nil {
+						penalty += r.PenalizeDeletion(h.Todo.Tasks[i])
+					}
+				}
+				deleteTasks(h, b.done)
+
+				if penalty > 0 {
+					if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+						return fmt.Errorf("failed to save reward file after the penalty: %w", err)
+					}
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after deleting task: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("delete command failed", err)
+				return
+			}
+			if penalty > 0 {
+				defer fmt.Printf("Penalty for deleting uncompleted tasks: -%d points\n", penalty)
 			}
+			if b.isBulk(opts) {
+				b.printSummary("Deleted")
+				logger.Info("tasks were deleted", slog.Int("count", len(b.done)))
+				return
+			}
+			fmt.Printf("Task %d was deleted!\n", b.done[0])
+			logger.Info("task was deleted", slog.Int("id", b.done[0]))
 		},
 	}
 }
@@ -416,14 +683,6 @@ func ClearCmd(cfg *config.Config) *cobra.Command {
 		Use:   "clear",
 		Short: "Remove all tasks without restore with confirmation",
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
-			if err != nil {
-				logger.Error("clear command failed", err)
-				return
-			}
-
-			h := &handlers.TaskHandler{Todo: todoList}
-
 			fmt.Print("The tasks cannot be restored!\nAre you sure you want to delete ALL tasks? (y/n): ")
 			var confirm string
 			fmt.Scanln(&confirm)
@@ -433,14 +692,46 @@ func ClearCmd(cfg *config.Config) *cobra.Command {
 				return
 			}
 
-			h.ClearAllTasks()
+			// Uncompleted tasks cost the same penalty as when they are
+			// deleted one by one, so the balance is saved together with
+			// the list.
+			penalty := 0
+			err := storage.Update([]string{cfg.Storage.TodoFile, cfg.Storage.RewardFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				rewardSystem, err := loaders.LoadRewardSystemTx(tx, cfg.Storage.RewardFile, cfg.User)
+				if err != nil {
+					return err
+				}
 
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after clearing all tasks", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				fmt.Println("All tasks has been removed!")
-				logger.Info("All tasks was removed by user")
+				h := &handlers.TaskHandler{Todo: todoList}
+				r := &handlers.RewardHandler{RSystem: rewardSystem, Rules: handlers.NewRules(cfg)}
+				for _, task := range h.Todo.Tasks {
+					penalty += r.PenalizeDeletion(task)
+				}
+				h.ClearAllTasks()
+
+				if penalty > 0 {
+					if err := tx.Save(cfg.Storage.RewardFile, r.RSystem); err != nil {
+						return fmt.Errorf("failed to save reward file after the penalty: %w", err)
+					}
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after clearing all tasks: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("clear command failed", err)
+				return
 			}
+			fmt.Println("All tasks has been removed!")
+			if penalty > 0 {
+				fmt.Printf("Penalty for deleting uncompleted tasks: -%d points\n", penalty)
+			}
+			logger.Info("All tasks was removed by user")
 		},
 	}
 }
@@ -448,27 +739,111 @@ func ClearCmd(cfg *config.Config) *cobra.Command {
 func CancelLastDeleteCmd(cfg *config.Config) *cobra.Command {
 	return &cobra.Command{
 		Use:   "cancel-delete",
-		Short: "Cancels the last delete and returns the task as not completed in todolist with a new ID",
+		Short: "Cancels the last delete and returns the task as not completed in todolist with its ID",
 		Run: func(cmd *cobra.Command, args []string) {
-			todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+			var h *handlers.TaskHandler
+			err := storage.Update([]string{cfg.Storage.TodoFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+
+				h = &handlers.TaskHandler{Todo: todoList}
+				if err := h.CancelLastDelete(); err != nil {
+					return fmt.Errorf("catch error when try canceling last delete: %w", err)
+				}
+
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after cancelling last deleted task: %w", err)
+				}
+				return nil
+			})
 			if err != nil {
 				logger.Error("cancel-delete command failed", err)
 				return
 			}
 
-			h := &handlers.TaskHandler{Todo: todoList}
+			// The restored task is the last one, its ID is the old one unless
+			// another task took it.
+			restored := len(h.Todo.Tasks) - 1
+			logger.Info("the task was restored", slog.Int("id", h.Todo.Tasks[restored].ID))
+			fmt.Printf("The task was restored: %s", utils.PrintInfoOfTask(h.Todo.Tasks[restored].ID, restored, h.Todo.Tasks))
+		},
+	}
+}
 
-			if err := h.CancelLastDelete(); err != nil {
-				logger.Error("catch error when try canceling last delete", err)
+func MoveCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "move <ID> --to <list>",
+		Short: "Move the task to another list",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			targetList, err := cmd.Flags().GetString("to")
+			if err != nil {
+				logger.Error("could not parse to flag", err, slog.String("flag", "--to"), slog.String("command", "move"))
+				return
+			}
+			if targetList == cfg.List {
+				logger.Error("could not move task", fmt.Errorf("task is already in list %q", targetList))
 				return
 			}
 
-			if err := storage.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
-				logger.Error("failed to save todo list after cancelling last deleted task", err, slog.String("file", cfg.Storage.TodoFile))
-			} else {
-				logger.Info("the task was restored with new id", slog.Int("new id", h.Todo.NextID-1))
-				fmt.Printf("The task was restored with NEW ID: %s", utils.PrintInfoOfTask(h.Todo.NextID-1, len(h.Todo.Tasks)-1, h.Todo.Tasks))
+			targetStorage, err := cfg.ListStorage(targetList)
+			if err != nil {
+				logger.Error("could not move task", err)
+				return
 			}
+			// Both lists would be saved into the same file, the second save
+			// dropping the task the first one added.
+			if filepath.Clean(targetStorage.TodoFile) == filepath.Clean(cfg.Storage.TodoFile) {
+				logger.Error("could not move task", fmt.Errorf("lists %q and %q use the same todo file %s", cfg.List, targetList, cfg.Storage.TodoFile))
+				return
+			}
+
+			var (
+				id, newID int
+				target    *handlers.TaskHandler
+			)
+			err = storage.Update([]string{cfg.Storage.TodoFile, targetStorage.TodoFile}, func(tx *storage.Tx) error {
+				todoList, err := loaders.LoadTodoListTx(tx, cfg.Storage.TodoFile)
+				if err != nil {
+					return err
+				}
+				targetTodoList, err := loaders.LoadTodoListTx(tx, targetStorage.TodoFile)
+				if err != nil {
+					return err
+				}
+
+				h := &handlers.TaskHandler{Todo: todoList}
+				target = &handlers.TaskHandler{Todo: targetTodoList}
+
+				if id, err = utils.ValidateID(args[0], h.Todo.Tasks); err != nil {
+					return fmt.Errorf("catch error when checking id: %w", err)
+				}
+				taskIndexElem, err := utils.CheckExistItem(id, h.Todo.Tasks)
+				if err != nil {
+					return fmt.Errorf("catch error when searching task by id: %w", err)
+				}
+
+				newID = target.Put(h.Take(taskIndexElem))
+
+				// The target is saved first: if saving the source fails, the task
+				// exists twice instead of being lost.
+				if err := tx.Save(targetStorage.TodoFile, target.Todo); err != nil {
+					return fmt.Errorf("failed to save target todo list after moving task: %w", err)
+				}
+				if err := tx.Save(cfg.Storage.TodoFile, h.Todo); err != nil {
+					return fmt.Errorf("failed to save todo list after moving task: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				logger.Error("move command failed", err)
+				return
+			}
+
+			logger.Info("task was moved", slog.Int("id", id), slog.String("list", targetList), slog.Int("new id", newID))
+			fmt.Printf("Task %d was moved to list '%s' with NEW ID: %s", id, targetList, utils.PrintInfoOfTask(newID, len(target.Todo.Tasks)-1, target.Todo.Tasks))
 		},
 	}
 }
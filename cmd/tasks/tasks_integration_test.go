@@ -9,9 +9,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
 	"github.com/svetsed/todo_cli_app/internal/logger"
 	"github.com/svetsed/todo_cli_app/internal/models"
 )
@@ -28,13 +30,28 @@ func executeCommand(cmd *cobra.Command, args ...string) (string, error) {
 	return strings.TrimSpace(out.String()), err
 }
 
+// TestMain keeps config.LoadConfig away from the real config and data
+// directories of the user.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "todo-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestIntegration_AddCmd_SuccessfullyAddsTask(t *testing.T) {
 	logger.Init(slog.LevelDebug, io.Discard)
 
 	tempDir := t.TempDir()
 	todoFile := filepath.Join(tempDir, "test_todo.json")
 
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -89,7 +106,7 @@ func TestIntegration_ListCmd_SuccessfullyShowsTasks(t *testing.T) {
 	tempDir := t.TempDir()
 	todoFile := filepath.Join(tempDir, "test_todo.json")
 
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -139,7 +156,7 @@ func TestIntegration_ListCmd_ShowsPointsWithFlag(t *testing.T) {
 	tempDir := t.TempDir()
 	todoFile := filepath.Join(tempDir, "test_todo.json")
 
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -179,8 +196,8 @@ func TestIntegration_CompleteCmd_SuccessfullyCompletedTask(t *testing.T) {
 	todoFile := filepath.Join(tempDir, "test_todo.json")
 	rewardFile := filepath.Join(tempDir, "test_rewards.json")
 
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
-	t.Setenv("STORAGE_REWARD_FILE", rewardFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_REWARD_FILE", rewardFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -252,12 +269,83 @@ func TestIntegration_CompleteCmd_SuccessfullyCompletedTask(t *testing.T) {
 	}
 }
 
+func TestIntegration_CompleteCmd_BulkAllOrNothingAndBestEffort(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+
+	tempDir := t.TempDir()
+	t.Setenv("TODO_STORAGE_TODO_FILE", filepath.Join(tempDir, "test_todo_bulk.json"))
+	t.Setenv("TODO_STORAGE_REWARD_FILE", filepath.Join(tempDir, "test_rewards_bulk.json"))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config for test: %v", err)
+	}
+
+	todoList := &models.TodoList{
+		Tasks: []models.Task{
+			{ID: 1, Text: "First", TaskPoints: 10},
+			{ID: 2, Text: "Second", TaskPoints: 20, IsComplete: true},
+			{ID: 3, Text: "Third", TaskPoints: 30},
+		},
+		NextID: 4,
+	}
+	initialData, err := json.Marshal(todoList)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := os.WriteFile(cfg.Storage.TodoFile, initialData, 0666); err != nil {
+		t.Fatalf("Failed to write in file %s: %v", cfg.Storage.TodoFile, err)
+	}
+
+	readResult := func() (models.TodoList, models.RewardSystem) {
+		t.Helper()
+		var resultTodoList models.TodoList
+		var resultRewardSystem models.RewardSystem
+		todoData, _ := os.ReadFile(cfg.Storage.TodoFile)
+		if err := json.Unmarshal(todoData, &resultTodoList); err != nil {
+			t.Fatalf("Could not parse json file %s with tasks: %v", cfg.Storage.TodoFile, err)
+		}
+		if rewardData, err := os.ReadFile(cfg.Storage.RewardFile); err == nil {
+			_ = json.Unmarshal(rewardData, &resultRewardSystem)
+		}
+		return resultTodoList, resultRewardSystem
+	}
+
+	newCompleteCmd := func() *cobra.Command {
+		completeCmd := CompleteCmd(cfg)
+		completeCmd.Flags().BoolP("delete", "d", false, "Delete task after completion")
+		completeCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation (only with -d)")
+		AddBulkFlags(completeCmd)
+		return completeCmd
+	}
+
+	// Task 2 is already completed, so nothing is saved.
+	if _, err := executeCommand(newCompleteCmd(), "1-3"); err != nil {
+		t.Fatalf("CompleteCmd command finished with an unexpected error: %v", err)
+	}
+	resultTodoList, resultRewardSystem := readResult()
+	if resultTodoList.Tasks[0].IsComplete || resultRewardSystem.UserPoints != 0 {
+		t.Fatalf("Expected nothing to change without --best-effort, got %+v and %d points", resultTodoList.Tasks, resultRewardSystem.UserPoints)
+	}
+
+	if _, err := executeCommand(newCompleteCmd(), "1-3", "--best-effort"); err != nil {
+		t.Fatalf("CompleteCmd command finished with an unexpected error: %v", err)
+	}
+	resultTodoList, resultRewardSystem = readResult()
+	if !resultTodoList.Tasks[0].IsComplete || !resultTodoList.Tasks[2].IsComplete {
+		t.Errorf("Expected tasks 1 and 3 to be completed, got %+v", resultTodoList.Tasks)
+	}
+	if resultRewardSystem.UserPoints != 40 {
+		t.Errorf("Expected user points balance to be 40, but it is %d", resultRewardSystem.UserPoints)
+	}
+}
+
 func TestIntegration_DeleteCmd_SuccessfullyDeletesTask(t *testing.T) {
 	logger.Init(slog.LevelDebug, io.Discard)
 
 	tempDir := t.TempDir()
 	todoFile := filepath.Join(tempDir, "test_todo_for_delete.json")
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -318,7 +406,7 @@ func TestIntegration_EditCmd_SuccessfullyEditsTask(t *testing.T) {
 
 	tempDir := t.TempDir()
 	todoFile := filepath.Join(tempDir, "test_todo_for_edit.json")
-	t.Setenv("STORAGE_TODO_FILE", todoFile)
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
 
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -360,3 +448,163 @@ func TestIntegration_EditCmd_SuccessfullyEditsTask(t *testing.T) {
 		t.Errorf("Expected task text to be '%s', but got '%s'", newText, resultList.Tasks[0].Text)
 	}
 }
+
+func TestIntegration_CompleteCmd_ByUUIDPrefixAfterMigration(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+
+	tempDir := t.TempDir()
+	t.Setenv("TODO_STORAGE_TODO_FILE", filepath.Join(tempDir, "test_todo.json"))
+	t.Setenv("TODO_STORAGE_REWARD_FILE", filepath.Join(tempDir, "test_rewards.json"))
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config for test: %v", err)
+	}
+
+	// A list of an older version: no UUIDs and NextID reset after a clear.
+	todoList := &models.TodoList{
+		Tasks:  []models.Task{{ID: 1, Text: "First"}, {ID: 2, Text: "Second", TaskPoints: 5}},
+		NextID: 1,
+	}
+	initialData, err := json.Marshal(todoList)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if err := os.WriteFile(cfg.Storage.TodoFile, initialData, 0666); err != nil {
+		t.Fatalf("Failed to write in file %s: %v", cfg.Storage.TodoFile, err)
+	}
+
+	listCmd := ListCmd(cfg)
+	listCmd.Flags().BoolP("points", "p", false, "Show points")
+	listCmd.Flags().Bool("uuid", false, "Show UUIDs")
+	output, err := executeCommand(listCmd, "--uuid")
+	if err != nil {
+		t.Fatalf("ListCmd command finished with an unexpected error: %v", err)
+	}
+
+	var migrated models.TodoList
+	todoData, err := os.ReadFile(cfg.Storage.TodoFile)
+	if err != nil {
+		t.Fatalf("Could not read file %s with tasks: %v", cfg.Storage.TodoFile, err)
+	}
+	if err := json.Unmarshal(todoData, &migrated); err != nil {
+		t.Fatalf("Could not parse json file %s with tasks: %v", cfg.Storage.TodoFile, err)
+	}
+	if migrated.NextID != 3 {
+		t.Errorf("Expected NextID to move past the taken IDs to 3, got %d", migrated.NextID)
+	}
+	uid := migrated.Tasks[1].UID
+	if uid == "" || !strings.Contains(output, uid) {
+		t.Fatalf("Expected the saved UUID %q in the output:\n%s", uid, output)
+	}
+
+	completeCmd := CompleteCmd(cfg)
+	completeCmd.Flags().BoolP("delete", "d", false, "Delete task after completion")
+	completeCmd.Flags().BoolP("force", "f", false, "Force delete without confirmation (only with -d)")
+	if _, err := executeCommand(completeCmd, uid[:8]); err != nil {
+		t.Fatalf("CompleteCmd command finished with an unexpected error: %v", err)
+	}
+
+	var result models.TodoList
+	todoData, _ = os.ReadFile(cfg.Storage.TodoFile)
+	if err := json.Unmarshal(todoData, &result); err != nil {
+		t.Fatalf("Could not parse json file %s with tasks: %v", cfg.Storage.TodoFile, err)
+	}
+	if result.Tasks[0].IsComplete || !result.Tasks[1].IsComplete {
+		t.Errorf("Expected only the task with the UUID to be completed, got %+v", result.Tasks)
+	}
+	if result.Tasks[1].UID != uid {
+		t.Errorf("Expected the UUID to stay %q, got %q", uid, result.Tasks[1].UID)
+	}
+}
+
+func TestIntegration_OverduePenalty_AddWithDueAndComplete(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+
+	tempDir := t.TempDir()
+	t.Setenv("TODO_STORAGE_TODO_FILE", filepath.Join(tempDir, "test_todo.json"))
+	t.Setenv("TODO_STORAGE_REWARD_FILE", filepath.Join(tempDir, "test_rewards.json"))
+	t.Setenv("TODO_PENALTIES_OVERDUE", "15")
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config for test: %v", err)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	addCmd := AddCmd(cfg)
+	addCmd.Flags().IntP("points", "p", 0, "Counts of points")
+	addCmd.Flags().String("due", "", "Due date")
+	if _, err := executeCommand(addCmd, "Pay taxes", "-p", "40", "--due", yesterday); err != nil {
+		t.Fatalf("AddCmd command finished with an unexpected error: %v", err)
+	}
+
+	// Completing the task late still costs the penalty, but only once.
+	complete := func() {
+		completeCmd := CompleteCmd(cfg)
+		completeCmd.Flags().BoolP("delete", "d", false, "Delete task after completion")
+		completeCmd.Flags().BoolP("force", "f", false, "Force delete")
+		AddBulkFlags(completeCmd)
+		if _, err := executeCommand(completeCmd, "1"); err != nil {
+			t.Fatalf("CompleteCmd command finished with an unexpected error: %v", err)
+		}
+	}
+	complete()
+	notCompletedCmd := NotCompletedCmd(cfg)
+	AddBulkFlags(notCompletedCmd)
+	if _, err := executeCommand(notCompletedCmd, "1"); err != nil {
+		t.Fatalf("NotCompletedCmd command finished with an unexpected error: %v", err)
+	}
+	complete()
+
+	todoList, err := loaders.LoadTodoList(cfg.Storage.TodoFile)
+	if err != nil {
+		t.Fatalf("LoadTodoList returned error: %v", err)
+	}
+	task := todoList.Tasks[0]
+	if task.Due == nil || task.Due.Format("2006-01-02") != yesterday || !task.IsComplete || !task.Penalized {
+		t.Fatalf("Expected the completed task due yesterday to be penalized, got %+v", task)
+	}
+	rewardSystem, err := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+	if err != nil {
+		t.Fatalf("LoadRewardSystem returned error: %v", err)
+	}
+	if rewardSystem.UserPoints != 25 {
+		t.Errorf("Expected 40 points minus the penalty of 15 once, got %d", rewardSystem.UserPoints)
+	}
+}
+
+func TestIntegration_MoveCmd_RefusesListsSharingTheTodoFile(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+
+	tempDir := t.TempDir()
+	todoFile := filepath.Join(tempDir, "test_todo.json")
+	t.Setenv("TODO_STORAGE_TODO_FILE", todoFile)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("Failed to load config for test: %v", err)
+	}
+	// Validate refuses such a config, the command has to be safe anyway.
+	cfg.Lists = map[string]config.ListConfig{"work": {Storage: config.StorageConfig{TodoFile: todoFile}}}
+
+	addCmd := AddCmd(cfg)
+	addCmd.Flags().IntP("points", "p", 0, "Counts of points")
+	if _, err := executeCommand(addCmd, "Write report"); err != nil {
+		t.Fatalf("AddCmd command finished with an unexpected error: %v", err)
+	}
+
+	moveCmd := MoveCmd(cfg)
+	moveCmd.Flags().String("to", "", "Target list")
+	if _, err := executeCommand(moveCmd, "1", "--to", "work"); err != nil {
+		t.Fatalf("MoveCmd command finished with an unexpected error: %v", err)
+	}
+
+	todoList, err := loaders.LoadTodoList(todoFile)
+	if err != nil {
+		t.Fatalf("LoadTodoList returned error: %v", err)
+	}
+	if len(todoList.Tasks) != 1 || todoList.Tasks[0].Text != "Write report" {
+		t.Errorf("Expected the task to stay in the shared file, got %+v", todoList.Tasks)
+	}
+}
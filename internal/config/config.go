@@ -2,68 +2,229 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/viper"
 )
 
-type Config struct {
-	Storage struct {
-		TodoFile   string `mapstructure:"todo_file"`
-		RewardFile string `mapstructure:"reward_file"`
-	} `mapstructure:"storage"`
-	Defaults struct {
-		TaskPoints  int `mapstructure:"task_points"`
-		RewardPrice int `mapstructure:"reward_price"`
-	} `mapstructure:"defaults"`
+type StorageConfig struct {
+	TodoFile   string `mapstructure:"todo_file"`
+	RewardFile string `mapstructure:"reward_file"`
 }
 
-func LoadConfig() (*Config, error) {
-	viper.AutomaticEnv()
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+type DefaultsConfig struct {
+	TaskPoints  int `mapstructure:"task_points"`
+	RewardPrice int `mapstructure:"reward_price"`
+}
+
+type ServerConfig struct {
+	Addr  string `mapstructure:"addr"`
+	Token string `mapstructure:"token"`
+}
+
+// StreakConfig sets when a day counts for the streak and the bonus points
+// for reaching a number of days in a row.
+type StreakConfig struct {
+	MinTasks int            `mapstructure:"min_tasks"`
+	Bonuses  map[string]int `mapstructure:"bonuses"`
+}
+
+// PenaltiesConfig sets the points lost for a task which passed its due date
+// uncompleted and for a task deleted without completion. 0 turns a penalty
+// off. Without AllowDebt the balance never goes below zero.
+type PenaltiesConfig struct {
+	Overdue   int  `mapstructure:"overdue"`
+	Delete    int  `mapstructure:"delete"`
+	AllowDebt bool `mapstructure:"allow_debt"`
+}
+
+// ApprovalConfig sets who approves requests and the transfers of points
+// which need it, 0 means none do.
+type ApprovalConfig struct {
+	Approvers      []string `mapstructure:"approvers"`
+	TransfersAbove int      `mapstructure:"transfers_above"`
+}
 
-	viper.SetDefault("storage.todo_file", "todo.json")
-	viper.SetDefault("storage.reward_file", "rewards.json")
-	viper.SetDefault("defaults.task_points", 20)
-	viper.SetDefault("defaults.reward_price", 20)
+type Config struct {
+	Storage     StorageConfig         `mapstructure:"storage"`
+	Defaults    DefaultsConfig        `mapstructure:"defaults"`
+	Lists       map[string]ListConfig `mapstructure:"lists"`
+	CurrentList string                `mapstructure:"current_list"`
+	Encryption  struct {
+		Enabled bool   `mapstructure:"enabled"`
+		KeyFile string `mapstructure:"key_file"`
+	} `mapstructure:"encryption"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Streak    StreakConfig    `mapstructure:"streak"`
+	Penalties PenaltiesConfig `mapstructure:"penalties"`
+	// User is who runs the commands when a list is shared, TODO_USER sets
+	// it for one shell.
+	User string `mapstructure:"user"`
+	// Users share the list with User, points can be given and tasks
+	// assigned only to them and to the users who already have an account.
+	Users    []string       `mapstructure:"users"`
+	Approval ApprovalConfig `mapstructure:"approval"`
+	Paths    Paths          `mapstructure:"-"`
+
+	// List is the name of the list that Storage and Defaults belong to now.
+	List         string         `mapstructure:"-"`
+	baseStorage  StorageConfig  `mapstructure:"-"`
+	baseDefaults DefaultsConfig `mapstructure:"-"`
+	v            *viper.Viper
+	loadErrs     ValidationErrors
+}
 
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
+// defaultValues are used for keys that are set neither in the config file
+// nor in the environment. 'todo init' writes them into a new config file.
+var defaultValues = map[string]any{
+	"storage.todo_file":        "todo.json",
+	"storage.reward_file":      "rewards.json",
+	"defaults.task_points":     20,
+	"defaults.reward_price":    20,
+	"current_list":             DefaultList,
+	"encryption.enabled":       false,
+	"encryption.key_file":      "",
+	"server.addr":              "127.0.0.1:8080",
+	"server.token":             "",
+	"streak.min_tasks":         1,
+	"penalties.overdue":        0,
+	"penalties.delete":         0,
+	"penalties.allow_debt":     false,
+	"user":                     "",
+	"users":                    []any{},
+	"approval.approvers":       []any{},
+	"approval.transfers_above": 0,
+}
 
-	err := viper.ReadInConfig()
+// LoadConfig reads the config file if there is one. It never creates the
+// file, see InitConfig. When values are invalid, the config is returned
+// together with ValidationErrors, so commands that fix the config still work.
+func LoadConfig() (*Config, error) {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
 
+	for key, value := range defaultValues {
+		v.SetDefault(key, value)
+	}
+
+	paths, err := ResolvePaths()
 	if err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			fmt.Println("Config file not found. Creating a new one 'config.yaml' with default values.")
+		return nil, fmt.Errorf("could not resolve config location: %w", err)
+	}
+
+	v.SetConfigFile(paths.ConfigFile)
+	v.SetConfigType("yaml")
 
-			if writeErr := viper.WriteConfigAs("./config.yaml"); writeErr != nil {
-				return nil, fmt.Errorf("could not create config file: %w", writeErr)
-			}
-		} else {
+	var loadErrs ValidationErrors
+	if _, err := os.Stat(paths.ConfigFile); err == nil {
+		if err := v.ReadInConfig(); err != nil {
 			return nil, fmt.Errorf("could not reading config file: %w", err)
 		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("could not reading config file: %w", err)
 	}
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("unable to decode into struct: %w", err)
+	if err := v.Unmarshal(&cfg); err != nil {
+		loadErrs = append(loadErrs, ValidationError{Key: paths.ConfigFile, Reason: err.Error()})
 	}
 
+	cfg.v = v
+	cfg.loadErrs = loadErrs
+	cfg.Paths = paths
+	cfg.Storage.TodoFile = resolveDataFile(paths.DataDir, cfg.Storage.TodoFile)
+	cfg.Storage.RewardFile = resolveDataFile(paths.DataDir, cfg.Storage.RewardFile)
+	cfg.List = DefaultList
+	cfg.baseStorage = cfg.Storage
+	cfg.baseDefaults = cfg.Defaults
+
+	if err := cfg.Validate(); err != nil {
+		return &cfg, err
+	}
 	return &cfg, nil
 }
 
-func SaveConfig() error {
-	if err := viper.WriteConfig(); err != nil {
-		return fmt.Errorf("could not save settings to config file: %v", err)
+// StreakBonuses returns the bonus points by the days of the streak. Keys
+// which are not numbers are reported by Validate and skipped here.
+func (c *Config) StreakBonuses() map[int]int {
+	bonuses := make(map[int]int, len(c.Streak.Bonuses))
+	for days, points := range c.Streak.Bonuses {
+		if n, err := strconv.Atoi(days); err == nil {
+			bonuses[n] = points
+		}
+	}
+	return bonuses
+}
+
+// InitConfig writes a config file with the default values to path.
+func InitConfig(path string) error {
+	f, err := OpenFile(path)
+	if err != nil {
+		return err
+	}
+	for key, value := range defaultValues {
+		if err := f.Set(key, value); err != nil {
+			return err
+		}
+	}
+	return f.Save()
+}
+
+// Edit opens the config file, applies edit and saves the result.
+func (c *Config) Edit(edit func(f *File) error) error {
+	f, err := OpenFile(c.Paths.ConfigFile)
+	if err != nil {
+		return err
 	}
-	return nil
+	if err := edit(f); err != nil {
+		return err
+	}
+	return f.Save()
+}
+
+// Set writes a single key into the config file.
+func (c *Config) Set(key string, value any) error {
+	return c.Edit(func(f *File) error {
+		return f.Set(key, value)
+	})
 }
 
-func EditPriceOfRewardByDefault(newPriceOfRewardByDef int) {
-	viper.Set("defaults.reward_price", newPriceOfRewardByDef)
+// Setting is the effective value of a key and where it comes from.
+type Setting struct {
+	Key    string
+	Value  any
+	Source string
 }
 
-func EditTaskPointsByDefault(newTaskPointsByDef int) {
-	viper.Set("defaults.task_points", newTaskPointsByDef)
+// Settings returns the effective value of every key, including defaults and
+// environment overrides, sorted by key.
+func (c *Config) Settings() ([]Setting, error) {
+	f, err := OpenFile(c.Paths.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := c.v.AllKeys()
+	sort.Strings(keys)
+
+	settings := make([]Setting, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := lookupKey(key); !ok {
+			continue
+		}
+
+		source := "default"
+		if _, ok := os.LookupEnv(EnvName(key)); ok {
+			source = "env " + EnvName(key)
+		} else if _, ok := f.Get(key); ok {
+			source = "file"
+		}
+		settings = append(settings, Setting{Key: key, Value: c.v.Get(key), Source: source})
+	}
+	return settings, nil
 }
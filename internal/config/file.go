@@ -0,0 +1,158 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the content of the config file as written by the user, without
+// defaults or environment overrides, so saving it never persists values
+// that only came from the environment.
+type File struct {
+	path string
+	data map[string]any
+}
+
+func OpenFile(path string) (*File, error) {
+	f := &File{path: path, data: map[string]any{}}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, &f.data); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	if f.data == nil {
+		f.data = map[string]any{}
+	}
+	return f, nil
+}
+
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) Get(key string) (any, bool) {
+	var node any = f.data
+	for _, part := range strings.Split(key, ".") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// Set writes value under key. Keys that Config does not know are refused, as
+// are values of the wrong type.
+func (f *File) Set(key string, value any) error {
+	spec, ok := lookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	if err := checkValue(key, spec, value); err != nil {
+		return err
+	}
+
+	parts := strings.Split(key, ".")
+	node := f.data
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := node[part].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			node[part] = child
+		}
+		node = child
+	}
+	node[parts[len(parts)-1]] = value
+	return nil
+}
+
+// Unset removes key, or a whole section such as lists.work, from the file.
+// Sections left empty are removed as well.
+func (f *File) Unset(key string) bool {
+	return unset(f.data, strings.Split(key, "."))
+}
+
+func unset(node map[string]any, parts []string) bool {
+	if len(parts) == 1 {
+		_, ok := node[parts[0]]
+		delete(node, parts[0])
+		return ok
+	}
+
+	child, ok := node[parts[0]].(map[string]any)
+	if !ok {
+		return false
+	}
+	removed := unset(child, parts[1:])
+	if len(child) == 0 {
+		delete(node, parts[0])
+	}
+	return removed
+}
+
+// Keys returns all leaf keys present in the file.
+func (f *File) Keys() []string {
+	var keys []string
+	collectKeys(f.data, "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+func collectKeys(node map[string]any, prefix string, keys *[]string) {
+	for name, value := range node {
+		if child, ok := value.(map[string]any); ok {
+			collectKeys(child, prefix+name+".", keys)
+			continue
+		}
+		*keys = append(*keys, prefix+name)
+	}
+}
+
+// UnknownKeys returns the keys of the file that Config does not know.
+func (f *File) UnknownKeys() []string {
+	var unknown []string
+	for _, key := range f.Keys() {
+		if _, ok := lookupKey(key); !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
+
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(4)
+	if err := encoder.Encode(f.data); err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	tmpFile := f.path + ".tmp"
+	if err := os.WriteFile(tmpFile, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("could not save settings to config file: %w", err)
+	}
+	if err := os.Rename(tmpFile, f.path); err != nil {
+		return fmt.Errorf("could not save settings to config file: %w", err)
+	}
+	return nil
+}
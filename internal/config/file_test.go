@@ -0,0 +1,68 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFile_SetValidatesKeysAndValues(t *testing.T) {
+	f, err := OpenFile(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("OpenFile returned error: %v", err)
+	}
+
+	if err := f.Set("defaults.task_points", 10); err != nil {
+		t.Errorf("Expected known key to be accepted, got %v", err)
+	}
+	if err := f.Set("lists.work.storage.todo_file", "work.json"); err != nil {
+		t.Errorf("Expected key of a named list to be accepted, got %v", err)
+	}
+	if err := f.Set("defaults.unknown", 1); err == nil {
+		t.Error("Expected unknown key to be refused")
+	}
+	if err := f.Set("defaults.task_points", "ten"); err == nil {
+		t.Error("Expected value of the wrong type to be refused")
+	}
+}
+
+func TestFile_SaveAndUnset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todo", "config.yaml")
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile returned error: %v", err)
+	}
+	if err := f.Set("lists.home.storage.todo_file", "home.json"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	reopened, err := OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile returned error: %v", err)
+	}
+	if value, ok := reopened.Get("lists.home.storage.todo_file"); !ok || value != "home.json" {
+		t.Errorf("Expected saved value 'home.json', got %v", value)
+	}
+
+	if !reopened.Unset("lists.home.storage.todo_file") {
+		t.Fatal("Expected Unset to report the removed key")
+	}
+	if _, ok := reopened.Get("lists"); ok {
+		t.Error("Expected empty sections to be removed together with the last key")
+	}
+}
+
+func TestParseValueAndEnvName(t *testing.T) {
+	value, err := ParseValue("encryption.enabled", "true")
+	if err != nil || value != true {
+		t.Errorf("Expected true, got %v (%v)", value, err)
+	}
+	if _, err := ParseValue("defaults.reward_price", "-"); err == nil {
+		t.Error("Expected error for a non-numeric price")
+	}
+	if got := EnvName("defaults.task_points"); got != "TODO_DEFAULTS_TASK_POINTS" {
+		t.Errorf("Expected TODO_DEFAULTS_TASK_POINTS, got %s", got)
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// DefaultList is the list described by the top-level storage and defaults
+// sections, it always exists.
+const DefaultList = "default"
+
+var listNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ListConfig is a named list with its own data files. Unset defaults are
+// inherited from the top-level defaults section.
+type ListConfig struct {
+	Storage  StorageConfig `mapstructure:"storage"`
+	Defaults struct {
+		TaskPoints  *int `mapstructure:"task_points"`
+		RewardPrice *int `mapstructure:"reward_price"`
+	} `mapstructure:"defaults"`
+}
+
+// UseList switches Storage and Defaults to the list with the given name.
+// Commands keep a pointer to the same Config, so they all follow the switch.
+func (c *Config) UseList(name string) error {
+	storage, err := c.ListStorage(name)
+	if err != nil {
+		return err
+	}
+
+	defaults := c.baseDefaults
+	if list, ok := c.Lists[name]; ok && name != DefaultList {
+		if list.Defaults.TaskPoints != nil {
+			defaults.TaskPoints = *list.Defaults.TaskPoints
+		}
+		if list.Defaults.RewardPrice != nil {
+			defaults.RewardPrice = *list.Defaults.RewardPrice
+		}
+	}
+
+	c.List = name
+	c.Storage = storage
+	c.Defaults = defaults
+	return nil
+}
+
+// ListStorage returns the resolved data files of a list without switching to it.
+func (c *Config) ListStorage(name string) (StorageConfig, error) {
+	if name == "" || name == DefaultList {
+		return c.baseStorage, nil
+	}
+
+	list, ok := c.Lists[name]
+	if !ok {
+		return StorageConfig{}, fmt.Errorf("unknown list %q (see 'todo lists')", name)
+	}
+
+	storage := list.Storage
+	if storage.TodoFile == "" {
+		storage.TodoFile = fmt.Sprintf("todo-%s.json", name)
+	}
+	if storage.RewardFile == "" {
+		storage.RewardFile = fmt.Sprintf("rewards-%s.json", name)
+	}
+	storage.TodoFile = resolveDataFile(c.Paths.DataDir, storage.TodoFile)
+	storage.RewardFile = resolveDataFile(c.Paths.DataDir, storage.RewardFile)
+	return storage, nil
+}
+
+// ListNames returns the default list followed by the named lists in
+// alphabetical order.
+func (c *Config) ListNames() []string {
+	names := make([]string, 0, len(c.Lists)+1)
+	for name := range c.Lists {
+		if name != DefaultList {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultList}, names...)
+}
+
+func (c *Config) HasList(name string) bool {
+	if name == DefaultList {
+		return true
+	}
+	_, ok := c.Lists[name]
+	return ok
+}
+
+func ValidateListName(name string) error {
+	if !listNamePattern.MatchString(name) {
+		return fmt.Errorf("list name may only contain letters, digits, '-' and '_'")
+	}
+	return nil
+}
+
+// AddList writes a new list with data files named after it into the config file.
+func (c *Config) AddList(name string) error {
+	return c.Edit(func(f *File) error {
+		if err := f.Set("lists."+name+".storage.todo_file", fmt.Sprintf("todo-%s.json", name)); err != nil {
+			return err
+		}
+		return f.Set("lists."+name+".storage.reward_file", fmt.Sprintf("rewards-%s.json", name))
+	})
+}
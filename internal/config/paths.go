@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	appDirName     = "todo"
+	ProjectDirName = ".todo"
+	configFileName = "config.yaml"
+)
+
+// Paths describes where the config and the data files live. A project-local
+// .todo/ directory takes precedence over the XDG locations.
+type Paths struct {
+	ConfigFile string
+	DataDir    string
+	ProjectDir string
+}
+
+func ResolvePaths() (Paths, error) {
+	projectDir, err := findProjectDir()
+	if err != nil {
+		return Paths{}, err
+	}
+
+	if projectDir != "" {
+		return Paths{
+			ConfigFile: filepath.Join(projectDir, configFileName),
+			DataDir:    projectDir,
+			ProjectDir: projectDir,
+		}, nil
+	}
+
+	configHome, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return Paths{}, err
+	}
+	dataHome, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return Paths{}, err
+	}
+
+	return Paths{
+		ConfigFile: filepath.Join(configHome, appDirName, configFileName),
+		DataDir:    filepath.Join(dataHome, appDirName),
+	}, nil
+}
+
+// xdgDir follows the XDG Base Directory spec: relative values are invalid
+// and must be ignored in favour of the default under the home directory.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory for %s: %w", env, err)
+	}
+	return filepath.Join(home, fallback), nil
+}
+
+// findProjectDir walks up from the working directory and returns the first
+// .todo/ directory it meets, or "" if there is none.
+func findProjectDir() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("could not get working directory: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, ProjectDirName)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func resolveDataFile(dataDir, file string) string {
+	if strings.TrimSpace(file) == "" {
+		return ""
+	}
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dataDir, file)
+}
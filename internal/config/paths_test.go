@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	old, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Could not get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Could not change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(old) })
+}
+
+func TestResolvePaths_XDG(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	chdir(t, root)
+
+	paths, err := ResolvePaths()
+	if err != nil {
+		t.Fatalf("ResolvePaths returned error: %v", err)
+	}
+
+	if want := filepath.Join(root, "cfg", "todo", "config.yaml"); paths.ConfigFile != want {
+		t.Errorf("Expected config file %s, got %s", want, paths.ConfigFile)
+	}
+	if want := filepath.Join(root, "data", "todo"); paths.DataDir != want {
+		t.Errorf("Expected data dir %s, got %s", want, paths.DataDir)
+	}
+	if paths.ProjectDir != "" {
+		t.Errorf("Expected no project dir, got %s", paths.ProjectDir)
+	}
+}
+
+func TestResolvePaths_ProjectDirInParent(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, ".todo")
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(project, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, nested)
+
+	paths, err := ResolvePaths()
+	if err != nil {
+		t.Fatalf("ResolvePaths returned error: %v", err)
+	}
+
+	// The temp dir may be reached through a symlink (e.g. on macOS).
+	wantProject, _ := filepath.EvalSymlinks(project)
+	gotProject, _ := filepath.EvalSymlinks(paths.ProjectDir)
+	if gotProject != wantProject {
+		t.Errorf("Expected project dir %s, got %s", wantProject, gotProject)
+	}
+	if paths.DataDir != paths.ProjectDir {
+		t.Errorf("Expected data dir to be the project dir, got %s", paths.DataDir)
+	}
+	if paths.ConfigFile != filepath.Join(paths.ProjectDir, "config.yaml") {
+		t.Errorf("Expected config file inside project dir, got %s", paths.ConfigFile)
+	}
+}
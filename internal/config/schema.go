@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix is the prefix of environment variables that override config keys:
+// defaults.task_points is read from TODO_DEFAULTS_TASK_POINTS.
+const EnvPrefix = "TODO"
+
+// keySpec is a settable leaf of Config. A "*" segment in the pattern matches
+// any name, it comes from map fields such as lists.
+type keySpec struct {
+	pattern string
+	kind    reflect.Kind
+	elem    reflect.Kind
+}
+
+var schema = buildSchema(reflect.TypeOf(Config{}), "")
+
+func buildSchema(t reflect.Type, prefix string) []keySpec {
+	var specs []keySpec
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+		specs = append(specs, specsForType(field.Type, prefix+tag)...)
+	}
+	return specs
+}
+
+func specsForType(t reflect.Type, key string) []keySpec {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return buildSchema(t, key+".")
+	case reflect.Map:
+		return specsForType(t.Elem(), key+".*")
+	case reflect.Slice:
+		return []keySpec{{pattern: key, kind: reflect.Slice, elem: t.Elem().Kind()}}
+	default:
+		return []keySpec{{pattern: key, kind: t.Kind()}}
+	}
+}
+
+func lookupKey(key string) (keySpec, bool) {
+	parts := strings.Split(key, ".")
+	for _, spec := range schema {
+		if matchPattern(strings.Split(spec.pattern, "."), parts) {
+			return spec, true
+		}
+	}
+	return keySpec{}, false
+}
+
+func matchPattern(pattern, parts []string) bool {
+	if len(pattern) != len(parts) {
+		return false
+	}
+	for i := range pattern {
+		if parts[i] == "" || (pattern[i] != "*" && pattern[i] != parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// KnownKeys returns the patterns of all keys that may appear in the config.
+func KnownKeys() []string {
+	keys := make([]string, 0, len(schema))
+	for _, spec := range schema {
+		keys = append(keys, spec.pattern)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EnvName returns the environment variable that overrides key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// ParseValue converts a value typed on the command line into the type that
+// Config expects for key.
+func ParseValue(key, raw string) (any, error) {
+	spec, ok := lookupKey(key)
+	if !ok {
+		return nil, fmt.Errorf("unknown config key %q", key)
+	}
+	return parseKind(key, spec.kind, spec.elem, raw)
+}
+
+func parseKind(key string, kind, elem reflect.Kind, raw string) (any, error) {
+	switch kind {
+	case reflect.String:
+		return raw, nil
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be an integer, got %q", key, raw)
+		}
+		return n, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false, got %q", key, raw)
+		}
+		return b, nil
+	case reflect.Slice:
+		values := []any{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			v, err := parseKind(key, elem, reflect.Invalid, item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("%s has unsupported type %s", key, kind)
+	}
+}
+
+// checkValue reports whether value, as decoded from YAML, fits key.
+func checkValue(key string, spec keySpec, value any) error {
+	switch spec.kind {
+	case reflect.String:
+		if _, ok := value.(string); ok {
+			return nil
+		}
+	case reflect.Int:
+		if _, ok := value.(int); ok {
+			return nil
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); ok {
+			return nil
+		}
+	case reflect.Slice:
+		items, ok := value.([]any)
+		if !ok {
+			break
+		}
+		for _, item := range items {
+			if err := checkValue(key, keySpec{kind: spec.elem}, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s must be of type %s, got %v", key, kindName(spec), value)
+}
+
+func kindName(spec keySpec) string {
+	if spec.kind == reflect.Slice {
+		return "list of " + spec.elem.String()
+	}
+	return spec.kind.String()
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes one invalid config value.
+type ValidationError struct {
+	Key    string
+	Value  any
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("%s: %s", e.Key, e.Reason)
+	}
+	return fmt.Sprintf("%s: %s (got %v)", e.Key, e.Reason, e.Value)
+}
+
+// ValidationErrors is returned by LoadConfig and Validate when the config
+// can be read but some of its values are unusable.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+// Validate checks the values of the config and the data directories. It
+// returns ValidationErrors or nil.
+func (c *Config) Validate() error {
+	errs := append(ValidationErrors{}, c.loadErrs...)
+
+	if f, err := OpenFile(c.Paths.ConfigFile); err != nil {
+		errs = append(errs, ValidationError{Key: c.Paths.ConfigFile, Reason: err.Error()})
+	} else {
+		for _, key := range f.UnknownKeys() {
+			errs = append(errs, ValidationError{Key: key, Reason: "unknown key"})
+		}
+	}
+
+	errs = append(errs, validateDefaults("defaults", c.baseDefaults.TaskPoints, c.baseDefaults.RewardPrice)...)
+	errs = append(errs, validateStorage("storage", c.baseStorage)...)
+	// Two lists must not share a data file: moving a task between them
+	// would save the file twice and lose the task.
+	owners := map[string]string{}
+	errs = append(errs, checkSharedFiles(owners, "storage", c.baseStorage)...)
+
+	for _, name := range c.ListNames()[1:] {
+		prefix := "lists." + name
+		if err := ValidateListName(name); err != nil {
+			errs = append(errs, ValidationError{Key: prefix, Reason: err.Error()})
+			continue
+		}
+
+		list := c.Lists[name]
+		if list.Defaults.TaskPoints != nil && *list.Defaults.TaskPoints < 0 {
+			errs = append(errs, ValidationError{Key: prefix + ".defaults.task_points", Value: *list.Defaults.TaskPoints, Reason: "must not be negative"})
+		}
+		if list.Defaults.RewardPrice != nil && *list.Defaults.RewardPrice < 0 {
+			errs = append(errs, ValidationError{Key: prefix + ".defaults.reward_price", Value: *list.Defaults.RewardPrice, Reason: "must not be negative"})
+		}
+
+		storage, err := c.ListStorage(name)
+		if err != nil {
+			errs = append(errs, ValidationError{Key: prefix, Reason: err.Error()})
+			continue
+		}
+		errs = append(errs, validateStorage(prefix+".storage", storage)...)
+		errs = append(errs, checkSharedFiles(owners, prefix+".storage", storage)...)
+	}
+
+	if !c.HasList(c.CurrentList) {
+		errs = append(errs, ValidationError{Key: "current_list", Value: c.CurrentList, Reason: "no such list"})
+	}
+
+	if c.Encryption.Enabled && c.Encryption.KeyFile != "" {
+		if _, err := os.Stat(c.Encryption.KeyFile); err != nil {
+			errs = append(errs, ValidationError{Key: "encryption.key_file", Value: c.Encryption.KeyFile, Reason: "key file is not readable"})
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, ValidationError{Key: "server.addr", Value: c.Server.Addr, Reason: "must be host:port"})
+	}
+
+	if c.Streak.MinTasks < 1 {
+		errs = append(errs, ValidationError{Key: "streak.min_tasks", Value: c.Streak.MinTasks, Reason: "must be at least 1"})
+	}
+	bonusDays := make([]string, 0, len(c.Streak.Bonuses))
+	for days := range c.Streak.Bonuses {
+		bonusDays = append(bonusDays, days)
+	}
+	sort.Strings(bonusDays)
+	for _, days := range bonusDays {
+		points := c.Streak.Bonuses[days]
+		if n, err := strconv.Atoi(days); err != nil || n < 1 {
+			errs = append(errs, ValidationError{Key: "streak.bonuses." + days, Reason: "must be a number of days"})
+		} else if points < 0 {
+			errs = append(errs, ValidationError{Key: "streak.bonuses." + days, Value: points, Reason: "must not be negative"})
+		}
+	}
+
+	if c.Penalties.Overdue < 0 {
+		errs = append(errs, ValidationError{Key: "penalties.overdue", Value: c.Penalties.Overdue, Reason: "must not be negative"})
+	}
+	if c.Penalties.Delete < 0 {
+		errs = append(errs, ValidationError{Key: "penalties.delete", Value: c.Penalties.Delete, Reason: "must not be negative"})
+	}
+	if c.Approval.TransfersAbove < 0 {
+		errs = append(errs, ValidationError{Key: "approval.transfers_above", Value: c.Approval.TransfersAbove, Reason: "must not be negative"})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateDefaults(prefix string, taskPoints, rewardPrice int) ValidationErrors {
+	var errs ValidationErrors
+	if taskPoints < 0 {
+		errs = append(errs, ValidationError{Key: prefix + ".task_points", Value: taskPoints, Reason: "must not be negative"})
+	}
+	if rewardPrice < 0 {
+		errs = append(errs, ValidationError{Key: prefix + ".reward_price", Value: rewardPrice, Reason: "must not be negative"})
+	}
+	return errs
+}
+
+func validateStorage(prefix string, storage StorageConfig) ValidationErrors {
+	var errs ValidationErrors
+	files := []struct {
+		key  string
+		path string
+	}{
+		{prefix + ".todo_file", storage.TodoFile},
+		{prefix + ".reward_file", storage.RewardFile},
+	}
+
+	for _, file := range files {
+		if strings.TrimSpace(file.path) == "" {
+			errs = append(errs, ValidationError{Key: file.key, Reason: "must not be empty"})
+			continue
+		}
+		if err := checkWritableDir(filepath.Dir(file.path)); err != nil {
+			errs = append(errs, ValidationError{Key: file.key, Value: file.path, Reason: err.Error()})
+		}
+	}
+
+	if storage.TodoFile != "" && storage.TodoFile == storage.RewardFile {
+		errs = append(errs, ValidationError{Key: prefix, Value: storage.TodoFile, Reason: "todo and reward files must be different"})
+	}
+	return errs
+}
+
+// checkSharedFiles reports the files of storage which another list already
+// uses. owners maps the files seen so far to their keys.
+func checkSharedFiles(owners map[string]string, prefix string, storage StorageConfig) ValidationErrors {
+	var errs ValidationErrors
+	for _, file := range []struct{ key, path string }{
+		{prefix + ".todo_file", storage.TodoFile},
+		{prefix + ".reward_file", storage.RewardFile},
+	} {
+		if strings.TrimSpace(file.path) == "" {
+			continue
+		}
+		path := filepath.Clean(file.path)
+		if owner, ok := owners[path]; ok {
+			errs = append(errs, ValidationError{Key: file.key, Value: file.path, Reason: "is already used by " + owner})
+			continue
+		}
+		owners[path] = file.key
+	}
+	return errs
+}
+
+// checkWritableDir reports whether files can be created in dir. A directory
+// that does not exist yet is fine as long as it can be created, so the check
+// goes up to the nearest existing parent.
+func checkWritableDir(dir string) error {
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("%s does not exist", dir)
+		}
+		dir = parent
+	}
+
+	probe, err := os.CreateTemp(dir, ".todo-write-check-*")
+	if err != nil {
+		return fmt.Errorf("directory %s is not writable", dir)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
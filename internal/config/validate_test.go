@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfig_ReturnsValidationErrors(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("TODO_DEFAULTS_TASK_POINTS", "-1")
+	t.Setenv("TODO_STORAGE_REWARD_FILE", " ")
+	chdir(t, root)
+
+	cfg, err := LoadConfig()
+
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+	if cfg == nil {
+		t.Fatal("Expected config to be returned together with validation errors")
+	}
+
+	keys := map[string]bool{}
+	for _, validationErr := range validationErrs {
+		keys[validationErr.Key] = true
+	}
+	if !keys["defaults.task_points"] {
+		t.Error("Expected an error for the negative default points")
+	}
+	if !keys["storage.reward_file"] {
+		t.Error("Expected an error for the empty reward file")
+	}
+}
+
+func TestLoadConfig_DoesNotCreateConfigFile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	chdir(t, root)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+
+	if _, err := os.Stat(cfg.Paths.ConfigFile); !os.IsNotExist(err) {
+		t.Errorf("LoadConfig should not create %s", cfg.Paths.ConfigFile)
+	}
+	if cfg.Defaults.TaskPoints != 20 {
+		t.Errorf("Expected default task points 20, got %d", cfg.Defaults.TaskPoints)
+	}
+}
+
+func TestInitConfig_WritesTheDefaults(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	chdir(t, root)
+
+	paths, err := ResolvePaths()
+	if err != nil {
+		t.Fatalf("ResolvePaths returned error: %v", err)
+	}
+	if err := InitConfig(paths.ConfigFile); err != nil {
+		t.Fatalf("InitConfig returned error: %v", err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	f, err := OpenFile(cfg.Paths.ConfigFile)
+	if err != nil {
+		t.Fatalf("OpenFile returned error: %v", err)
+	}
+	for key := range defaultValues {
+		if _, ok := f.Get(key); !ok {
+			t.Errorf("Expected %s in the new config file", key)
+		}
+	}
+	if len(cfg.Approval.Approvers) != 0 || cfg.Defaults.TaskPoints != 20 {
+		t.Errorf("Expected the default values, got approvers %v and task points %d", cfg.Approval.Approvers, cfg.Defaults.TaskPoints)
+	}
+}
+
+func TestValidate_Streak(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("TODO_STREAK_MIN_TASKS", "0")
+	chdir(t, root)
+
+	cfg, err := LoadConfig()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 || validationErrs[0].Key != "streak.min_tasks" {
+		t.Fatalf("Expected an error for streak.min_tasks, got %v", err)
+	}
+
+	cfg.Streak.MinTasks = 2
+	cfg.Streak.Bonuses = map[string]int{"7": 50, "week": 10, "30": -1}
+	validationErrs = nil
+	if !errors.As(cfg.Validate(), &validationErrs) || len(validationErrs) != 2 {
+		t.Fatalf("Expected errors for the bonuses 'week' and 30, got %v", validationErrs)
+	}
+	if bonuses := cfg.StreakBonuses(); bonuses[7] != 50 || len(bonuses) != 2 {
+		t.Errorf("Expected numeric bonuses only, got %v", bonuses)
+	}
+}
+
+func TestValidate_Penalties(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	t.Setenv("TODO_PENALTIES_OVERDUE", "-5")
+	chdir(t, root)
+
+	cfg, err := LoadConfig()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 || validationErrs[0].Key != "penalties.overdue" {
+		t.Fatalf("Expected an error for penalties.overdue, got %v", err)
+	}
+	if cfg.Penalties.Delete != 0 || cfg.Penalties.AllowDebt {
+		t.Errorf("Expected the other penalties to be off by default, got %+v", cfg.Penalties)
+	}
+}
+
+func TestValidate_ListsSharingAFile(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "cfg"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(root, "data"))
+	chdir(t, root)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig returned error: %v", err)
+	}
+	if err := cfg.Set("lists.work.storage.todo_file", "todo.json"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	cfg, err = LoadConfig()
+	var validationErrs ValidationErrors
+	if !errors.As(err, &validationErrs) || len(validationErrs) != 1 || validationErrs[0].Key != "lists.work.storage.todo_file" {
+		t.Fatalf("Expected an error for the todo file shared with the default list, got %v", err)
+	}
+	if cfg == nil {
+		t.Fatal("Expected config to be returned together with validation errors")
+	}
+}
@@ -0,0 +1,236 @@
+package formats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+var (
+	taskCSVHeader   = []string{"id", "text", "done", "points", "priority", "created", "completed", "due", "projects", "contexts"}
+	rewardCSVHeader = []string{"id", "description", "price", "available"}
+)
+
+func WriteTasksCSV(w io.Writer, tasks []models.Task) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(taskCSVHeader); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		record := []string{
+			strconv.Itoa(task.ID),
+			task.Text,
+			strconv.FormatBool(task.IsComplete),
+			strconv.Itoa(task.TaskPoints),
+			task.Priority,
+			formatCSVTime(task.CreatedAt),
+			formatCSVTime(task.CompletedAt),
+			formatCSVTime(task.Due),
+			strings.Join(task.Projects, " "),
+			strings.Join(task.Contexts, " "),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ParseTasksCSV reads tasks by column names, so the columns may come in any
+// order and all of them except text are optional.
+func ParseTasksCSV(r io.Reader, defaultPoints int) ([]models.Task, error) {
+	rows, err := readCSV(r, "text")
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.Task, 0, len(rows))
+	for _, row := range rows {
+		task := models.Task{
+			Text:       row.get("text"),
+			TaskPoints: defaultPoints,
+			Priority:   strings.ToUpper(row.get("priority")),
+			Projects:   strings.Fields(row.get("projects")),
+			Contexts:   strings.Fields(row.get("contexts")),
+		}
+		if task.Text == "" {
+			return nil, fmt.Errorf("line %d: task has no text", row.line)
+		}
+
+		if task.IsComplete, err = row.getBool("done"); err != nil {
+			return nil, err
+		}
+		if value := row.get("points"); value != "" {
+			if task.TaskPoints, err = parseCSVPoints(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.line, err)
+			}
+		}
+		if task.CreatedAt, err = row.getTime("created"); err != nil {
+			return nil, err
+		}
+		if task.CompletedAt, err = row.getTime("completed"); err != nil {
+			return nil, err
+		}
+		if task.Due, err = row.getTime("due"); err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func WriteRewardsCSV(w io.Writer, rewards []models.Reward) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(rewardCSVHeader); err != nil {
+		return err
+	}
+
+	for _, reward := range rewards {
+		record := []string{
+			strconv.Itoa(reward.ID),
+			reward.Description,
+			strconv.Itoa(reward.PriceOfReward),
+			strconv.FormatBool(reward.IsAvailable),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func ParseRewardsCSV(r io.Reader, defaultPrice int) ([]models.Reward, error) {
+	rows, err := readCSV(r, "description")
+	if err != nil {
+		return nil, err
+	}
+
+	rewards := make([]models.Reward, 0, len(rows))
+	for _, row := range rows {
+		reward := models.Reward{
+			Description:   row.get("description"),
+			PriceOfReward: defaultPrice,
+		}
+		if reward.Description == "" {
+			return nil, fmt.Errorf("line %d: reward has no description", row.line)
+		}
+		if value := row.get("price"); value != "" {
+			if reward.PriceOfReward, err = parseCSVPoints(value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.line, err)
+			}
+		}
+
+		rewards = append(rewards, reward)
+	}
+	return rewards, nil
+}
+
+type csvRow struct {
+	line    int
+	columns map[string]int
+	record  []string
+}
+
+func (r csvRow) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r csvRow) getBool(column string) (bool, error) {
+	value := r.get(column)
+	if value == "" {
+		return false, nil
+	}
+	switch strings.ToLower(value) {
+	case "x", "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("line %d: incorrect %s value %q", r.line, column, value)
+	}
+	return b, nil
+}
+
+func (r csvRow) getTime(column string) (*time.Time, error) {
+	value := r.get(column)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t, err = time.ParseInLocation(todoTxtDate, value, time.Local)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("line %d: incorrect %s date %q", r.line, column, value)
+	}
+	return &t, nil
+}
+
+// readCSV reads the header and the records after it. The required column
+// must be present in the header.
+func readCSV(r io.Reader, required string) ([]csvRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns[required]; !ok {
+		return nil, fmt.Errorf("CSV header has no %q column", required)
+	}
+
+	var rows []csvRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, csvRow{line: line, columns: columns, record: record})
+	}
+	return rows, nil
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseCSVPoints(value string) (int, error) {
+	points, err := strconv.Atoi(value)
+	if err != nil || points < 0 {
+		return 0, fmt.Errorf("incorrect count of points %q", value)
+	}
+	return points, nil
+}
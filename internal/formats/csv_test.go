@@ -0,0 +1,71 @@
+package formats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTasksCSV_RoundTrip(t *testing.T) {
+	due := time.Date(2026, 10, 20, 18, 30, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 1, Text: "Report, part \"one\"", TaskPoints: 30, Priority: "A", Due: &due, Projects: []string{"work"}},
+		{ID: 2, Text: "Buy milk", TaskPoints: 5, IsComplete: true},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTasksCSV(&buf, tasks); err != nil {
+		t.Fatalf("WriteTasksCSV returned error: %v", err)
+	}
+
+	parsed, err := ParseTasksCSV(&buf, 20)
+	if err != nil {
+		t.Fatalf("ParseTasksCSV returned error: %v", err)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(parsed))
+	}
+	if parsed[0].Text != tasks[0].Text || parsed[0].TaskPoints != 30 || parsed[0].Priority != "A" {
+		t.Errorf("First task changed: %+v", parsed[0])
+	}
+	if parsed[0].Due == nil || !parsed[0].Due.Equal(due) {
+		t.Errorf("Expected due %v, got %v", due, parsed[0].Due)
+	}
+	if !parsed[1].IsComplete || parsed[1].TaskPoints != 5 {
+		t.Errorf("Second task changed: %+v", parsed[1])
+	}
+}
+
+func TestParseTasksCSV_ColumnsByName(t *testing.T) {
+	input := "Points,Text\n,Water plants\n3,Sweep\n"
+
+	tasks, err := ParseTasksCSV(strings.NewReader(input), 20)
+	if err != nil {
+		t.Fatalf("ParseTasksCSV returned error: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].TaskPoints != 20 || tasks[1].TaskPoints != 3 {
+		t.Errorf("Unexpected tasks: %+v", tasks)
+	}
+
+	if _, err := ParseTasksCSV(strings.NewReader("name\nx\n"), 20); err == nil {
+		t.Error("Expected error for a header without text column")
+	}
+}
+
+func TestRewardsCSV_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteRewardsCSV(&buf, []models.Reward{{ID: 1, Description: "Cinema", PriceOfReward: 50}}); err != nil {
+		t.Fatalf("WriteRewardsCSV returned error: %v", err)
+	}
+
+	rewards, err := ParseRewardsCSV(&buf, 100)
+	if err != nil {
+		t.Fatalf("ParseRewardsCSV returned error: %v", err)
+	}
+	if len(rewards) != 1 || rewards[0].Description != "Cinema" || rewards[0].PriceOfReward != 50 {
+		t.Errorf("Unexpected rewards: %+v", rewards)
+	}
+}
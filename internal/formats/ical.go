@@ -0,0 +1,329 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// iCalendar (RFC 5545) tasks are VTODO components. Points have no standard
+// property and go to X-TODO-POINTS; contexts share CATEGORIES with projects
+// and keep their "@" there.
+const (
+	icalDate     = "20060102"
+	icalDateTime = "20060102T150405"
+	icalUTC      = "20060102T150405Z"
+	icalPoints   = "X-TODO-POINTS"
+	icalLineLen  = 75
+)
+
+func WriteTasksICal(w io.Writer, tasks []models.Task) error {
+	iw := &icalWriter{w: bufio.NewWriter(w)}
+	stamp := time.Now().UTC().Format(icalUTC)
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", "-//svetsed//todo_cli_app//EN")
+	for _, task := range tasks {
+		iw.line("BEGIN", "VTODO")
+		iw.line("UID", icalUID(task))
+		iw.line("DTSTAMP", stamp)
+		iw.line("SUMMARY", escapeICalText(task.Text))
+		if task.CreatedAt != nil {
+			iw.line("CREATED", task.CreatedAt.UTC().Format(icalUTC))
+		}
+
+		if task.IsComplete {
+			iw.line("STATUS", "COMPLETED")
+			if task.CompletedAt != nil {
+				iw.line("COMPLETED", task.CompletedAt.UTC().Format(icalUTC))
+			}
+		} else {
+			iw.line("STATUS", "NEEDS-ACTION")
+		}
+
+		if task.Due != nil {
+			if isMidnight(*task.Due) {
+				iw.line("DUE;VALUE=DATE", task.Due.Format(icalDate))
+			} else {
+				iw.line("DUE", task.Due.UTC().Format(icalUTC))
+			}
+		}
+		if task.Priority != "" {
+			iw.line("PRIORITY", strconv.Itoa(icalPriority(task.Priority)))
+		}
+
+		var categories []string
+		for _, project := range task.Projects {
+			categories = append(categories, escapeICalText(project))
+		}
+		for _, context := range task.Contexts {
+			categories = append(categories, "@"+escapeICalText(context))
+		}
+		if len(categories) > 0 {
+			iw.line("CATEGORIES", strings.Join(categories, ","))
+		}
+
+		iw.line(icalPoints, strconv.Itoa(task.TaskPoints))
+		iw.line("END", "VTODO")
+	}
+	iw.line("END", "VCALENDAR")
+
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// ParseTasksICal reads the VTODO components and skips everything else, so a
+// whole calendar export can be imported.
+func ParseTasksICal(r io.Reader, defaultPoints int) ([]models.Task, error) {
+	lines, err := unfoldICal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		tasks []models.Task
+		task  *models.Task
+		depth int
+	)
+	for i, line := range lines {
+		name, params, value, err := splitICalLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VTODO") && task == nil:
+			task = &models.Task{TaskPoints: defaultPoints}
+			continue
+		case name == "END" && strings.EqualFold(value, "VTODO") && task != nil:
+			if task.Text == "" {
+				return nil, fmt.Errorf("line %d: VTODO has no SUMMARY", i+1)
+			}
+			tasks = append(tasks, *task)
+			task = nil
+			continue
+		case task == nil:
+			continue
+		// Nested components like VALARM have their own properties.
+		case name == "BEGIN":
+			depth++
+			continue
+		case name == "END":
+			depth--
+			continue
+		case depth > 0:
+			continue
+		}
+
+		if err := applyICalProperty(task, name, params, value); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	if task != nil {
+		return nil, fmt.Errorf("VTODO is not closed")
+	}
+	return tasks, nil
+}
+
+func applyICalProperty(task *models.Task, name string, params map[string]string, value string) error {
+	switch name {
+	case "UID":
+		task.UID = value
+	case "SUMMARY":
+		task.Text = strings.Join(strings.Fields(unescapeICalText(value)), " ")
+	case "STATUS":
+		task.IsComplete = strings.EqualFold(value, "COMPLETED")
+	case "CREATED", "COMPLETED", "DUE":
+		t, err := parseICalTime(value, params)
+		if err != nil {
+			return fmt.Errorf("incorrect %s: %w", name, err)
+		}
+		switch name {
+		case "CREATED":
+			task.CreatedAt = &t
+		case "COMPLETED":
+			task.CompletedAt = &t
+			task.IsComplete = true
+		case "DUE":
+			task.Due = &t
+		}
+	case "PRIORITY":
+		priority, err := strconv.Atoi(value)
+		if err != nil || priority < 0 || priority > 9 {
+			return fmt.Errorf("incorrect PRIORITY %q", value)
+		}
+		if priority > 0 {
+			task.Priority = string(rune('A' + priority - 1))
+		}
+	case "CATEGORIES":
+		for _, category := range splitICalList(value) {
+			category = strings.TrimSpace(unescapeICalText(category))
+			switch {
+			case len(category) > 1 && category[0] == '@':
+				task.Contexts = appendUnique(task.Contexts, category[1:])
+			case category != "":
+				task.Projects = appendUnique(task.Projects, strings.TrimPrefix(category, "+"))
+			}
+		}
+	case icalPoints:
+		points, err := strconv.Atoi(value)
+		if err != nil || points < 0 {
+			return fmt.Errorf("incorrect %s %q", icalPoints, value)
+		}
+		task.TaskPoints = points
+	}
+	return nil
+}
+
+// icalUID keeps the UID a task got from a calendar, other tasks get one
+// made of their ID and creation time.
+func icalUID(task models.Task) string {
+	if task.UID != "" {
+		return task.UID
+	}
+	created := int64(0)
+	if task.CreatedAt != nil {
+		created = task.CreatedAt.Unix()
+	}
+	return fmt.Sprintf("%d-%d@todo_cli_app", task.ID, created)
+}
+
+// icalPriority maps A..I to 1..9, the highest priority being 1. Later
+// letters get the lowest priority.
+func icalPriority(priority string) int {
+	p := int(priority[0]-'A') + 1
+	if p > 9 {
+		p = 9
+	}
+	return p
+}
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+func parseICalTime(value string, params map[string]string) (time.Time, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(icalDate) {
+		return time.ParseInLocation(icalDate, value, time.Local)
+	}
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icalUTC, value)
+	}
+
+	location := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			location = l
+		}
+	}
+	return time.ParseInLocation(icalDateTime, value, location)
+}
+
+// unfoldICal joins continuation lines, which start with a space or a tab.
+func unfoldICal(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// splitICalLine splits "NAME;PARAM=VALUE:value". Colons inside quoted
+// parameter values do not end the parameters.
+func splitICalLine(line string) (string, map[string]string, string, error) {
+	quoted := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon == -1 {
+		return "", nil, "", fmt.Errorf("no value in %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := map[string]string{}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], nil
+}
+
+func splitICalList(value string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, value[start:])
+}
+
+var (
+	icalEscaper   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	icalUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+func escapeICalText(text string) string {
+	return icalEscaper.Replace(text)
+}
+
+func unescapeICalText(text string) string {
+	return icalUnescaper.Replace(text)
+}
+
+// icalWriter writes content lines with CRLF and folds them at 75 octets
+// without breaking UTF-8 sequences.
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (iw *icalWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+
+	line := name + ":" + value
+	limit := icalLineLen
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, iw.err = iw.w.WriteString(line[:cut] + "\r\n "); iw.err != nil {
+			return
+		}
+		line = line[cut:]
+		// The leading space of a continuation line counts too.
+		limit = icalLineLen - 1
+	}
+	_, iw.err = iw.w.WriteString(line + "\r\n")
+}
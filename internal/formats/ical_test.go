@@ -0,0 +1,83 @@
+package formats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTasksICal_RoundTrip(t *testing.T) {
+	due := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2026, 10, 18, 9, 15, 0, 0, time.UTC)
+	tasks := []models.Task{
+		{ID: 1, Text: strings.Repeat("long, text; ", 10) + "ünïcødé", TaskPoints: 30, Priority: "B", Due: &due, Projects: []string{"work"}, Contexts: []string{"office"}},
+		{ID: 2, Text: "Buy milk", TaskPoints: 5, IsComplete: true, CompletedAt: &completed, UID: "abc@example.com"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTasksICal(&buf, tasks); err != nil {
+		t.Fatalf("WriteTasksICal returned error: %v", err)
+	}
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		if len(line) > icalLineLen {
+			t.Errorf("Line is not folded: %q", line)
+		}
+	}
+
+	parsed, err := ParseTasksICal(&buf, 20)
+	if err != nil {
+		t.Fatalf("ParseTasksICal returned error: %v", err)
+	}
+	if len(parsed) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(parsed))
+	}
+
+	first := parsed[0]
+	if first.Text != strings.TrimSpace(tasks[0].Text) || first.TaskPoints != 30 || first.Priority != "B" {
+		t.Errorf("First task changed: %+v", first)
+	}
+	if first.Due == nil || !first.Due.Equal(due) {
+		t.Errorf("Expected due %v, got %v", due, first.Due)
+	}
+	if !reflect.DeepEqual(first.Projects, []string{"work"}) || !reflect.DeepEqual(first.Contexts, []string{"office"}) {
+		t.Errorf("Unexpected categories: %v %v", first.Projects, first.Contexts)
+	}
+
+	second := parsed[1]
+	if !second.IsComplete || second.CompletedAt == nil || !second.CompletedAt.Equal(completed) || second.UID != "abc@example.com" {
+		t.Errorf("Second task changed: %+v", second)
+	}
+}
+
+func TestParseTasksICal_SkipsOtherComponents(t *testing.T) {
+	input := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Meeting",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"SUMMARY;LANGUAGE=en:Pay",
+		"  bills",
+		"DUE;TZID=\"Europe/Berlin\":20261020T120000",
+		"BEGIN:VALARM",
+		"SUMMARY:Reminder",
+		"END:VALARM",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	tasks, err := ParseTasksICal(strings.NewReader(input), 20)
+	if err != nil {
+		t.Fatalf("ParseTasksICal returned error: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Text != "Pay bills" || tasks[0].TaskPoints != 20 {
+		t.Fatalf("Expected only the VTODO with default points, got %+v", tasks)
+	}
+	if tasks[0].Due == nil || tasks[0].Due.UTC().Hour() != 10 {
+		t.Errorf("Expected due at 10:00 UTC, got %v", tasks[0].Due)
+	}
+}
@@ -0,0 +1,87 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// Checklist items look like "- [x] text (20 points)". For rewards the mark
+// shows whether the reward can be bought now. Other lines are ignored, so a
+// checklist can be imported from any note.
+var (
+	markdownItem   = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	markdownPoints = regexp.MustCompile(`^(.*?)\s*\((\d+) points?\)$`)
+)
+
+func WriteTasksMarkdown(w io.Writer, tasks []models.Task) error {
+	for _, task := range tasks {
+		if _, err := fmt.Fprintln(w, formatMarkdownItem(task.IsComplete, task.Text, task.TaskPoints)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ParseTasksMarkdown(r io.Reader, defaultPoints int) ([]models.Task, error) {
+	var tasks []models.Task
+	err := scanMarkdownItems(r, defaultPoints, func(checked bool, text string, points int) {
+		tasks = append(tasks, models.Task{Text: text, IsComplete: checked, TaskPoints: points})
+	})
+	return tasks, err
+}
+
+func WriteRewardsMarkdown(w io.Writer, rewards []models.Reward) error {
+	for _, reward := range rewards {
+		if _, err := fmt.Fprintln(w, formatMarkdownItem(reward.IsAvailable, reward.Description, reward.PriceOfReward)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseRewardsMarkdown ignores the marks: availability depends on the
+// balance of the list the rewards are imported to.
+func ParseRewardsMarkdown(r io.Reader, defaultPrice int) ([]models.Reward, error) {
+	var rewards []models.Reward
+	err := scanMarkdownItems(r, defaultPrice, func(_ bool, text string, price int) {
+		rewards = append(rewards, models.Reward{Description: text, PriceOfReward: price})
+	})
+	return rewards, err
+}
+
+func formatMarkdownItem(checked bool, text string, points int) string {
+	mark := " "
+	if checked {
+		mark = "x"
+	}
+	return fmt.Sprintf("- [%s] %s (%d points)", mark, text, points)
+}
+
+func scanMarkdownItems(r io.Reader, defaultPoints int, item func(checked bool, text string, points int)) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		match := markdownItem.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		text, points := strings.TrimSpace(match[2]), defaultPoints
+		if pointsMatch := markdownPoints.FindStringSubmatch(text); pointsMatch != nil {
+			if n, err := strconv.Atoi(pointsMatch[2]); err == nil {
+				text, points = pointsMatch[1], n
+			}
+		}
+		if text == "" {
+			continue
+		}
+
+		item(match[1] != " ", text, points)
+	}
+	return scanner.Err()
+}
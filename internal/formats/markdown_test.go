@@ -0,0 +1,52 @@
+package formats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTasksMarkdown_RoundTrip(t *testing.T) {
+	tasks := []models.Task{
+		{Text: "Read (chapter 2)", TaskPoints: 10, IsComplete: true},
+		{Text: "Walk", TaskPoints: 1},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTasksMarkdown(&buf, tasks); err != nil {
+		t.Fatalf("WriteTasksMarkdown returned error: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "- [x] Read (chapter 2) (10 points)\n") {
+		t.Errorf("Unexpected markdown:\n%s", buf.String())
+	}
+
+	parsed, err := ParseTasksMarkdown(&buf, 20)
+	if err != nil {
+		t.Fatalf("ParseTasksMarkdown returned error: %v", err)
+	}
+	for i := range tasks {
+		if parsed[i].Text != tasks[i].Text || parsed[i].TaskPoints != tasks[i].TaskPoints || parsed[i].IsComplete != tasks[i].IsComplete {
+			t.Errorf("Task %d changed: %+v", i, parsed[i])
+		}
+	}
+}
+
+func TestParseRewardsMarkdown_SkipsOtherLines(t *testing.T) {
+	input := "# Rewards\n\nSome note\n* [ ] Cinema (50 points)\n- [X] Coffee\n"
+
+	rewards, err := ParseRewardsMarkdown(strings.NewReader(input), 100)
+	if err != nil {
+		t.Fatalf("ParseRewardsMarkdown returned error: %v", err)
+	}
+	if len(rewards) != 2 {
+		t.Fatalf("Expected 2 rewards, got %d", len(rewards))
+	}
+	if rewards[0].Description != "Cinema" || rewards[0].PriceOfReward != 50 {
+		t.Errorf("Unexpected first reward: %+v", rewards[0])
+	}
+	if rewards[1].Description != "Coffee" || rewards[1].PriceOfReward != 100 {
+		t.Errorf("Expected default price for the second reward, got %+v", rewards[1])
+	}
+}
@@ -0,0 +1,203 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// Taskwarrior (https://taskwarrior.org/docs/design/task/) keeps dates as
+// UTC timestamps and has H/M/L priorities, which map to A/B/C. The project
+// is the first one of the task and tags are its contexts. Points are written
+// as the "points" attribute, which Taskwarrior keeps as an orphaned UDA.
+const taskwarriorTime = "20060102T150405Z"
+
+type taskwarriorTask struct {
+	UUID        string                  `json:"uuid,omitempty"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"`
+	Entry       string                  `json:"entry,omitempty"`
+	End         string                  `json:"end,omitempty"`
+	Due         string                  `json:"due,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Priority    string                  `json:"priority,omitempty"`
+	Annotations []taskwarriorAnnotation `json:"annotations,omitempty"`
+	Points      json.RawMessage         `json:"points,omitempty"`
+}
+
+type taskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+var (
+	taskwarriorPriority = map[string]string{"A": "H", "B": "M", "C": "L"}
+	priorityFromTW      = map[string]string{"H": "A", "M": "B", "L": "C"}
+)
+
+func WriteTasksTaskwarrior(w io.Writer, tasks []models.Task) error {
+	exported := make([]taskwarriorTask, 0, len(tasks))
+	for _, task := range tasks {
+		tw := taskwarriorTask{
+			UUID:        task.UID,
+			Description: task.Text,
+			Status:      "pending",
+			Entry:       formatTaskwarriorTime(task.CreatedAt),
+			Due:         formatTaskwarriorTime(task.Due),
+			Tags:        task.Contexts,
+			Priority:    taskwarriorPriority[task.Priority],
+			Points:      json.RawMessage(strconv.Itoa(task.TaskPoints)),
+		}
+		if task.IsComplete {
+			tw.Status = "completed"
+			tw.End = formatTaskwarriorTime(task.CompletedAt)
+			if tw.End == "" {
+				tw.End = time.Now().UTC().Format(taskwarriorTime)
+			}
+		}
+		if len(task.Projects) > 0 {
+			tw.Project = task.Projects[0]
+		}
+		for _, annotation := range task.Annotations {
+			tw.Annotations = append(tw.Annotations, taskwarriorAnnotation{
+				Entry:       annotation.CreatedAt.UTC().Format(taskwarriorTime),
+				Description: annotation.Text,
+			})
+		}
+		exported = append(exported, tw)
+	}
+
+	data, err := json.MarshalIndent(exported, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// ParseTasksTaskwarrior reads the output of 'task export'. Deleted tasks are
+// skipped.
+func ParseTasksTaskwarrior(r io.Reader, defaultPoints int) ([]models.Task, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	// Without json.array Taskwarrior writes one object per line, older
+	// versions with a comma after each of them.
+	if data[0] != '[' {
+		var objects [][]byte
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			line = bytes.TrimRight(bytes.TrimSpace(line), ",")
+			if len(line) > 0 {
+				objects = append(objects, line)
+			}
+		}
+		data = append(append([]byte("["), bytes.Join(objects, []byte(","))...), ']')
+	}
+
+	var exported []taskwarriorTask
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("invalid Taskwarrior JSON: %w", err)
+	}
+
+	tasks := make([]models.Task, 0, len(exported))
+	for i, tw := range exported {
+		if tw.Status == "deleted" {
+			continue
+		}
+		task, err := tw.toTask(defaultPoints)
+		if err != nil {
+			return nil, fmt.Errorf("task %d: %w", i+1, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (tw taskwarriorTask) toTask(defaultPoints int) (models.Task, error) {
+	task := models.Task{
+		UID:        tw.UUID,
+		Text:       strings.Join(strings.Fields(tw.Description), " "),
+		IsComplete: tw.Status == "completed",
+		TaskPoints: defaultPoints,
+		Priority:   priorityFromTW[tw.Priority],
+		Contexts:   tw.Tags,
+	}
+	if task.Text == "" {
+		return models.Task{}, fmt.Errorf("task has no description")
+	}
+	if tw.Project != "" {
+		task.Projects = []string{tw.Project}
+	}
+
+	var err error
+	if task.CreatedAt, err = parseTaskwarriorTime(tw.Entry); err != nil {
+		return models.Task{}, err
+	}
+	if task.Due, err = parseTaskwarriorTime(tw.Due); err != nil {
+		return models.Task{}, err
+	}
+	if task.IsComplete {
+		if task.CompletedAt, err = parseTaskwarriorTime(tw.End); err != nil {
+			return models.Task{}, err
+		}
+	}
+
+	for _, annotation := range tw.Annotations {
+		entry, err := parseTaskwarriorTime(annotation.Entry)
+		if err != nil {
+			return models.Task{}, err
+		}
+		a := models.Annotation{Text: annotation.Description}
+		if entry != nil {
+			a.CreatedAt = *entry
+		}
+		task.Annotations = append(task.Annotations, a)
+	}
+
+	// UDAs come as numbers or as strings depending on their definition.
+	if len(tw.Points) > 0 {
+		value := strings.Trim(string(tw.Points), `"`)
+		points, err := strconv.Atoi(value)
+		if err != nil || points < 0 {
+			return models.Task{}, fmt.Errorf("incorrect points %s", tw.Points)
+		}
+		task.TaskPoints = points
+	}
+
+	return task, nil
+}
+
+func formatTaskwarriorTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(taskwarriorTime)
+}
+
+func parseTaskwarriorTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(taskwarriorTime, value)
+	if err != nil {
+		// Some versions and hooks write ISO 8601 with separators.
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, fmt.Errorf("incorrect date %q", value)
+		}
+	}
+	t = t.Local()
+	return &t, nil
+}
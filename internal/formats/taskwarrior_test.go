@@ -0,0 +1,68 @@
+package formats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const taskwarriorExport = `[
+{"id":1,"description":"Fix the sink","entry":"20261001T080000Z","modified":"20261002T080000Z","status":"pending","uuid":"5b2d7ad6-7a3e-4e0b-9a0c-2c1c6f1f5e11","project":"home","tags":["plumbing","weekend"],"due":"20261020T000000Z","priority":"H","annotations":[{"entry":"20261002T080000Z","description":"Call the landlord first"}],"urgency":9.3},
+{"id":0,"description":"Old task","entry":"20260901T080000Z","end":"20260905T080000Z","status":"completed","uuid":"0f8a8f5e-2f8e-4d6c-bb8f-0f0f0f0f0f0f","points":"15"},
+{"id":0,"description":"Gone","status":"deleted","uuid":"11111111-2f8e-4d6c-bb8f-0f0f0f0f0f0f"}
+]`
+
+func TestParseTasksTaskwarrior(t *testing.T) {
+	tasks, err := ParseTasksTaskwarrior(strings.NewReader(taskwarriorExport), 20)
+	if err != nil {
+		t.Fatalf("ParseTasksTaskwarrior returned error: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected deleted task to be skipped, got %d tasks", len(tasks))
+	}
+
+	sink := tasks[0]
+	if sink.Text != "Fix the sink" || sink.UID != "5b2d7ad6-7a3e-4e0b-9a0c-2c1c6f1f5e11" || sink.Priority != "A" || sink.TaskPoints != 20 {
+		t.Errorf("Unexpected first task: %+v", sink)
+	}
+	if !reflect.DeepEqual(sink.Projects, []string{"home"}) || !reflect.DeepEqual(sink.Contexts, []string{"plumbing", "weekend"}) {
+		t.Errorf("Unexpected project and tags: %v %v", sink.Projects, sink.Contexts)
+	}
+	if sink.Due == nil || len(sink.Annotations) != 1 || sink.Annotations[0].Text != "Call the landlord first" {
+		t.Errorf("Expected due date and annotation, got %+v", sink)
+	}
+
+	old := tasks[1]
+	if !old.IsComplete || old.CompletedAt == nil || old.TaskPoints != 15 {
+		t.Errorf("Unexpected second task: %+v", old)
+	}
+}
+
+func TestTasksTaskwarrior_RoundTripAndLineFormat(t *testing.T) {
+	tasks, err := ParseTasksTaskwarrior(strings.NewReader(taskwarriorExport), 20)
+	if err != nil {
+		t.Fatalf("ParseTasksTaskwarrior returned error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTasksTaskwarrior(&buf, tasks); err != nil {
+		t.Fatalf("WriteTasksTaskwarrior returned error: %v", err)
+	}
+	parsed, err := ParseTasksTaskwarrior(&buf, 0)
+	if err != nil {
+		t.Fatalf("ParseTasksTaskwarrior returned error: %v", err)
+	}
+	if !reflect.DeepEqual(parsed, tasks) {
+		t.Errorf("Tasks changed after round trip:\n got  %+v\n want %+v", parsed, tasks)
+	}
+
+	lines := "{\"description\":\"One\",\"status\":\"pending\"},\n{\"description\":\"Two\",\"status\":\"waiting\"}\n"
+	parsed, err = ParseTasksTaskwarrior(strings.NewReader(lines), 20)
+	if err != nil {
+		t.Fatalf("ParseTasksTaskwarrior returned error for one object per line: %v", err)
+	}
+	if len(parsed) != 2 || parsed[1].Text != "Two" || parsed[1].IsComplete {
+		t.Errorf("Unexpected tasks: %+v", parsed)
+	}
+}
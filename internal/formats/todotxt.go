@@ -0,0 +1,223 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// Format of a todo.txt line (https://github.com/todotxt/todo.txt):
+//
+//	x 2026-10-18 2026-10-01 (A)? text +project @context key:value
+//
+// Priority of a completed task has no place in the format, so it is kept in
+// the pri: extension. Points are kept in pts: and the deadline in due:.
+const todoTxtDate = "2006-01-02"
+
+var (
+	todoTxtPriority  = regexp.MustCompile(`^\([A-Z]\)$`)
+	todoTxtExtension = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_-]*):([^\s:]+)$`)
+)
+
+func WriteTodoTxt(w io.Writer, tasks []models.Task) error {
+	for _, task := range tasks {
+		if _, err := fmt.Fprintln(w, FormatTodoTxtLine(task)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func FormatTodoTxtLine(task models.Task) string {
+	var parts []string
+
+	if task.IsComplete {
+		parts = append(parts, "x")
+		// The creation date is only recognised after a completion date.
+		if task.CompletedAt != nil {
+			parts = append(parts, task.CompletedAt.Format(todoTxtDate))
+			if task.CreatedAt != nil {
+				parts = append(parts, task.CreatedAt.Format(todoTxtDate))
+			}
+		}
+	} else {
+		if task.Priority != "" {
+			parts = append(parts, "("+task.Priority+")")
+		}
+		if task.CreatedAt != nil {
+			parts = append(parts, task.CreatedAt.Format(todoTxtDate))
+		}
+	}
+
+	parts = append(parts, task.Text)
+
+	// Tags are usually part of the text already, only missing ones are added.
+	inText := map[string]bool{}
+	for _, word := range strings.Fields(task.Text) {
+		inText[word] = true
+	}
+	for _, project := range task.Projects {
+		if !inText["+"+project] {
+			parts = append(parts, "+"+project)
+		}
+	}
+	for _, context := range task.Contexts {
+		if !inText["@"+context] {
+			parts = append(parts, "@"+context)
+		}
+	}
+
+	if task.IsComplete && task.Priority != "" {
+		parts = append(parts, "pri:"+task.Priority)
+	}
+	if task.Due != nil {
+		parts = append(parts, "due:"+task.Due.Format(todoTxtDate))
+	}
+	parts = append(parts, "pts:"+strconv.Itoa(task.TaskPoints))
+
+	keys := make([]string, 0, len(task.Extensions))
+	for key := range task.Extensions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts = append(parts, key+":"+task.Extensions[key])
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseTodoTxt reads one task per non-empty line. Tasks without pts: get
+// defaultPoints.
+func ParseTodoTxt(r io.Reader, defaultPoints int) ([]models.Task, error) {
+	var tasks []models.Task
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		task, err := ParseTodoTxtLine(line, defaultPoints)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func ParseTodoTxtLine(line string, defaultPoints int) (models.Task, error) {
+	task := models.Task{TaskPoints: defaultPoints}
+	tokens := strings.Fields(line)
+
+	if len(tokens) > 0 && tokens[0] == "x" {
+		task.IsComplete = true
+		tokens = tokens[1:]
+		if date, ok := parseTodoTxtDate(tokens); ok {
+			task.CompletedAt = &date
+			tokens = tokens[1:]
+			if date, ok := parseTodoTxtDate(tokens); ok {
+				task.CreatedAt = &date
+				tokens = tokens[1:]
+			}
+		}
+	} else {
+		if len(tokens) > 0 && todoTxtPriority.MatchString(tokens[0]) {
+			task.Priority = tokens[0][1:2]
+			tokens = tokens[1:]
+		}
+		if date, ok := parseTodoTxtDate(tokens); ok {
+			task.CreatedAt = &date
+			tokens = tokens[1:]
+		}
+	}
+
+	var text []string
+	for _, token := range tokens {
+		if match := todoTxtExtension.FindStringSubmatch(token); match != nil {
+			if applyTodoTxtExtension(&task, match[1], match[2]) {
+				continue
+			}
+		}
+
+		switch {
+		case len(token) > 1 && token[0] == '+':
+			task.Projects = appendUnique(task.Projects, token[1:])
+		case len(token) > 1 && token[0] == '@':
+			task.Contexts = appendUnique(task.Contexts, token[1:])
+		}
+		text = append(text, token)
+	}
+
+	if len(text) == 0 {
+		return models.Task{}, fmt.Errorf("task has no text")
+	}
+	task.Text = strings.Join(text, " ")
+	return task, nil
+}
+
+// applyTodoTxtExtension stores key:value in the task and reports whether the
+// token belongs to the metadata rather than to the text.
+func applyTodoTxtExtension(task *models.Task, key, value string) bool {
+	switch key {
+	case "pts":
+		points, err := strconv.Atoi(value)
+		if err != nil || points < 0 {
+			return false
+		}
+		task.TaskPoints = points
+	case "due":
+		date, err := time.ParseInLocation(todoTxtDate, value, time.Local)
+		if err != nil {
+			return false
+		}
+		task.Due = &date
+	case "pri":
+		if len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+			return false
+		}
+		task.Priority = value
+	default:
+		// Anything that looks like a URL stays in the text.
+		if strings.HasPrefix(value, "//") {
+			return false
+		}
+		if task.Extensions == nil {
+			task.Extensions = map[string]string{}
+		}
+		task.Extensions[key] = value
+	}
+	return true
+}
+
+func parseTodoTxtDate(tokens []string) (time.Time, bool) {
+	if len(tokens) == 0 {
+		return time.Time{}, false
+	}
+	date, err := time.ParseInLocation(todoTxtDate, tokens[0], time.Local)
+	return date, err == nil
+}
+
+func appendUnique(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
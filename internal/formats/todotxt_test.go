@@ -0,0 +1,88 @@
+package formats
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestParseTodoTxtLine(t *testing.T) {
+	task, err := ParseTodoTxtLine("(B) 2026-10-01 Call mom +family @phone due:2026-10-20 pts:15 rec:1w", 20)
+	if err != nil {
+		t.Fatalf("ParseTodoTxtLine returned error: %v", err)
+	}
+
+	if task.Text != "Call mom +family @phone" {
+		t.Errorf("Expected text with tags, got %q", task.Text)
+	}
+	if task.Priority != "B" || task.TaskPoints != 15 {
+		t.Errorf("Expected priority B and 15 points, got %q and %d", task.Priority, task.TaskPoints)
+	}
+	if task.CreatedAt == nil || task.CreatedAt.Format(todoTxtDate) != "2026-10-01" {
+		t.Errorf("Expected creation date 2026-10-01, got %v", task.CreatedAt)
+	}
+	if task.Due == nil || task.Due.Format(todoTxtDate) != "2026-10-20" {
+		t.Errorf("Expected due date 2026-10-20, got %v", task.Due)
+	}
+	if !reflect.DeepEqual(task.Projects, []string{"family"}) || !reflect.DeepEqual(task.Contexts, []string{"phone"}) {
+		t.Errorf("Unexpected tags: %v %v", task.Projects, task.Contexts)
+	}
+	if task.Extensions["rec"] != "1w" {
+		t.Errorf("Expected unknown extension to be kept, got %v", task.Extensions)
+	}
+}
+
+func TestParseTodoTxtLine_CompletedTaskAndDefaults(t *testing.T) {
+	task, err := ParseTodoTxtLine("x 2026-10-18 2026-10-01 Read https://example.com at 10:30", 20)
+	if err != nil {
+		t.Fatalf("ParseTodoTxtLine returned error: %v", err)
+	}
+
+	if !task.IsComplete || task.CompletedAt == nil || task.CreatedAt == nil {
+		t.Errorf("Expected completed task with both dates, got %+v", task)
+	}
+	if task.Text != "Read https://example.com at 10:30" {
+		t.Errorf("Expected URL and time to stay in the text, got %q", task.Text)
+	}
+	if task.TaskPoints != 20 {
+		t.Errorf("Expected default points 20, got %d", task.TaskPoints)
+	}
+}
+
+func TestTodoTxt_RoundTrip(t *testing.T) {
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	completed := time.Date(2026, 10, 18, 0, 0, 0, 0, time.Local)
+	tasks := []models.Task{
+		{Text: "Write report +work", TaskPoints: 30, Priority: "A", CreatedAt: &created, Projects: []string{"work", "q4"}, Contexts: []string{"office"}},
+		{Text: "Buy milk", TaskPoints: 5, IsComplete: true, Priority: "C", CreatedAt: &created, CompletedAt: &completed, Due: &completed, Extensions: map[string]string{"store": "corner"}},
+		{Text: "Nothing special", TaskPoints: 0},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTodoTxt(&buf, tasks); err != nil {
+		t.Fatalf("WriteTodoTxt returned error: %v", err)
+	}
+
+	parsed, err := ParseTodoTxt(strings.NewReader(buf.String()), 20)
+	if err != nil {
+		t.Fatalf("ParseTodoTxt returned error: %v", err)
+	}
+	if len(parsed) != len(tasks) {
+		t.Fatalf("Expected %d tasks, got %d", len(tasks), len(parsed))
+	}
+
+	// Tags that were not in the text are appended to it on export.
+	tasks[0].Text = "Write report +work +q4 @office"
+	for i := range tasks {
+		if FormatTodoTxtLine(parsed[i]) != FormatTodoTxtLine(tasks[i]) {
+			t.Errorf("Task %d changed:\n got  %s\n want %s", i, FormatTodoTxtLine(parsed[i]), FormatTodoTxtLine(tasks[i]))
+		}
+		if parsed[i].Text != tasks[i].Text || parsed[i].TaskPoints != tasks[i].TaskPoints || parsed[i].Priority != tasks[i].Priority {
+			t.Errorf("Task %d changed: %+v", i, parsed[i])
+		}
+	}
+}
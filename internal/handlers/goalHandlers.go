@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+// rateDays is how many recent days the earning rate is measured over.
+const rateDays = 14
+
+// SetGoal makes the reward the goal. Points reserved for another goal move
+// to the new one as far as its price needs them.
+func (r *RewardHandler) SetGoal(indexGoalElem int) error {
+	reward := r.RSystem.Rewards[indexGoalElem]
+	goal := r.RSystem.Goal
+	if goal != nil && goal.RewardID == reward.ID {
+		return fmt.Errorf("reward %d is already the goal", reward.ID)
+	}
+
+	reserved := 0
+	if goal != nil {
+		reserved = goal.Reserved
+	}
+	r.RSystem.Goal = &models.Goal{RewardID: reward.ID, Reserved: reserved, SetAt: time.Now()}
+	r.reserve(0)
+	r.RSystem.IsUserPointsUpdate = true
+	return nil
+}
+
+// ReleaseGoal drops the goal, its reserved points can be spent again. It
+// returns the dropped goal.
+func (r *RewardHandler) ReleaseGoal() (models.Goal, error) {
+	if r.RSystem.Goal == nil {
+		return models.Goal{}, fmt.Errorf("no goal is set")
+	}
+	goal := *r.RSystem.Goal
+	r.RSystem.Goal = nil
+	r.RSystem.IsUserPointsUpdate = true
+	return goal, nil
+}
+
+// GoalReward returns the index of the reward of the goal, or -1 without a
+// goal.
+func (r *RewardHandler) GoalReward() int {
+	if r.RSystem.Goal == nil {
+		return -1
+	}
+	for i, reward := range r.RSystem.Rewards {
+		if reward.ID == r.RSystem.Goal.RewardID {
+			return i
+		}
+	}
+	return -1
+}
+
+// EarningRate returns the points earned per day over the recent days.
+func (r *RewardHandler) EarningRate(now time.Time) float64 {
+	since := now.AddDate(0, 0, -rateDays)
+	earned := 0
+	for _, entry := range r.RSystem.Ledger {
+		if entry.Points > 0 && entry.At.After(since) {
+			earned += entry.Points
+		}
+	}
+	return float64(earned) / rateDays
+}
+
+// ShowGoal writes the goal with a progress bar and the expected date it is
+// reached at the current earning rate.
+func (r *RewardHandler) ShowGoal(writer io.Writer) {
+	i := r.GoalReward()
+	if i == -1 {
+		fmt.Fprintln(writer, "No goal is set")
+		return
+	}
+	reward := r.RSystem.Rewards[i]
+	reserved := r.RSystem.Goal.Reserved
+
+	fmt.Fprintf(writer, "Goal: %d. %s  %s %d/%d\n", reward.ID, reward.Description,
+		progressBar(reserved, reward.PriceOfReward, 20), reserved, reward.PriceOfReward)
+
+	left := reward.PriceOfReward - reserved
+	rate := r.EarningRate(time.Now())
+	switch {
+	case left <= 0:
+		fmt.Fprintln(writer, "The goal is reached, buy the reward!")
+	case rate == 0:
+		fmt.Fprintf(writer, "%d points left, no points earned in the last %d days to estimate when\n", left, rateDays)
+	default:
+		days := int(math.Ceil(float64(left) / rate))
+		eta := time.Now().AddDate(0, 0, days).Format("2006-01-02")
+		fmt.Fprintf(writer, "%d points left, about %d days at %.1f points/day (by %s)\n", left, days, rate, eta)
+	}
+}
+
+// reserve reserves incoming points for the goal up to the price of its
+// reward and keeps the reserve within the balance.
+func (r *RewardHandler) reserve(countPoints int) {
+	goal := r.RSystem.Goal
+	if goal == nil {
+		return
+	}
+	if countPoints > 0 {
+		goal.Reserved += countPoints
+	}
+	if i := r.GoalReward(); i != -1 && goal.Reserved > r.RSystem.Rewards[i].PriceOfReward {
+		goal.Reserved = r.RSystem.Rewards[i].PriceOfReward
+	}
+	if goal.Reserved > r.RSystem.UserPoints {
+		goal.Reserved = max(r.RSystem.UserPoints, 0)
+	}
+}
+
+// progressBar draws done of total as a bar of the width.
+func progressBar(done, total, width int) string {
+	filled := width
+	if total > 0 && done < total {
+		filled = done * width / total
+	}
+	filled = max(filled, 0)
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// rewardProgress describes how far the spendable points are from the price
+// of the reward, for the list.
+func rewardProgress(rewardSystem *models.RewardSystem, reward models.Reward) string {
+	points := utils.SpendablePoints(rewardSystem, reward)
+	if goal := rewardSystem.Goal; goal != nil && goal.RewardID == reward.ID {
+		points = goal.Reserved
+	}
+	return fmt.Sprintf("%s %d%%", progressBar(points, reward.PriceOfReward, 10), percent(points, reward.PriceOfReward))
+}
+
+func percent(done, total int) int {
+	if total <= 0 || done >= total {
+		return 100
+	}
+	return max(done, 0) * 100 / total
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestRewardHandler_GoalReservesIncomingPoints(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			UserPoints: 40,
+			Rewards: []models.Reward{
+				{ID: 1, Description: "Coffee", PriceOfReward: 30},
+				{ID: 2, Description: "Bike", PriceOfReward: 100},
+			},
+		},
+	}
+
+	if err := r.SetGoal(1); err != nil {
+		t.Fatalf("SetGoal() returned an unexpected error: %v", err)
+	}
+	r.UpdateUserPoints(50, "")
+	if r.RSystem.Goal.Reserved != 50 {
+		t.Fatalf("Expected 50 incoming points to be reserved, got %d", r.RSystem.Goal.Reserved)
+	}
+
+	// 90 points, but only 40 of them are free.
+	if _, err := r.BuyRewards(0); err != nil {
+		t.Fatalf("Expected the free points to be enough for coffee, got %v", err)
+	}
+	_, err := r.BuyRewards(0)
+	if err == nil || !strings.Contains(err.Error(), "reserved for the goal") {
+		t.Fatalf("Expected reserved points not to be spendable, got %v", err)
+	}
+
+	r.UpdateUserPoints(70, "")
+	if r.RSystem.Goal.Reserved != 100 {
+		t.Errorf("Expected the reserve to stop at the price 100, got %d", r.RSystem.Goal.Reserved)
+	}
+	if _, err := r.BuyRewards(1); err != nil {
+		t.Fatalf("Expected the goal to be bought with the reserved points, got %v", err)
+	}
+	if r.RSystem.Goal != nil || r.RSystem.UserPoints != 30 {
+		t.Errorf("Expected the goal to be done and 30 points left, got %+v and %d", r.RSystem.Goal, r.RSystem.UserPoints)
+	}
+}
+
+func TestRewardHandler_ReleaseGoal(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			Rewards: []models.Reward{{ID: 1, Description: "Bike", PriceOfReward: 100}},
+		},
+	}
+
+	if _, err := r.ReleaseGoal(); err == nil {
+		t.Error("Expected error when no goal is set")
+	}
+	_ = r.SetGoal(0)
+	r.UpdateUserPoints(20, "")
+	goal, err := r.ReleaseGoal()
+	if err != nil || goal.Reserved != 20 || r.RSystem.Goal != nil {
+		t.Errorf("Expected the goal with 20 reserved points to be released, got %+v, %v", goal, err)
+	}
+}
+
+func TestRewardHandler_ShowGoal(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			UserPoints: 50,
+			Rewards:    []models.Reward{{ID: 3, Description: "Bike", PriceOfReward: 200}},
+			Goal:       &models.Goal{RewardID: 3, Reserved: 50},
+			Ledger: []models.LedgerEntry{
+				{At: time.Now().AddDate(0, 0, -2), Points: 70},
+				{At: time.Now().AddDate(0, 0, -1), Points: 70},
+				{At: time.Now().AddDate(0, 0, -1), Points: -30},
+				{At: time.Now().AddDate(0, 0, -30), Points: 500},
+			},
+		},
+	}
+
+	if rate := r.EarningRate(time.Now()); rate != 10 {
+		t.Fatalf("Expected 140 points over 14 days to be 10 points/day, got %v", rate)
+	}
+
+	var out bytes.Buffer
+	r.ShowGoal(&out)
+	for _, want := range []string{"3. Bike", "[#####---------------] 50/200", "150 points left, about 15 days"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// Penalize takes up to points from the balance and returns how many were
+// really taken.
+func (r *RewardHandler) Penalize(points int, note string) int {
+	if !r.Rules.AllowDebt {
+		points = min(points, max(r.RSystem.UserPoints, 0))
+	}
+	if points <= 0 {
+		return 0
+	}
+	r.UpdateUserPoints(-points, "penalty: "+note)
+	r.UpdateIsAvailableRewards()
+	return points
+}
+
+// PenalizeDeletion charges the penalty for deleting the task to its
+// assignee, unless it was completed. A task of an unknown user is charged to
+// nobody.
+func (r *RewardHandler) PenalizeDeletion(task models.Task) int {
+	if task.IsComplete || r.Rules.DeletePenalty == 0 {
+		return 0
+	}
+	taken := 0
+	err := r.As(task.Assignee, func() {
+		taken = r.Penalize(r.Rules.DeletePenalty, "deleted: "+task.Text)
+	})
+	if err != nil {
+		r.Notices = append(r.Notices, fmt.Sprintf("Task %d: no penalty was charged, %v", task.ID, err))
+	}
+	return taken
+}
+
+// PenalizeOverdue charges the penalty once for every task whose due day is
+// over and which was not completed by then. It returns how many tasks were charged and the points
+// taken, which are fewer when the balance ran out.
+func (h *TaskHandler) PenalizeOverdue(r *RewardHandler, now time.Time) (int, int) {
+	if r.Rules.OverduePenalty == 0 {
+		return 0, 0
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	tasks, points := 0, 0
+	for i := range h.Todo.Tasks {
+		task := &h.Todo.Tasks[i]
+		if task.Penalized || task.Due == nil || !task.Due.Before(today) {
+			continue
+		}
+		// A completed task pays only when it was completed after its due
+		// day, completing it late does not save it from the penalty.
+		dueEnd := time.Date(task.Due.Year(), task.Due.Month(), task.Due.Day()+1, 0, 0, 0, 0, now.Location())
+		if task.IsComplete && (task.CompletedAt == nil || task.CompletedAt.Before(dueEnd)) {
+			continue
+		}
+		taken := 0
+		err := r.As(task.Assignee, func() {
+			taken = r.Penalize(r.Rules.OverduePenalty, "overdue: "+task.Text)
+		})
+		if err != nil {
+			// The task is charged once its assignee is known.
+			r.Notices = append(r.Notices, fmt.Sprintf("Task %d: no penalty was charged, %v", task.ID, err))
+			continue
+		}
+		task.Penalized = true
+		tasks++
+		if taken > 0 {
+			r.Notices = append(r.Notices, fmt.Sprintf("Task %d is overdue: -%d points", task.ID, taken))
+		}
+		points += taken
+	}
+	return tasks, points
+}
+
+// ShowPoints writes the balance and the last entries of the ledger, all of
+// them when last is 0.
+func (r *RewardHandler) ShowPoints(writer io.Writer, last int) {
+	if r.RSystem.User != "" {
+		fmt.Fprintf(writer, "Balance of %s: %d points\n", r.RSystem.User, r.RSystem.UserPoints)
+	} else {
+		fmt.Fprintf(writer, "Balance: %d points\n", r.RSystem.UserPoints)
+	}
+	if goal := r.RSystem.Goal; goal != nil && goal.Reserved > 0 {
+		fmt.Fprintf(writer, "Reserved for the goal: %d points\n", goal.Reserved)
+	}
+	if held := r.HeldPoints(); held > 0 {
+		fmt.Fprintf(writer, "Held by pending requests: %d points\n", held)
+	}
+
+	entries := r.RSystem.Ledger
+	if len(entries) == 0 {
+		fmt.Fprintln(writer, "\nNo changes of the balance yet")
+		return
+	}
+	if last > 0 && len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+
+	fmt.Fprintln(writer)
+	tw := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Date\tPoints\tNote")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%+d\t%s\n", entry.At.Format("2006-01-02 15:04"), entry.Points, entry.Note)
+	}
+	tw.Flush()
+}
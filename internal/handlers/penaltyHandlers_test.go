@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTaskHandler_PenalizeOverdue(t *testing.T) {
+	now := time.Now()
+	yesterday, tomorrow := now.AddDate(0, 0, -1), now.AddDate(0, 0, 1)
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks: []models.Task{
+				{ID: 1, Text: "late", Due: &yesterday},
+				{ID: 2, Text: "late and done", Due: &yesterday, IsComplete: true},
+				{ID: 3, Text: "today", Due: &now},
+				{ID: 4, Text: "later", Due: &tomorrow},
+				{ID: 5, Text: "late too", Due: &yesterday},
+				{ID: 6, Text: "done too late", Due: &yesterday, IsComplete: true, CompletedAt: &now},
+			},
+		},
+	}
+	r := &RewardHandler{RSystem: &models.RewardSystem{UserPoints: 20}, Rules: Rules{OverduePenalty: 15}}
+
+	tasks, points := h.PenalizeOverdue(r, now)
+	if tasks != 3 || points != 20 {
+		t.Fatalf("Expected 3 overdue tasks and 20 points without debt, got %d and %d", tasks, points)
+	}
+	if r.RSystem.UserPoints != 0 {
+		t.Errorf("Expected the balance to stop at 0, got %d", r.RSystem.UserPoints)
+	}
+	if entry := r.RSystem.Ledger[0]; entry.Points != -15 || entry.Note != "penalty: overdue: late" {
+		t.Errorf("Expected the penalty in the ledger, got %+v", entry)
+	}
+
+	// Every task pays once.
+	if tasks, _ := h.PenalizeOverdue(r, now); tasks != 0 {
+		t.Errorf("Expected no task to be charged twice, got %d", tasks)
+	}
+}
+
+func TestRewardHandler_PenalizeDeletion(t *testing.T) {
+	r := &RewardHandler{RSystem: &models.RewardSystem{UserPoints: 5}, Rules: Rules{DeletePenalty: 10, AllowDebt: true}}
+	if taken := r.PenalizeDeletion(models.Task{Text: "done", IsComplete: true}); taken != 0 {
+		t.Errorf("Expected no penalty for a completed task, got %d", taken)
+	}
+	if taken := r.PenalizeDeletion(models.Task{Text: "dropped"}); taken != 10 || r.RSystem.UserPoints != -5 {
+		t.Errorf("Expected the debt of 5 points when it is allowed, got %d taken and %d left", taken, r.RSystem.UserPoints)
+	}
+}
+
+func TestRewardHandler_ShowPoints(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			UserPoints: 25,
+			Ledger: []models.LedgerEntry{
+				{At: time.Date(2025, time.March, 1, 10, 0, 0, 0, time.Local), Points: 40, Note: "completed: run"},
+				{At: time.Date(2025, time.March, 2, 10, 0, 0, 0, time.Local), Points: -15, Note: "penalty: overdue: taxes"},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	r.ShowPoints(&out, 1)
+	if !strings.Contains(out.String(), "Balance: 25 points") || !strings.Contains(out.String(), "-15     penalty: overdue: taxes") {
+		t.Errorf("Expected the balance and the penalty in the output:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "completed: run") {
+		t.Errorf("Expected only the last entry:\n%s", out.String())
+	}
+}
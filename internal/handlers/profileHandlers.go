@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// progress is what the achievement rules look at.
+type progress struct {
+	completed  int
+	bought     int
+	bestDay    int
+	bestStreak int
+	level      int
+}
+
+// achievementRule unlocks an achievement once done is true.
+type achievementRule struct {
+	id          string
+	title       string
+	description string
+	done        func(p progress) bool
+}
+
+var achievementRules = []achievementRule{
+	{"first-task", "First step", "complete a task", func(p progress) bool { return p.completed >= 1 }},
+	{"tasks-10", "Getting started", "complete 10 tasks", func(p progress) bool { return p.completed >= 10 }},
+	{"tasks-100", "Hundred", "complete 100 tasks", func(p progress) bool { return p.completed >= 100 }},
+	{"day-100", "100-point day", "earn 100 XP in one day", func(p progress) bool { return p.bestDay >= 100 }},
+	{"streak-7", "7-day streak", "complete tasks 7 days in a row", func(p progress) bool { return p.bestStreak >= 7 }},
+	{"streak-30", "30-day streak", "complete tasks 30 days in a row", func(p progress) bool { return p.bestStreak >= 30 }},
+	{"first-reward", "Treat yourself", "buy a reward", func(p progress) bool { return p.bought >= 1 }},
+	{"level-5", "Level 5", "reach level 5", func(p progress) bool { return p.level >= 5 }},
+}
+
+// levelAt returns the level for xp and the XP where this level and the next
+// one start. Level n starts at 50*n*(n-1) XP: 0, 100, 300, 600, 1000...
+func levelAt(xp int) (int, int, int) {
+	level := 1
+	for 50*(level+1)*level <= xp {
+		level++
+	}
+	return level, 50 * level * (level - 1), 50 * (level + 1) * level
+}
+
+func (r *RewardHandler) profile() *models.Profile {
+	if r.RSystem.Profile == nil {
+		r.RSystem.Profile = &models.Profile{}
+	}
+	if r.RSystem.Profile.XPByDay == nil {
+		r.RSystem.Profile.XPByDay = map[string]int{}
+	}
+	return r.RSystem.Profile
+}
+
+// gainXP adds xp earned on the day of at. Negative xp takes back what a task
+// gave when it is not completed any more, the XP never goes below zero.
+func (r *RewardHandler) gainXP(xp int, at time.Time) {
+	if xp == 0 {
+		return
+	}
+	profile := r.profile()
+	before, _, _ := levelAt(profile.XP)
+
+	profile.XP = max(profile.XP+xp, 0)
+	day := at.Format(dayLayout)
+	profile.XPByDay[day] += xp
+	if profile.XPByDay[day] <= 0 {
+		delete(profile.XPByDay, day)
+	}
+
+	if level, _, _ := levelAt(profile.XP); level > before {
+		r.Notices = append(r.Notices, fmt.Sprintf("Level up! You are level %d now", level))
+	}
+}
+
+// progress collects the counters for the achievement rules.
+func (r *RewardHandler) progress(now time.Time) progress {
+	var p progress
+	if r.RSystem.Streak != nil {
+		for _, count := range r.RSystem.Streak.Days {
+			p.completed += count
+		}
+	}
+	if profile := r.RSystem.Profile; profile != nil {
+		p.bought = profile.Bought
+		for _, xp := range profile.XPByDay {
+			p.bestDay = max(p.bestDay, xp)
+		}
+		p.level, _, _ = levelAt(profile.XP)
+	}
+	_, p.bestStreak = r.StreakStats(now)
+	return p
+}
+
+// checkAchievements unlocks the achievements whose rules are done now. It
+// runs after a task is completed and after a reward is bought.
+func (r *RewardHandler) checkAchievements(now time.Time) {
+	p := r.progress(now)
+	profile := r.profile()
+	for _, rule := range achievementRules {
+		if r.unlocked(rule.id) != nil || !rule.done(p) {
+			continue
+		}
+		profile.Achievements = append(profile.Achievements, models.Achievement{ID: rule.id, At: now})
+		r.Notices = append(r.Notices, fmt.Sprintf("Achievement unlocked: %s", rule.title))
+	}
+}
+
+func (r *RewardHandler) unlocked(id string) *models.Achievement {
+	if r.RSystem.Profile == nil {
+		return nil
+	}
+	i := slices.IndexFunc(r.RSystem.Profile.Achievements, func(a models.Achievement) bool { return a.ID == id })
+	if i == -1 {
+		return nil
+	}
+	return &r.RSystem.Profile.Achievements[i]
+}
+
+// ShowProfile writes the level, the progress to the next one and the
+// achievements, unlocked or not.
+func (r *RewardHandler) ShowProfile(writer io.Writer, now time.Time) {
+	xp := 0
+	if r.RSystem.Profile != nil {
+		xp = r.RSystem.Profile.XP
+	}
+	level, start, next := levelAt(xp)
+	p := r.progress(now)
+
+	fmt.Fprintf(writer, "Level %d\n", level)
+	fmt.Fprintf(writer, "XP: %d %s %d/%d to level %d\n", xp, progressBar(xp-start, next-start, 20), xp-start, next-start, level+1)
+	fmt.Fprintf(writer, "Completed tasks: %d, rewards bought: %d, best day: %d XP, best streak: %d days\n",
+		p.completed, p.bought, p.bestDay, p.bestStreak)
+
+	count := 0
+	for _, rule := range achievementRules {
+		if r.unlocked(rule.id) != nil {
+			count++
+		}
+	}
+	fmt.Fprintf(writer, "\nAchievements %d/%d:\n", count, len(achievementRules))
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, rule := range achievementRules {
+		if a := r.unlocked(rule.id); a != nil {
+			fmt.Fprintf(w, "[x]\t%s\t%s\t%s\n", rule.title, rule.description, a.At.Format(dayLayout))
+		} else {
+			fmt.Fprintf(w, "[ ]\t%s\t%s\t\n", rule.title, rule.description)
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestLevelAt(t *testing.T) {
+	tests := []struct {
+		xp, level, start, next int
+	}{
+		{0, 1, 0, 100},
+		{99, 1, 0, 100},
+		{100, 2, 100, 300},
+		{650, 4, 600, 1000},
+	}
+	for _, tt := range tests {
+		level, start, next := levelAt(tt.xp)
+		if level != tt.level || start != tt.start || next != tt.next {
+			t.Errorf("levelAt(%d) = %d, %d, %d, want %d, %d, %d", tt.xp, level, start, next, tt.level, tt.start, tt.next)
+		}
+	}
+}
+
+func TestRewardHandler_XPIsNotSpent(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{Tasks: []models.Task{{ID: 1, Text: "big one", TaskPoints: 120}}},
+	}
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{Rewards: []models.Reward{{ID: 1, Description: "Film", PriceOfReward: 100}}},
+	}
+
+	if _, err := h.CompleteWithPoints(0, r); err != nil {
+		t.Fatalf("CompleteWithPoints() returned an unexpected error: %v", err)
+	}
+	if _, err := r.BuyRewards(0); err != nil {
+		t.Fatalf("BuyRewards() returned an unexpected error: %v", err)
+	}
+	if r.RSystem.UserPoints != 20 || r.RSystem.Profile.XP != 120 {
+		t.Fatalf("Expected 20 points and 120 XP, got %d and %d", r.RSystem.UserPoints, r.RSystem.Profile.XP)
+	}
+
+	var ids []string
+	for _, a := range r.RSystem.Profile.Achievements {
+		ids = append(ids, a.ID)
+	}
+	for _, want := range []string{"first-task", "day-100", "first-reward"} {
+		if !slices.Contains(ids, want) {
+			t.Errorf("Expected achievement %q to be unlocked, got %v", want, ids)
+		}
+	}
+	if !slices.Contains(r.Notices, "Level up! You are level 2 now") {
+		t.Errorf("Expected the level up notice, got %q", r.Notices)
+	}
+
+	// Taking back the task takes back its XP, the achievements stay.
+	_, _ = h.NotCompletedWithPoints(0, r)
+	if r.RSystem.Profile.XP != 0 || len(r.RSystem.Profile.Achievements) != len(ids) {
+		t.Errorf("Expected 0 XP and the same achievements, got %+v", r.RSystem.Profile)
+	}
+}
+
+func TestRewardHandler_ShowProfile(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			Profile: &models.Profile{
+				XP:           150,
+				Achievements: []models.Achievement{{ID: "first-task", At: time.Date(2025, time.May, 4, 9, 0, 0, 0, time.Local)}},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	r.ShowProfile(&out, time.Now())
+	for _, want := range []string{"Level 2", "50/200 to level 3", "Achievements 1/", "[x]  First step", "2025-05-04", "[ ]  Getting started"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+// isApprover reports whether the active user may approve requests of
+// others. Nobody may while no approvers are set.
+func (r *RewardHandler) isApprover() bool {
+	return slices.Contains(r.Rules.Approvers, r.RSystem.User)
+}
+
+// errNoApprovers is returned for a request which nobody could decide on.
+var errNoApprovers = fmt.Errorf("no approvers are set, use 'todo config set approval.approvers <name>,...'")
+
+// Give sends points of the active user to another one. A transfer above the
+// threshold becomes a pending request with the points held, unless an
+// approver gives them. It returns the request and whether it is pending.
+func (r *RewardHandler) Give(to string, points int, note string) (models.Request, bool, error) {
+	from := r.RSystem.User
+	to = utils.NormalizeUser(to)
+	switch {
+	case from == "":
+		return models.Request{}, false, fmt.Errorf("no user is set, use 'todo config set user <name>' or TODO_USER")
+	case to == "" || to == from:
+		return models.Request{}, false, fmt.Errorf("points can be given only to another user")
+	case points <= 0:
+		return models.Request{}, false, fmt.Errorf("count of points must be positive")
+	}
+	if err := r.CheckUser(to); err != nil {
+		return models.Request{}, false, err
+	}
+	if spendable := utils.SpendablePoints(r.RSystem, models.Reward{}); points > spendable {
+		return models.Request{}, false, fmt.Errorf("not enough points: %d can be given", max(spendable, 0))
+	}
+
+	request := models.Request{
+		Kind:      models.RequestTransfer,
+		From:      from,
+		To:        to,
+		Points:    points,
+		Note:      note,
+		CreatedAt: time.Now(),
+	}
+	pending := r.Rules.TransfersAbove > 0 && points > r.Rules.TransfersAbove && !r.isApprover()
+	if pending {
+		if len(r.Rules.Approvers) == 0 {
+			return models.Request{}, false, fmt.Errorf("transfers above %d points need approval: %w", r.Rules.TransfersAbove, errNoApprovers)
+		}
+		request = r.addRequest(request)
+		r.UpdateUserPoints(-points, withNote(fmt.Sprintf("held for request %d: transfer to %s", request.ID, to), note))
+		return request, true, nil
+	}
+
+	r.UpdateUserPoints(-points, withNote("given to "+to, note))
+	err := r.As(to, func() {
+		r.UpdateUserPoints(points, withNote("received from "+from, note))
+	})
+	return request, false, err
+}
+
+// addRequest gives the request the next ID and keeps it until it is
+// approved or denied.
+func (r *RewardHandler) addRequest(request models.Request) models.Request {
+	if r.RSystem.NextRequestID < 1 {
+		r.RSystem.NextRequestID = 1
+	}
+	request.ID = r.RSystem.NextRequestID
+	r.RSystem.NextRequestID++
+	r.RSystem.Requests = append(r.RSystem.Requests, request)
+	return request
+}
+
+// NeedsApproval reports whether the active user has to ask for the reward,
+// approvers do not.
+func (r *RewardHandler) NeedsApproval(indexElem int) bool {
+	return r.RSystem.Rewards[indexElem].RequiresApproval && !r.isApprover()
+}
+
+// SetRequiresApproval sets whether the reward has to be asked for, which
+// needs approvers. Only they may turn the approval off.
+func (r *RewardHandler) SetRequiresApproval(indexElem int, requiresApproval bool) error {
+	if requiresApproval && len(r.Rules.Approvers) == 0 {
+		return fmt.Errorf("the reward cannot need approval: %w", errNoApprovers)
+	}
+	if !requiresApproval && r.RSystem.Rewards[indexElem].RequiresApproval && len(r.Rules.Approvers) > 0 && !r.isApprover() {
+		return fmt.Errorf("only %v can turn the approval off", r.Rules.Approvers)
+	}
+	r.RSystem.Rewards[indexElem].RequiresApproval = requiresApproval
+	return nil
+}
+
+// RequestReward asks for the reward: its price is held from the balance of
+// the active user until the request is approved or denied.
+func (r *RewardHandler) RequestReward(indexElem int) (models.Request, error) {
+	if r.RSystem.User == "" {
+		return models.Request{}, fmt.Errorf("the reward needs approval, but no user is set: use 'todo config set user <name>' or TODO_USER")
+	}
+	if len(r.Rules.Approvers) == 0 {
+		return models.Request{}, fmt.Errorf("the reward needs approval: %w", errNoApprovers)
+	}
+	reward, err := r.checkPurchase(indexElem, time.Now())
+	if err != nil {
+		return models.Request{}, err
+	}
+
+	request := r.addRequest(models.Request{
+		Kind:      models.RequestReward,
+		From:      r.RSystem.User,
+		Points:    reward.PriceOfReward,
+		CreatedAt: time.Now(),
+		RewardUID: reward.UID,
+		Reward:    reward.Description,
+	})
+	r.UpdateUserPoints(-reward.PriceOfReward, fmt.Sprintf("held for request %d: %s", request.ID, reward.Description))
+	return request, nil
+}
+
+// findRequest returns the index of the pending request id which the active
+// user may decide on.
+func (r *RewardHandler) findRequest(id int) (int, error) {
+	i := slices.IndexFunc(r.RSystem.Requests, func(request models.Request) bool { return request.ID == id })
+	if i == -1 {
+		return -1, fmt.Errorf("request %d was not found", id)
+	}
+	if r.RSystem.Requests[i].From == r.RSystem.User {
+		return -1, fmt.Errorf("request %d is yours, another user has to decide on it", id)
+	}
+	if len(r.Rules.Approvers) == 0 {
+		return -1, errNoApprovers
+	}
+	if !r.isApprover() {
+		return -1, fmt.Errorf("only %v can decide on requests", r.Rules.Approvers)
+	}
+	return i, nil
+}
+
+// Approve carries out the pending request id with the held points: the
+// points of a transfer go to the receiver, a reward is given to the user
+// who asked for it.
+func (r *RewardHandler) Approve(id int) (models.Request, error) {
+	i, err := r.findRequest(id)
+	if err != nil {
+		return models.Request{}, err
+	}
+	request := r.RSystem.Requests[i]
+
+	switch request.Kind {
+	case models.RequestReward:
+		rewardIndex := slices.IndexFunc(r.RSystem.Rewards, func(reward models.Reward) bool { return reward.UID == request.RewardUID })
+		if rewardIndex == -1 {
+			return request, fmt.Errorf("the reward %s was deleted, deny the request to give the points back", request.Reward)
+		}
+		// The stock, the cooldown and the limit are checked again with the
+		// purchases of the user who asked, the held points pay for it.
+		now := time.Now()
+		var checkErr error
+		err = r.As(request.From, func() {
+			if checkErr = r.checkAvailable(rewardIndex, now); checkErr == nil {
+				r.deliver(rewardIndex, now)
+			}
+		})
+		if err == nil {
+			err = checkErr
+		}
+	default:
+		err = r.As(request.To, func() {
+			r.UpdateUserPoints(request.Points, withNote("received from "+request.From, request.Note))
+		})
+	}
+	if err != nil {
+		return request, err
+	}
+	r.RSystem.Requests = slices.Delete(r.RSystem.Requests, i, i+1)
+	return request, nil
+}
+
+// Deny drops the pending request id and gives the held points back.
+func (r *RewardHandler) Deny(id int) (models.Request, error) {
+	i, err := r.findRequest(id)
+	if err != nil {
+		return models.Request{}, err
+	}
+	request := r.RSystem.Requests[i]
+	err = r.As(request.From, func() {
+		r.UpdateUserPoints(request.Points, fmt.Sprintf("refund of request %d: denied", request.ID))
+	})
+	if err != nil {
+		return request, err
+	}
+	r.RSystem.Requests = slices.Delete(r.RSystem.Requests, i, i+1)
+	return request, nil
+}
+
+// ShowRequests writes the pending requests.
+func (r *RewardHandler) ShowRequests(writer io.Writer) {
+	if len(r.RSystem.Requests) == 0 {
+		fmt.Fprintln(writer, "No pending requests")
+		return
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tFrom\tRequest\tPoints\tCreated\n")
+	for _, request := range r.RSystem.Requests {
+		fmt.Fprintf(w, "%d.\t%s\t%s\t%d\t%s\n", request.ID, request.From, DescribeRequest(request),
+			request.Points, request.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+}
+
+// DescribeRequest tells what the request is about.
+func DescribeRequest(request models.Request) string {
+	if request.Kind == models.RequestReward {
+		return "reward: " + request.Reward
+	}
+	return withNote("transfer to "+request.To, request.Note)
+}
+
+// HeldPoints returns the points of the active user held by pending
+// requests.
+func (r *RewardHandler) HeldPoints() int {
+	held := 0
+	for _, request := range r.RSystem.Requests {
+		if request.From == r.RSystem.User {
+			held += request.Points
+		}
+	}
+	return held
+}
+
+func withNote(text, note string) string {
+	if note == "" {
+		return text
+	}
+	return text + ": " + note
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestRewardHandler_Give(t *testing.T) {
+	r := &RewardHandler{RSystem: &models.RewardSystem{User: "alice", UserPoints: 50}, Rules: Rules{Users: []string{"alice", "bob"}}}
+
+	if _, _, err := r.Give("alice", 10, ""); err == nil {
+		t.Error("Expected error when giving points to yourself")
+	}
+	if _, _, err := r.Give("bbo", 10, ""); err == nil || !strings.Contains(err.Error(), "unknown user") {
+		t.Errorf("Expected error for an unknown user, got %v", err)
+	}
+	if r.RSystem.UserPoints != 50 || r.RSystem.Accounts["bbo"] != nil {
+		t.Errorf("Expected no points to move to an unknown user, got %d left and accounts %v", r.RSystem.UserPoints, r.RSystem.Accounts)
+	}
+	if _, _, err := r.Give("bob", 60, ""); err == nil || !strings.Contains(err.Error(), "not enough points") {
+		t.Errorf("Expected error when giving more than the balance, got %v", err)
+	}
+
+	_, pending, err := r.Give("Bob", 20, "for the movie")
+	if err != nil || pending {
+		t.Fatalf("Expected the transfer to be done at once, got pending %v and %v", pending, err)
+	}
+	if r.RSystem.UserPoints != 30 || r.RSystem.Accounts["bob"].UserPoints != 20 {
+		t.Fatalf("Expected 30 points for alice and 20 for bob, got %d and %d", r.RSystem.UserPoints, r.RSystem.Accounts["bob"].UserPoints)
+	}
+	bobLedger := r.RSystem.Accounts["bob"].Ledger
+	if len(bobLedger) != 1 || bobLedger[0].Note != "received from alice: for the movie" {
+		t.Errorf("Expected the transfer in the ledger of bob, got %+v", bobLedger)
+	}
+}
+
+func TestRewardHandler_GiveNeedsApproval(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{User: "kid", UserPoints: 100},
+		Rules:   Rules{Users: []string{"kid", "sis"}, Approvers: []string{"mom"}, TransfersAbove: 25},
+	}
+	request, pending, err := r.Give("sis", 40, "")
+	if err != nil || !pending || request.ID != 1 {
+		t.Fatalf("Expected request 1 to wait for approval, got %+v, %v, %v", request, pending, err)
+	}
+	if r.RSystem.UserPoints != 60 || r.HeldPoints() != 40 {
+		t.Fatalf("Expected 40 points to be held, got %d left and %d held", r.RSystem.UserPoints, r.HeldPoints())
+	}
+	if _, err := r.Approve(1); err == nil {
+		t.Error("Expected the sender not to approve their own request")
+	}
+
+	r.As("sis", func() {
+		if _, err := r.Approve(1); err == nil {
+			t.Error("Expected only mom to approve")
+		}
+	})
+	r.As("mom", func() {
+		if _, err := r.Deny(1); err != nil {
+			t.Fatalf("Deny() returned an unexpected error: %v", err)
+		}
+	})
+	if r.RSystem.UserPoints != 100 || len(r.RSystem.Requests) != 0 {
+		t.Errorf("Expected the held points to come back, got %d and %+v", r.RSystem.UserPoints, r.RSystem.Requests)
+	}
+
+	// An approver does not wait for anybody.
+	r.As("mom", func() {
+		r.UpdateUserPoints(50, "")
+		if _, pending, err := r.Give("kid", 50, ""); err != nil || pending {
+			t.Errorf("Expected the transfer of an approver to be done at once, got %v, %v", pending, err)
+		}
+	})
+	if r.RSystem.UserPoints != 150 {
+		t.Errorf("Expected 150 points for the kid, got %d", r.RSystem.UserPoints)
+	}
+}
+
+func TestRewardHandler_RequestReward(t *testing.T) {
+	stock := 1
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			User:       "kid",
+			UserPoints: 100,
+			Rewards: []models.Reward{
+				{ID: 1, UID: "r-1", Description: "Game hour", PriceOfReward: 30, RequiresApproval: true, Stock: &stock},
+				{ID: 2, UID: "r-2", Description: "Ice cream", PriceOfReward: 20, RequiresApproval: true},
+			},
+		},
+		Rules: Rules{Approvers: []string{"mom"}},
+	}
+
+	if !r.NeedsApproval(0) {
+		t.Fatal("Expected the kid to need approval")
+	}
+	if _, err := r.BuyRewards(0); err == nil {
+		t.Fatal("Expected BuyRewards to refuse a reward which needs approval")
+	}
+	first, err := r.RequestReward(0)
+	if err != nil {
+		t.Fatalf("RequestReward() returned an unexpected error: %v", err)
+	}
+	second, _ := r.RequestReward(1)
+	if r.RSystem.UserPoints != 50 || r.HeldPoints() != 50 {
+		t.Fatalf("Expected 50 points to be held, got %d left and %d held", r.RSystem.UserPoints, r.HeldPoints())
+	}
+
+	var out bytes.Buffer
+	r.ShowRequests(&out)
+	if !strings.Contains(out.String(), "reward: Game hour") || !strings.Contains(out.String(), "reward: Ice cream") {
+		t.Errorf("Expected both requests in the output:\n%s", out.String())
+	}
+
+	r.As("mom", func() {
+		if r.NeedsApproval(0) {
+			t.Error("Expected an approver not to need approval")
+		}
+		if _, err := r.Approve(first.ID); err != nil {
+			t.Fatalf("Approve() returned an unexpected error: %v", err)
+		}
+		if _, err := r.Deny(second.ID); err != nil {
+			t.Fatalf("Deny() returned an unexpected error: %v", err)
+		}
+	})
+
+	if *r.RSystem.Rewards[0].Stock != 0 || r.RSystem.Profile == nil || r.RSystem.Profile.Bought != 1 {
+		t.Errorf("Expected the game hour to be given to the kid, got %+v and %+v", r.RSystem.Rewards[0], r.RSystem.Profile)
+	}
+	if r.RSystem.UserPoints != 70 || len(r.RSystem.Requests) != 0 {
+		t.Errorf("Expected the ice cream to be refunded, got %d points and %+v", r.RSystem.UserPoints, r.RSystem.Requests)
+	}
+}
+
+func TestRewardHandler_ApproveChecksTheLimit(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			User:       "kid",
+			UserPoints: 100,
+			Rewards: []models.Reward{
+				{ID: 1, UID: "r-1", Description: "Game hour", PriceOfReward: 30, RequiresApproval: true, Limit: 1, LimitPeriod: "day"},
+			},
+		},
+		Rules: Rules{Approvers: []string{"mom"}},
+	}
+
+	// Both requests are made before the first one is approved.
+	first, _ := r.RequestReward(0)
+	second, err := r.RequestReward(0)
+	if err != nil {
+		t.Fatalf("RequestReward() returned an unexpected error: %v", err)
+	}
+
+	r.As("mom", func() {
+		if _, err := r.Approve(first.ID); err != nil {
+			t.Fatalf("Approve() returned an unexpected error: %v", err)
+		}
+		if _, err := r.Approve(second.ID); err == nil || !strings.Contains(err.Error(), "available again") {
+			t.Errorf("Expected the limit of once a day to refuse the second request, got %v", err)
+		}
+	})
+	if len(r.RSystem.Requests) != 1 || r.RSystem.Profile == nil || r.RSystem.Profile.Bought != 1 {
+		t.Errorf("Expected the second request to wait, got %+v and %+v", r.RSystem.Requests, r.RSystem.Profile)
+	}
+}
+
+func TestRewardHandler_OnlyApproversTurnApprovalOff(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			User:       "kid",
+			UserPoints: 100,
+			Rewards:    []models.Reward{{ID: 1, UID: "r-1", Description: "Game hour", PriceOfReward: 30, RequiresApproval: true}},
+		},
+		Rules: Rules{Approvers: []string{"mom"}},
+	}
+
+	if err := r.SetRequiresApproval(0, false); err == nil {
+		t.Fatal("Expected the kid not to turn the approval off")
+	}
+	if _, err := r.BuyRewards(0); err == nil || !r.RSystem.Rewards[0].RequiresApproval {
+		t.Fatalf("Expected the reward to still need approval, got %+v and %v", r.RSystem.Rewards[0], err)
+	}
+
+	r.As("mom", func() {
+		if err := r.SetRequiresApproval(0, false); err != nil {
+			t.Errorf("SetRequiresApproval() returned an unexpected error: %v", err)
+		}
+	})
+	if r.RSystem.Rewards[0].RequiresApproval {
+		t.Error("Expected mom to turn the approval off")
+	}
+}
+
+func TestRewardHandler_ApprovalNeedsApprovers(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			User:       "kid",
+			UserPoints: 100,
+			Rewards:    []models.Reward{{ID: 1, UID: "r-1", Description: "Game hour", PriceOfReward: 30}},
+			Requests:   []models.Request{{ID: 1, Kind: models.RequestTransfer, From: "sis", To: "kid", Points: 10}},
+		},
+		Rules: Rules{Users: []string{"kid", "sis"}, TransfersAbove: 25},
+	}
+
+	if err := r.SetRequiresApproval(0, true); err == nil {
+		t.Error("Expected a reward not to need approval without approvers")
+	}
+	if _, err := r.Approve(1); err == nil {
+		t.Error("Expected nobody to approve without approvers")
+	}
+	if _, _, err := r.Give("sis", 40, ""); err == nil {
+		t.Error("Expected a transfer which needs approval to be refused without approvers")
+	}
+
+	// A reward marked before the approvers were removed cannot be asked for.
+	r.RSystem.Rewards[0].RequiresApproval = true
+	if _, err := r.RequestReward(0); err == nil {
+		t.Error("Expected a request which nobody could approve to be refused")
+	}
+	if r.RSystem.UserPoints != 100 || len(r.RSystem.Requests) != 1 {
+		t.Errorf("Expected nothing to change, got %d points and %+v", r.RSystem.UserPoints, r.RSystem.Requests)
+	}
+}
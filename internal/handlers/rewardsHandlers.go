@@ -3,7 +3,10 @@ package handlers
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
 	"github.com/svetsed/todo_cli_app/internal/utils"
@@ -11,15 +14,23 @@ import (
 
 type RewardHandler struct {
 	RSystem *models.RewardSystem `json:"rewardSystem"`
+	// Notices collects messages about bonuses given while the balance
+	// changed, for the commands to show.
+	Notices []string `json:"-"`
+	// Rules are the bonuses, penalties and approvals of the config.
+	Rules Rules `json:"-"`
 }
 
+// AddReward appends a new reward under the next display ID, which is never
+// reused, and a permanent UUID.
 func (r *RewardHandler) AddReward(desrc string, price int) {
-	if len(r.RSystem.Rewards) == 0 {
+	if r.RSystem.NextID < 1 {
 		r.RSystem.NextID = 1
 	}
-	_, isAvailable := utils.CalculateIsAvailableReward(r.RSystem.UserPoints, price)
+	_, isAvailable := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, models.Reward{}), price)
 	reward := models.Reward{
 		ID:            r.RSystem.NextID,
+		UID:           utils.NewUUID(),
 		Description:   desrc,
 		PriceOfReward: price,
 		IsAvailable:   isAvailable,
@@ -30,65 +41,316 @@ func (r *RewardHandler) AddReward(desrc string, price int) {
 
 func (r *RewardHandler) ListReward(writer io.Writer) {
 	fmt.Printf("Your balance of points: %d\n", r.RSystem.UserPoints)
+	if goal := r.RSystem.Goal; goal != nil && goal.Reserved > 0 {
+		fmt.Printf("Reserved for the goal: %d, free to spend: %d\n", goal.Reserved, r.RSystem.UserPoints-goal.Reserved)
+	}
+	if held := r.HeldPoints(); held > 0 {
+		fmt.Printf("Held by pending requests: %d\n", held)
+	}
 
 	if len(r.RSystem.Rewards) == 0 {
 		fmt.Println("The rewards was not added")
 		return
 	}
 
-	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "Available\tID\tDescription\tPrice\n")
-
+	r.Restock(time.Now())
 	if r.RSystem.IsUserPointsUpdate {
 		r.UpdateIsAvailableRewards()
 	}
 
+	limited := false
+	for _, reward := range r.RSystem.Rewards {
+		limited = limited || reward.Stock != nil || reward.SingleUse || reward.Cooldown != "" || reward.Limit > 0
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	header := "Available\tID\tDescription\tPrice\tProgress"
+	if limited {
+		header += "\tStock\tLimit\tNext available"
+	}
+	fmt.Fprintln(w, header)
+
 	for _, reward := range r.RSystem.Rewards {
 		status := "no"
 		if reward.IsAvailable {
 			status = "yes"
+			// The reward is asked for, an approver decides.
+			if reward.RequiresApproval {
+				status = "ask"
+			}
+		}
+		row := fmt.Sprintf("  %s\t%d.\t%s\t%d\t%s", status, reward.ID, reward.Description, reward.PriceOfReward, rewardProgress(r.RSystem, reward))
+		if limited {
+			row += fmt.Sprintf("\t%s\t%s\t%s", stockNote(reward), limitNote(reward), nextNote(reward, now))
 		}
-		fmt.Fprintf(w, "  %s\t%d.\t%s\t%d\n", status, reward.ID, reward.Description, reward.PriceOfReward)
+		fmt.Fprintln(w, row)
 	}
 	w.Flush()
+
+	if r.GoalReward() != -1 {
+		fmt.Fprintln(writer)
+		r.ShowGoal(writer)
+	}
 }
 
-func (r *RewardHandler) BuyRewards(indexBuyElem int) error {
-	points, available := utils.CalculateIsAvailableReward(r.RSystem.UserPoints, r.RSystem.Rewards[indexBuyElem].PriceOfReward)
-	if available {
-		r.RSystem.UserPoints = points
-		r.RSystem.IsUserPointsUpdate = true
-	} else {
-		return fmt.Errorf("not enough %d points", points)
+// ListRewardUIDs shows the permanent UUIDs of the rewards next to the display
+// IDs.
+func (r *RewardHandler) ListRewardUIDs(writer io.Writer) {
+	if len(r.RSystem.Rewards) == 0 {
+		fmt.Fprintln(writer, "The rewards was not added")
+		return
+	}
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tUUID\tDescription\n")
+	for _, reward := range r.RSystem.Rewards {
+		fmt.Fprintf(w, "%d.\t%s\t%s\n", reward.ID, reward.UID, reward.Description)
+	}
+	w.Flush()
+}
+
+// BuyRewards buys the reward and returns it, a single-use reward is removed
+// from the list. Rewards out of stock can not be bought, rewards which need
+// approval are asked for with RequestReward.
+func (r *RewardHandler) BuyRewards(indexBuyElem int) (models.Reward, error) {
+	now := time.Now()
+	reward, err := r.checkPurchase(indexBuyElem, now)
+	if err != nil {
+		return reward, err
+	}
+	if r.NeedsApproval(indexBuyElem) {
+		return reward, fmt.Errorf("the reward needs approval")
+	}
+
+	r.RSystem.UserPoints -= reward.PriceOfReward
+	r.RSystem.IsUserPointsUpdate = true
+	r.record(-reward.PriceOfReward, "bought "+reward.Description)
+	return r.deliver(indexBuyElem, now), nil
+}
+
+// checkPurchase returns the reward if the active user can buy it now.
+func (r *RewardHandler) checkPurchase(indexBuyElem int, now time.Time) (models.Reward, error) {
+	if err := r.checkAvailable(indexBuyElem, now); err != nil {
+		return r.RSystem.Rewards[indexBuyElem], err
+	}
+	reward := r.RSystem.Rewards[indexBuyElem]
+
+	points, available := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, reward), reward.PriceOfReward)
+	if !available {
+		if goal := r.RSystem.Goal; goal != nil && goal.Reserved > 0 && goal.RewardID != reward.ID {
+			return reward, fmt.Errorf("not enough %d points, %d points are reserved for the goal", points, goal.Reserved)
+		}
+		return reward, fmt.Errorf("not enough %d points", points)
+	}
+	return reward, nil
+}
+
+// checkAvailable returns an error unless the reward is in stock and its
+// cooldown and limit let the active user have it now.
+func (r *RewardHandler) checkAvailable(indexElem int, now time.Time) error {
+	r.Restock(now)
+	reward := r.RSystem.Rewards[indexElem]
+	if !utils.InStock(reward) {
+		return fmt.Errorf("the reward is out of stock%s", restockNote(reward))
+	}
+	if next := utils.NextAvailable(reward, now); next.After(now) {
+		return fmt.Errorf("the reward is available again at %s", next.Format("2006-01-02 15:04"))
 	}
 	return nil
 }
 
-func (r *RewardHandler) UpdateUserPoints(countPoints int) {
+// deliver gives the paid reward to the active user: the goal is done, the
+// stock and the purchases change and a single-use reward is removed.
+func (r *RewardHandler) deliver(indexBuyElem int, now time.Time) models.Reward {
+	reward := r.RSystem.Rewards[indexBuyElem]
+	if goal := r.RSystem.Goal; goal != nil && goal.RewardID == reward.ID {
+		r.RSystem.Goal = nil
+	}
+
+	if reward.Stock != nil {
+		left := *reward.Stock - 1
+		reward.Stock = &left
+	}
+	if reward.Cooldown != "" || reward.Limit > 0 {
+		reward.Purchases = append(reward.Purchases, now)
+		reward.Purchases = utils.RecentPurchases(reward, now)
+	}
+	r.RSystem.Rewards[indexBuyElem] = reward
+	if reward.SingleUse {
+		r.DeleteReward(indexBuyElem)
+	}
+	r.profile().Bought++
+	r.checkAchievements(now)
+	return reward
+}
+
+// SetStock limits how many times the reward can be bought, a negative stock
+// removes the limit. With a period (week or month) the stock is filled up
+// again when a new period begins.
+func (r *RewardHandler) SetStock(indexStockElem int, stock int, restock string) {
+	reward := &r.RSystem.Rewards[indexStockElem]
+	if stock < 0 {
+		reward.Stock = nil
+		reward.Restock = ""
+		reward.RestockTo = 0
+		reward.RestockedAt = nil
+	} else {
+		now := time.Now()
+		reward.Stock = &stock
+		reward.Restock = restock
+		reward.RestockTo = stock
+		reward.RestockedAt = &now
+		if restock == "" {
+			reward.RestockTo = 0
+			reward.RestockedAt = nil
+		}
+	}
+	_, isAvailable := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, *reward), reward.PriceOfReward)
+	reward.IsAvailable = isAvailable && utils.InStock(*reward)
+}
+
+// SetLimits sets the cooldown after a purchase and how many times the reward
+// can be bought per period. An empty cooldown or a zero limit removes them.
+func (r *RewardHandler) SetLimits(indexLimitElem int, cooldown string, limit int, period string) {
+	reward := &r.RSystem.Rewards[indexLimitElem]
+	reward.Cooldown = cooldown
+	reward.Limit = limit
+	reward.LimitPeriod = period
+	if limit == 0 {
+		reward.LimitPeriod = ""
+	}
+	if cooldown == "" && limit == 0 {
+		reward.Purchases = nil
+	}
+	_, isAvailable := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, *reward), reward.PriceOfReward)
+	reward.IsAvailable = isAvailable && utils.InStock(*reward) && !utils.NextAvailable(*reward, time.Now()).After(time.Now())
+}
+
+// Restock fills up the stock of rewards whose restock period has begun since
+// the last refill. It marks the availability to be recalculated and saved.
+func (r *RewardHandler) Restock(now time.Time) bool {
+	restocked := false
+	for i := range r.RSystem.Rewards {
+		reward := &r.RSystem.Rewards[i]
+		if reward.Restock == "" || reward.Stock == nil {
+			continue
+		}
+		if reward.RestockedAt != nil && !reward.RestockedAt.Before(utils.PeriodStart(reward.Restock, now)) {
+			continue
+		}
+		stock := reward.RestockTo
+		reward.Stock = &stock
+		reward.RestockedAt = &now
+		restocked = true
+	}
+	if restocked {
+		r.RSystem.IsUserPointsUpdate = true
+	}
+	return restocked
+}
+
+// restockNote tells when a reward out of stock is filled up again.
+func restockNote(reward models.Reward) string {
+	if reward.Restock == "" {
+		return ""
+	}
+	return fmt.Sprintf(", restocked on %s", utils.NextPeriodStart(reward.Restock, time.Now()).Format("2006-01-02"))
+}
+
+// nextNote tells when the reward can be bought again, not counting the
+// balance.
+func nextNote(reward models.Reward, now time.Time) string {
+	if !utils.InStock(reward) {
+		if reward.Restock == "" {
+			return "never"
+		}
+		return utils.NextPeriodStart(reward.Restock, now).Format("2006-01-02 15:04")
+	}
+	if next := utils.NextAvailable(reward, now); next.After(now) {
+		return next.Format("2006-01-02 15:04")
+	}
+	return "now"
+}
+
+// limitNote describes the cooldown and the limit of the reward for the list.
+func limitNote(reward models.Reward) string {
+	var notes []string
+	if reward.Limit > 0 {
+		notes = append(notes, fmt.Sprintf("%d per %s", reward.Limit, reward.LimitPeriod))
+	}
+	if reward.Cooldown != "" {
+		notes = append(notes, "every "+reward.Cooldown)
+	}
+	if len(notes) == 0 {
+		return "-"
+	}
+	return strings.Join(notes, ", ")
+}
+
+// stockNote describes the stock of the reward for the list.
+func stockNote(reward models.Reward) string {
+	var note string
+	switch {
+	case reward.Stock == nil:
+		note = "-"
+	case reward.Restock != "":
+		note = fmt.Sprintf("%d/%d per %s", *reward.Stock, reward.RestockTo, reward.Restock)
+	default:
+		note = strconv.Itoa(*reward.Stock)
+	}
+	if reward.SingleUse {
+		note += ", once"
+	}
+	return note
+}
+
+// UpdateUserPoints changes the balance and writes the change to the ledger
+// with the note. Incoming points are reserved for the goal until it is
+// reached.
+func (r *RewardHandler) UpdateUserPoints(countPoints int, note string) {
 	if countPoints > 0 || countPoints < 0 {
 		r.RSystem.UserPoints += countPoints
 		r.RSystem.IsUserPointsUpdate = true
+		r.record(countPoints, note)
+		r.reserve(countPoints)
 	}
 
 }
 
+// record writes a change of the balance to the ledger.
+func (r *RewardHandler) record(countPoints int, note string) {
+	r.RSystem.Ledger = append(r.RSystem.Ledger, models.LedgerEntry{At: time.Now(), Points: countPoints, Note: note})
+}
+
 func (r *RewardHandler) EditDesrcRewards(indexEditElem int, newDesrc string) {
 	r.RSystem.Rewards[indexEditElem].Description = newDesrc
 }
 
 func (r *RewardHandler) EditPriceRewards(indexEditElem int, newPrice int) {
 	r.RSystem.Rewards[indexEditElem].PriceOfReward = newPrice
-	_, r.RSystem.Rewards[indexEditElem].IsAvailable = utils.CalculateIsAvailableReward(r.RSystem.UserPoints, newPrice)
+	r.reserve(0)
+	_, isAvailable := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, r.RSystem.Rewards[indexEditElem]), newPrice)
+	r.RSystem.Rewards[indexEditElem].IsAvailable = isAvailable && utils.InStock(r.RSystem.Rewards[indexEditElem])
 }
 
 func (r *RewardHandler) DeleteReward(indexDelElem int) {
+	if goal := r.RSystem.Goal; goal != nil && goal.RewardID == r.RSystem.Rewards[indexDelElem].ID {
+		r.RSystem.Goal = nil
+	}
 	r.RSystem.Rewards = append(r.RSystem.Rewards[:indexDelElem], r.RSystem.Rewards[indexDelElem+1:]...)
 }
 
+// UpdateIsAvailableRewards restocks the rewards and marks those the balance
+// is enough for, which are in stock and not held back by a cooldown or a
+// limit as available.
 func (r *RewardHandler) UpdateIsAvailableRewards() {
-	userPoints := r.RSystem.UserPoints
+	now := time.Now()
+	r.Restock(now)
 	for i := range r.RSystem.Rewards {
-		_, r.RSystem.Rewards[i].IsAvailable = utils.CalculateIsAvailableReward(userPoints, r.RSystem.Rewards[i].PriceOfReward)
+		reward := r.RSystem.Rewards[i]
+		_, isAvailable := utils.CalculateIsAvailableReward(utils.SpendablePoints(r.RSystem, reward), reward.PriceOfReward)
+		r.RSystem.Rewards[i].IsAvailable = isAvailable && utils.InStock(reward) && !utils.NextAvailable(reward, now).After(now)
 	}
 
 	r.RSystem.IsUserPointsUpdate = false
@@ -96,10 +358,26 @@ func (r *RewardHandler) UpdateIsAvailableRewards() {
 
 func (r *RewardHandler) ClearAllRewards() {
 	r.RSystem.Rewards = []models.Reward{}
-	r.RSystem.NextID = 1
+	r.RSystem.Goal = nil
 }
 
 func (r *RewardHandler) ResetPoints() {
+	if r.RSystem.UserPoints != 0 {
+		r.record(-r.RSystem.UserPoints, "reset")
+	}
 	r.RSystem.UserPoints = 0
 	r.RSystem.IsUserPointsUpdate = true
+	r.reserve(0)
+}
+
+// HasReward reports whether there is already a reward with the same
+// description, ignoring case and surrounding spaces.
+func (r *RewardHandler) HasReward(description string) bool {
+	description = strings.TrimSpace(description)
+	for _, reward := range r.RSystem.Rewards {
+		if strings.EqualFold(strings.TrimSpace(reward.Description), description) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/utils"
 )
 
 func TestRewardHandler_AddReward(t *testing.T) {
@@ -42,22 +45,84 @@ func TestRewardHandler_BuyRewards(t *testing.T) {
 		},
 	}
 
-	err := r.BuyRewards(0)
+	reward, err := r.BuyRewards(0)
 	if err != nil {
 		t.Fatalf("Unexpected error buying reward: %v", err)
 	}
+	if reward.Description != "Reward 1" {
+		t.Errorf("Expected the bought reward to be returned, got %+v", reward)
+	}
 
 	if r.RSystem.UserPoints != 5 {
 		t.Errorf("Expected user points 5 after purchase, got %d", r.RSystem.UserPoints)
 	}
 
 	r.RSystem.UserPoints = 10
-	err = r.BuyRewards(0)
+	_, err = r.BuyRewards(0)
 	if err == nil {
 		t.Fatal("Expected error when buying reward with insufficient points, got nil")
 	}
 }
 
+func TestRewardHandler_BuyRewards_StockAndSingleUse(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			UserPoints: 100,
+			Rewards: []models.Reward{
+				{ID: 1, Description: "Movie night", PriceOfReward: 10},
+				{ID: 2, Description: "Concert", PriceOfReward: 30, SingleUse: true},
+			},
+		},
+	}
+	r.SetStock(0, 2, "")
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.BuyRewards(0); err != nil {
+			t.Fatalf("Unexpected error buying reward %d time: %v", i+1, err)
+		}
+	}
+	if _, err := r.BuyRewards(0); err == nil || !strings.Contains(err.Error(), "out of stock") {
+		t.Fatalf("Expected out of stock error, got %v", err)
+	}
+	r.UpdateIsAvailableRewards()
+	if r.RSystem.Rewards[0].IsAvailable {
+		t.Error("Reward out of stock must not be available")
+	}
+	if r.RSystem.UserPoints != 80 {
+		t.Errorf("Expected 80 points after two purchases, got %d", r.RSystem.UserPoints)
+	}
+
+	if _, err := r.BuyRewards(1); err != nil {
+		t.Fatalf("Unexpected error buying single-use reward: %v", err)
+	}
+	if len(r.RSystem.Rewards) != 1 || r.RSystem.Rewards[0].ID != 1 {
+		t.Errorf("Expected single-use reward to be removed, got %+v", r.RSystem.Rewards)
+	}
+}
+
+func TestRewardHandler_Restock(t *testing.T) {
+	lastMonth := utils.PeriodStart(utils.PeriodMonth, time.Now()).Add(-time.Hour)
+	empty := 0
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			Rewards: []models.Reward{
+				{ID: 1, Stock: &empty, Restock: utils.PeriodMonth, RestockTo: 3, RestockedAt: &lastMonth},
+				{ID: 2, Stock: &empty},
+			},
+		},
+	}
+
+	if !r.Restock(time.Now()) {
+		t.Fatal("Expected the monthly reward to be restocked")
+	}
+	if *r.RSystem.Rewards[0].Stock != 3 || *r.RSystem.Rewards[1].Stock != 0 {
+		t.Errorf("Expected stocks 3 and 0, got %d and %d", *r.RSystem.Rewards[0].Stock, *r.RSystem.Rewards[1].Stock)
+	}
+	if r.Restock(time.Now()) {
+		t.Error("Expected no second restock in the same month")
+	}
+}
+
 func TestRewardHandler_EditDesrcRewards(t *testing.T) {
 	r := &RewardHandler{
 		RSystem: &models.RewardSystem{
@@ -122,7 +187,7 @@ func TestRewardHandler_UpdateUserPoints(t *testing.T) {
 		},
 	}
 
-	r.UpdateUserPoints(5)
+	r.UpdateUserPoints(5, "")
 	if r.RSystem.UserPoints != 15 {
 		t.Errorf("Expected UserPoints 15, got %d", r.RSystem.UserPoints)
 	}
@@ -130,7 +195,7 @@ func TestRewardHandler_UpdateUserPoints(t *testing.T) {
 		t.Error("Expected IsUserPointsUpdate to be true after update")
 	}
 
-	r.UpdateUserPoints(-3)
+	r.UpdateUserPoints(-3, "")
 	if r.RSystem.UserPoints != 12 {
 		t.Errorf("Expected UserPoints 12, got %d", r.RSystem.UserPoints)
 	}
@@ -150,8 +215,8 @@ func TestRewardHandler_ClearAllRewards(t *testing.T) {
 	if len(r.RSystem.Rewards) != 0 {
 		t.Errorf("Expected 0 rewards after clear, got %d", len(r.RSystem.Rewards))
 	}
-	if r.RSystem.NextID != 1 {
-		t.Errorf("Expected NextID reset to 1 after clear, got %d", r.RSystem.NextID)
+	if r.RSystem.NextID != 2 {
+		t.Errorf("Expected NextID to stay 2 after clear, IDs are never reused, got %d", r.RSystem.NextID)
 	}
 }
 
@@ -171,3 +236,39 @@ func TestRewardHandler_ResetPoints(t *testing.T) {
 		t.Error("Expected IsUserPointsUpdate to be true after reset")
 	}
 }
+
+func TestRewardHandler_BuyRewards_CooldownAndLimit(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			UserPoints: 100,
+			Rewards: []models.Reward{
+				{ID: 1, Description: "Gaming hour", PriceOfReward: 10},
+				{ID: 2, Description: "Dessert", PriceOfReward: 5},
+			},
+		},
+	}
+	r.SetLimits(0, "1d", 0, "")
+	r.SetLimits(1, "", 2, utils.PeriodWeek)
+
+	if _, err := r.BuyRewards(0); err != nil {
+		t.Fatalf("Unexpected error buying reward: %v", err)
+	}
+	_, err := r.BuyRewards(0)
+	if err == nil || !strings.Contains(err.Error(), "available again at") {
+		t.Fatalf("Expected cooldown error, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.BuyRewards(1); err != nil {
+			t.Fatalf("Unexpected error buying reward %d time: %v", i+1, err)
+		}
+	}
+	_, err = r.BuyRewards(1)
+	nextWeek := utils.NextPeriodStart(utils.PeriodWeek, time.Now()).Format("2006-01-02 15:04")
+	if err == nil || !strings.Contains(err.Error(), nextWeek) {
+		t.Fatalf("Expected the reward to be available again at %s, got %v", nextWeek, err)
+	}
+	if r.RSystem.UserPoints != 80 {
+		t.Errorf("Expected 80 points after three purchases, got %d", r.RSystem.UserPoints)
+	}
+}
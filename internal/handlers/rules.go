@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+// Rules are the settings of the config which the reward system follows. The
+// zero value gives no bonuses and no penalties.
+type Rules struct {
+	// StreakMinTasks completed tasks make a day of the streak, at least one.
+	StreakMinTasks int
+	// StreakBonuses are the bonus points by the days of the streak.
+	StreakBonuses map[int]int
+
+	// OverduePenalty is lost for a task which passed its due date
+	// uncompleted, DeletePenalty for a task deleted without completion.
+	OverduePenalty int
+	DeletePenalty  int
+	// AllowDebt lets a penalty take the balance below zero.
+	AllowDebt bool
+
+	// Users are the configured users: the one set in the config and those
+	// sharing the list with them.
+	Users []string
+	// Approvers are the users who approve requests.
+	Approvers []string
+	// TransfersAbove is the number of points above which a transfer needs
+	// approval, 0 for none.
+	TransfersAbove int
+}
+
+// NewRules takes the rules from the config.
+func NewRules(cfg *config.Config) Rules {
+	rules := Rules{
+		StreakMinTasks: cfg.Streak.MinTasks,
+		StreakBonuses:  cfg.StreakBonuses(),
+		OverduePenalty: max(cfg.Penalties.Overdue, 0),
+		DeletePenalty:  max(cfg.Penalties.Delete, 0),
+		AllowDebt:      cfg.Penalties.AllowDebt,
+		TransfersAbove: max(cfg.Approval.TransfersAbove, 0),
+	}
+	for _, user := range append([]string{cfg.User}, cfg.Users...) {
+		if user = utils.NormalizeUser(user); user != "" {
+			rules.Users = append(rules.Users, user)
+		}
+	}
+	for _, approver := range cfg.Approval.Approvers {
+		if approver = utils.NormalizeUser(approver); approver != "" {
+			rules.Approvers = append(rules.Approvers, approver)
+		}
+	}
+	return rules
+}
+
+// streakMinTasks is StreakMinTasks, a day never counts without tasks.
+func (rules Rules) streakMinTasks() int {
+	return max(rules.StreakMinTasks, 1)
+}
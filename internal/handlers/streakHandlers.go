@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+const dayLayout = "2006-01-02"
+
+// countCompletion adds delta completed tasks to the day of at. A day which
+// makes the streak reach a milestone gives its bonus once per streak.
+func (r *RewardHandler) countCompletion(at time.Time, delta int) {
+	if r.RSystem.Streak == nil {
+		r.RSystem.Streak = &models.Streak{}
+	}
+	streak := r.RSystem.Streak
+	if streak.Days == nil {
+		streak.Days = map[string]int{}
+	}
+
+	day := at.Format(dayLayout)
+	streak.Days[day] += delta
+	if streak.Days[day] <= 0 {
+		delete(streak.Days, day)
+	}
+	if delta <= 0 || streak.Days[day] != r.Rules.streakMinTasks() {
+		return
+	}
+
+	start, days := r.streakEndingAt(at)
+	bonus, ok := r.Rules.StreakBonuses[days]
+	awarded := fmt.Sprintf("%s/%d", start.Format(dayLayout), days)
+	if !ok || bonus <= 0 || slices.Contains(streak.Awarded, awarded) {
+		return
+	}
+	streak.Awarded = append(streak.Awarded, awarded)
+	r.UpdateUserPoints(bonus, fmt.Sprintf("streak bonus: %d days", days))
+	r.gainXP(bonus, at)
+	r.Notices = append(r.Notices, fmt.Sprintf("Streak of %d days! Bonus: %d points", days, bonus))
+}
+
+// dayCounts reports whether enough tasks were completed on the day of t.
+func (r *RewardHandler) dayCounts(t time.Time) bool {
+	if r.RSystem.Streak == nil {
+		return false
+	}
+	return r.RSystem.Streak.Days[t.Format(dayLayout)] >= r.Rules.streakMinTasks()
+}
+
+// streakEndingAt returns the first day and the length of the streak which
+// ends on the day of t.
+func (r *RewardHandler) streakEndingAt(t time.Time) (time.Time, int) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	days := 0
+	for r.dayCounts(day) {
+		days++
+		day = day.AddDate(0, 0, -1)
+	}
+	return day.AddDate(0, 0, 1), days
+}
+
+// StreakStats returns the current streak and the best one. The current
+// streak still lasts when today is not done yet but yesterday was.
+func (r *RewardHandler) StreakStats(now time.Time) (int, int) {
+	_, current := r.streakEndingAt(now)
+	if current == 0 {
+		_, current = r.streakEndingAt(now.AddDate(0, 0, -1))
+	}
+
+	best := 0
+	if r.RSystem.Streak != nil {
+		for date := range r.RSystem.Streak.Days {
+			day, err := time.ParseInLocation(dayLayout, date, now.Location())
+			if err != nil || r.dayCounts(day.AddDate(0, 0, 1)) {
+				continue
+			}
+			_, days := r.streakEndingAt(day)
+			best = max(best, days)
+		}
+	}
+	return current, best
+}
+
+// ShowStreak writes the current and the best streak and a calendar of the
+// recent weeks: # is a day of the streak, + a day with fewer tasks.
+func (r *RewardHandler) ShowStreak(writer io.Writer, now time.Time, weeks int) {
+	current, best := r.StreakStats(now)
+	fmt.Fprintf(writer, "Current streak: %d days\n", current)
+	fmt.Fprintf(writer, "Best streak: %d days\n", best)
+	fmt.Fprintf(writer, "A day counts with %d completed tasks\n\n", r.Rules.streakMinTasks())
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -((int(today.Weekday())+6)%7)-7*(weeks-1))
+
+	fmt.Fprintln(writer, "            Mo Tu We Th Fr Sa Su")
+	for week := 0; week < weeks; week++ {
+		start := monday.AddDate(0, 0, 7*week)
+		cells := make([]string, 7)
+		for i := range cells {
+			day := start.AddDate(0, 0, i)
+			switch {
+			case day.After(today):
+				cells[i] = "  "
+			case r.dayCounts(day):
+				cells[i] = " #"
+			case r.RSystem.Streak != nil && r.RSystem.Streak.Days[day.Format(dayLayout)] > 0:
+				cells[i] = " +"
+			default:
+				cells[i] = " ."
+			}
+		}
+		fmt.Fprintf(writer, "%s %s\n", start.Format(dayLayout), strings.Join(cells, " "))
+	}
+
+	if next := r.nextMilestone(current); next > 0 {
+		fmt.Fprintf(writer, "\nNext bonus: %d points at %d days\n", r.Rules.StreakBonuses[next], next)
+	}
+}
+
+// nextMilestone returns the nearest days of a bonus after the current
+// streak, or 0.
+func (r *RewardHandler) nextMilestone(current int) int {
+	next := 0
+	for days, bonus := range r.Rules.StreakBonuses {
+		if days > current && bonus > 0 && (next == 0 || days < next) {
+			next = days
+		}
+	}
+	return next
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTaskHandler_CompleteWithPoints_StreakBonus(t *testing.T) {
+	now := time.Now()
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks: []models.Task{{ID: 1, TaskPoints: 10}, {ID: 2, TaskPoints: 10}, {ID: 3, TaskPoints: 10}},
+		},
+	}
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			Streak: &models.Streak{Days: map[string]int{
+				now.AddDate(0, 0, -2).Format(dayLayout): 2,
+				now.AddDate(0, 0, -1).Format(dayLayout): 3,
+			}},
+		},
+		Rules: Rules{StreakMinTasks: 2, StreakBonuses: map[int]int{3: 50}},
+	}
+
+	if _, err := h.CompleteWithPoints(0, r); err != nil {
+		t.Fatalf("CompleteWithPoints() returned an unexpected error: %v", err)
+	}
+	if r.RSystem.UserPoints != 10 || streakNotices(r) != 0 {
+		t.Fatalf("Expected no bonus with one task today, got %d points and %q", r.RSystem.UserPoints, r.Notices)
+	}
+
+	_, _ = h.CompleteWithPoints(1, r)
+	if r.RSystem.UserPoints != 70 || streakNotices(r) != 1 {
+		t.Fatalf("Expected the bonus 50 for 3 days, got %d points and %q", r.RSystem.UserPoints, r.Notices)
+	}
+	if last := r.RSystem.Ledger[len(r.RSystem.Ledger)-1]; last.Points != 50 || !strings.Contains(last.Note, "streak") {
+		t.Errorf("Expected the bonus in the ledger, got %+v", last)
+	}
+
+	// Undoing and completing again must not give the bonus twice.
+	_, _ = h.NotCompletedWithPoints(1, r)
+	_, _ = h.CompleteWithPoints(1, r)
+	_, _ = h.CompleteWithPoints(2, r)
+	if r.RSystem.UserPoints != 80 {
+		t.Errorf("Expected the bonus only once, got %d points", r.RSystem.UserPoints)
+	}
+
+	current, best := r.StreakStats(now)
+	if current != 3 || best != 3 {
+		t.Errorf("Expected current and best streak 3, got %d and %d", current, best)
+	}
+}
+
+// streakNotices counts the notices about streak bonuses, achievements may
+// be told as well.
+func streakNotices(r *RewardHandler) int {
+	count := 0
+	for _, notice := range r.Notices {
+		if strings.HasPrefix(notice, "Streak of") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestRewardHandler_ShowStreak(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 12, 0, 0, 0, time.Local)
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			Streak: &models.Streak{Days: map[string]int{
+				"2025-01-02": 1, "2025-01-03": 1, "2025-01-04": 1, "2025-01-05": 1,
+				"2025-01-13": 1, "2025-01-14": 1,
+			}},
+		},
+		Rules: Rules{StreakMinTasks: 1, StreakBonuses: map[int]int{7: 100}},
+	}
+
+	var out bytes.Buffer
+	r.ShowStreak(&out, now, 2)
+	for _, want := range []string{
+		"Current streak: 2 days",
+		"Best streak: 4 days",
+		"2025-01-06  .  .  .  .  .  .  .",
+		"2025-01-13  #  #  .",
+		"Next bonus: 100 points at 7 days",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the output:\n%s", want, out.String())
+		}
+	}
+}
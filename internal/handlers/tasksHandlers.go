@@ -3,25 +3,33 @@ package handlers
 import (
 	"fmt"
 	"io"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/utils"
 )
 
 type TaskHandler struct {
 	Todo *models.TodoList `json:"Todolist"`
 }
 
+// Add appends a new task. Display IDs are never reused, the task gets the
+// next one and a UUID that stays with it for good.
 func (h *TaskHandler) Add(text string, points int) {
-	if len(h.Todo.Tasks) == 0 {
+	if h.Todo.NextID < 1 {
 		h.Todo.NextID = 1
 	}
+	now := time.Now()
 	task := models.Task{
 		ID:                  h.Todo.NextID,
+		UID:                 utils.NewUUID(),
 		Text:                text,
 		IsComplete:          false,
 		TaskPoints:          points,
 		IsTaskPointsReceive: false,
+		CreatedAt:           &now,
 	}
 	h.Todo.Tasks = append(h.Todo.Tasks, task)
 	h.Todo.NextID++
@@ -33,28 +41,52 @@ func (h *TaskHandler) List(pointsFlag bool, writer io.Writer) {
 		return
 	}
 
+	// The assignees are shown only when the list is shared.
+	assigned := false
+	for _, task := range h.Todo.Tasks {
+		assigned = assigned || task.Assignee != ""
+	}
+
 	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
 
-	if !pointsFlag {
-		fmt.Fprintf(w, "Done\tID\tTask\n")
-		for _, task := range h.Todo.Tasks {
-			status := " "
-			if task.IsComplete {
-				status = "✓"
-			}
-			fmt.Fprintf(w, "[%s]\t%d.\t%s\n", status, task.ID, task.Text)
+	header := "Done\tID\tTask"
+	if pointsFlag {
+		header += "\tPoints for task"
+	}
+	if assigned {
+		header += "\tAssignee"
+	}
+	fmt.Fprintln(w, header)
+	for _, task := range h.Todo.Tasks {
+		status := " "
+		if task.IsComplete {
+			status = "✓"
+		}
+		row := fmt.Sprintf("[%s]\t%d.\t%s", status, task.ID, task.Text)
+		if pointsFlag {
+			row += fmt.Sprintf("\t%d", task.TaskPoints)
 		}
-	} else {
-		fmt.Fprintf(w, "Done\tID\tTask\tPoints for task\n")
-		for _, task := range h.Todo.Tasks {
-			status := " "
-			if task.IsComplete {
-				status = "✓"
-			}
-			fmt.Fprintf(w, "[%s]\t%d.\t%s\t%d\n", status, task.ID, task.Text, task.TaskPoints)
+		if assigned {
+			row += "\t" + task.Assignee
 		}
+		fmt.Fprintln(w, row)
+	}
+
+	w.Flush()
+}
+
+// ListUIDs shows the permanent UUIDs of the tasks next to the display IDs.
+func (h *TaskHandler) ListUIDs(writer io.Writer) {
+	if len(h.Todo.Tasks) == 0 {
+		fmt.Fprintln(writer, "No tasks, well done!")
+		return
 	}
 
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tUUID\tTask\n")
+	for _, task := range h.Todo.Tasks {
+		fmt.Fprintf(w, "%d.\t%s\t%s\n", task.ID, task.UID, task.Text)
+	}
 	w.Flush()
 }
 
@@ -62,7 +94,9 @@ func (h *TaskHandler) Complete(indexCompElem int) error {
 	if h.Todo.Tasks[indexCompElem].IsComplete {
 		return fmt.Errorf("the task has already been completed")
 	}
+	now := time.Now()
 	h.Todo.Tasks[indexCompElem].IsComplete = true
+	h.Todo.Tasks[indexCompElem].CompletedAt = &now
 	return nil
 }
 
@@ -71,9 +105,67 @@ func (h *TaskHandler) NotCompleted(indexNotCompElem int) error {
 		return fmt.Errorf("the task has not been completed yet")
 	}
 	h.Todo.Tasks[indexNotCompElem].IsComplete = false
+	h.Todo.Tasks[indexNotCompElem].CompletedAt = nil
 	return nil
 }
 
+// CompleteWithPoints marks the task as completed and adds its points to the
+// balance of the assignee, or of the active user for a task nobody is
+// assigned to, unless they were received before. The task counts for the
+// streak of the day. It returns the added points.
+func (h *TaskHandler) CompleteWithPoints(indexCompElem int, r *RewardHandler) (int, error) {
+	if err := r.CheckUser(h.Todo.Tasks[indexCompElem].Assignee); err != nil {
+		return 0, err
+	}
+	if err := h.Complete(indexCompElem); err != nil {
+		return 0, err
+	}
+
+	task := &h.Todo.Tasks[indexCompElem]
+	received := 0
+	err := r.As(task.Assignee, func() {
+		if !task.IsTaskPointsReceive {
+			r.UpdateUserPoints(task.TaskPoints, "completed: "+task.Text)
+			r.gainXP(task.TaskPoints, *task.CompletedAt)
+			task.IsTaskPointsReceive = true
+			received = task.TaskPoints
+		}
+		r.countCompletion(*task.CompletedAt, 1)
+		r.checkAchievements(time.Now())
+	})
+	return received, err
+}
+
+// NotCompletedWithPoints marks the task as not completed and takes back the
+// points received for it. It returns the taken points.
+func (h *TaskHandler) NotCompletedWithPoints(indexNotCompElem int, r *RewardHandler) (int, error) {
+	if err := r.CheckUser(h.Todo.Tasks[indexNotCompElem].Assignee); err != nil {
+		return 0, err
+	}
+	completedAt := h.Todo.Tasks[indexNotCompElem].CompletedAt
+	if err := h.NotCompleted(indexNotCompElem); err != nil {
+		return 0, err
+	}
+
+	task := &h.Todo.Tasks[indexNotCompElem]
+	taken := 0
+	err := r.As(task.Assignee, func() {
+		if completedAt != nil {
+			r.countCompletion(*completedAt, -1)
+		}
+		if !task.IsTaskPointsReceive {
+			return
+		}
+		r.UpdateUserPoints(-task.TaskPoints, "not completed: "+task.Text)
+		if completedAt != nil {
+			r.gainXP(-task.TaskPoints, *completedAt)
+		}
+		task.IsTaskPointsReceive = false
+		taken = task.TaskPoints
+	})
+	return taken, err
+}
+
 func (h *TaskHandler) Edit(indexEditElem int, newText string) {
 	h.Todo.Tasks[indexEditElem].Text = newText
 }
@@ -84,7 +176,6 @@ func (h *TaskHandler) EditTaskPoints(indexPointsElem int, newTaskPoints int) {
 
 func (h *TaskHandler) ClearAllTasks() {
 	h.Todo.Tasks = []models.Task{}
-	h.Todo.NextID = 1
 }
 
 func (h *TaskHandler) Delete(indexDelElem int) {
@@ -97,13 +188,115 @@ func (h *TaskHandler) CancelLastDelete() error {
 		return fmt.Errorf("no deleted tasks")
 	}
 
+	// The task keeps its ID, unless a list from an older version gave it
+	// to another task.
 	last := h.Todo.DeletedTasks[len(h.Todo.DeletedTasks)-1]
-	last.ID = h.Todo.NextID
-	h.Todo.NextID++
+	if _, err := utils.CheckExistItem(last.ID, h.Todo.Tasks); err == nil || last.ID < 1 {
+		last.ID = h.Todo.NextID
+		h.Todo.NextID++
+	}
+	if last.UID == "" {
+		last.UID = utils.NewUUID()
+	}
 	last.IsComplete = false
+	last.CompletedAt = nil
 
 	h.Todo.Tasks = append(h.Todo.Tasks, last)
 	h.Todo.DeletedTasks = []models.Task{}
 
 	return nil
 }
+
+// Take removes the task from the list without keeping it for cancel-delete,
+// it is used when the task moves to another list.
+func (h *TaskHandler) Take(indexTakeElem int) models.Task {
+	task := h.Todo.Tasks[indexTakeElem]
+	h.Todo.Tasks = append(h.Todo.Tasks[:indexTakeElem], h.Todo.Tasks[indexTakeElem+1:]...)
+	return task
+}
+
+// Put appends a task taken from another list or from an imported file under
+// a new ID of this list. The UUID moves with the task.
+func (h *TaskHandler) Put(task models.Task) int {
+	if h.Todo.NextID < 1 {
+		h.Todo.NextID = 1
+	}
+	if task.UID == "" {
+		task.UID = utils.NewUUID()
+	}
+	task.ID = h.Todo.NextID
+	h.Todo.Tasks = append(h.Todo.Tasks, task)
+	h.Todo.NextID++
+	return task.ID
+}
+
+// IndexByUID returns the index of the task with the UID it got from another
+// app, or -1 if there is none.
+func (h *TaskHandler) IndexByUID(uid string) int {
+	if uid == "" {
+		return -1
+	}
+	for i, task := range h.Todo.Tasks {
+		if task.UID == uid {
+			return i
+		}
+	}
+	return -1
+}
+
+// Replace overwrites the task with a newer version of it from another app.
+// The fields the other app does not know stay as they are: the ID, the
+// received points, the assignee and the charged penalty, unless the due
+// date changed. A task completed or reopened in the other app is completed
+// or reopened with r, so the balance and the streak follow.
+func (h *TaskHandler) Replace(indexReplaceElem int, task models.Task, r *RewardHandler) error {
+	old := h.Todo.Tasks[indexReplaceElem]
+	complete := task.IsComplete
+	task.ID = old.ID
+	task.IsComplete = old.IsComplete
+	task.CompletedAt = old.CompletedAt
+	task.IsTaskPointsReceive = old.IsTaskPointsReceive
+	task.Assignee = old.Assignee
+	task.Penalized = old.Penalized && sameDue(old.Due, task.Due)
+	h.Todo.Tasks[indexReplaceElem] = task
+
+	var err error
+	switch {
+	case complete && !old.IsComplete:
+		_, err = h.CompleteWithPoints(indexReplaceElem, r)
+	case !complete && old.IsComplete:
+		_, err = h.NotCompletedWithPoints(indexReplaceElem, r)
+	}
+	return err
+}
+
+// SetDue sets the due date of the task, nil removes it. The penalty for a
+// passed due date is charged again when the new one passes.
+func (h *TaskHandler) SetDue(indexElem int, due *time.Time) {
+	task := &h.Todo.Tasks[indexElem]
+	if !sameDue(task.Due, due) {
+		task.Penalized = false
+	}
+	task.Due = due
+}
+
+// sameDue reports whether both due dates are missing or fall on the same
+// day.
+func sameDue(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format(dayLayout) == b.Format(dayLayout)
+}
+
+// HasTask reports whether the list already has a task with the same text,
+// ignoring case and surrounding spaces.
+func (h *TaskHandler) HasTask(text string) bool {
+	text = strings.TrimSpace(text)
+	for _, task := range h.Todo.Tasks {
+		if strings.EqualFold(strings.TrimSpace(task.Text), text) {
+			return true
+		}
+	}
+	return false
+}
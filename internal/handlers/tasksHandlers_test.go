@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"testing"
+	"time"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
 )
@@ -39,6 +40,9 @@ func TestTaskHandler_Add(t *testing.T) {
 	if handler.Todo.NextID != 2 {
 		t.Errorf("Expected, what NextID becoming 2, but he equal %d", handler.Todo.NextID)
 	}
+	if len(addedTask.UID) != 36 {
+		t.Errorf("Expected the new task to get a UUID, got %q", addedTask.UID)
+	}
 }
 
 func TestTaskHandler_Complete_SuccessfullyCompletedTask(t *testing.T) {
@@ -186,13 +190,13 @@ func TestTaskHandler_ClearAllTasks_ListCleared(t *testing.T) {
 	if len(handler.Todo.Tasks) != 0 {
 		t.Errorf("Expected an empty task list, but it has %d elements", len(handler.Todo.Tasks))
 	}
-	if handler.Todo.NextID != 1 {
-		t.Errorf("Expected NextID to be reset to 1, but it is %d", handler.Todo.NextID)
+	if handler.Todo.NextID != 3 {
+		t.Errorf("Expected NextID to stay 3, IDs are never reused, but it is %d", handler.Todo.NextID)
 	}
 }
 
 func TestTaskHandler_CancelLastDelete_SuccessfullyRestoresTask(t *testing.T) {
-	deletedTask := models.Task{ID: 2, Text: "Deleted Task", IsComplete: true}
+	deletedTask := models.Task{ID: 2, Text: "Deleted Task", IsComplete: true, UID: "uid-2"}
 	handler := &TaskHandler{
 		Todo: &models.TodoList{
 			Tasks:        []models.Task{{ID: 1, Text: "Existing Task"}},
@@ -217,14 +221,14 @@ func TestTaskHandler_CancelLastDelete_SuccessfullyRestoresTask(t *testing.T) {
 	if restoredTask.Text != deletedTask.Text {
 		t.Error("The text of the restored task does not match the deleted one")
 	}
-	if restoredTask.ID != 3 {
-		t.Errorf("Expected the restored task to have a new ID of 3, but got %d", restoredTask.ID)
+	if restoredTask.ID != 2 || restoredTask.UID != "uid-2" {
+		t.Errorf("Expected the restored task to keep ID 2 and its UID, but got %d and %q", restoredTask.ID, restoredTask.UID)
 	}
 	if restoredTask.IsComplete {
 		t.Error("Expected the restored task to be marked as not complete, but it was")
 	}
-	if handler.Todo.NextID != 4 {
-		t.Errorf("Expected NextID to be incremented to 4, but it is %d", handler.Todo.NextID)
+	if handler.Todo.NextID != 3 {
+		t.Errorf("Expected NextID to stay 3, but it is %d", handler.Todo.NextID)
 	}
 }
 
@@ -242,3 +246,184 @@ func TestTaskHandler_CancelLastDelete_WhenNothingToDelete(t *testing.T) {
 		t.Fatal("Expected an error when canceling with no deleted tasks, but got nil")
 	}
 }
+
+func TestTaskHandler_TakeAndPut_MovesTaskBetweenLists(t *testing.T) {
+	source := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks: []models.Task{
+				{ID: 1, Text: "Stay"},
+				{ID: 2, Text: "Move", TaskPoints: 30, IsComplete: true},
+			},
+			NextID: 3,
+		},
+	}
+	target := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 1, Text: "Already there"}},
+			NextID: 2,
+		},
+	}
+
+	newID := target.Put(source.Take(1))
+
+	if len(source.Todo.Tasks) != 1 || source.Todo.Tasks[0].Text != "Stay" {
+		t.Errorf("Expected only 'Stay' to remain in the source list, got %+v", source.Todo.Tasks)
+	}
+	if len(source.Todo.DeletedTasks) != 0 {
+		t.Error("A moved task should not be kept for cancel-delete")
+	}
+	if newID != 2 {
+		t.Errorf("Expected the moved task to get ID 2, got %d", newID)
+	}
+
+	moved := target.Todo.Tasks[1]
+	if moved.Text != "Move" || moved.TaskPoints != 30 || !moved.IsComplete {
+		t.Errorf("Moved task lost its fields: %+v", moved)
+	}
+	if target.Todo.NextID != 3 {
+		t.Errorf("Expected NextID of the target to be 3, got %d", target.Todo.NextID)
+	}
+}
+
+func TestTaskHandler_HasTask_IgnoresCaseAndSpaces(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 1, Text: "Buy milk"}},
+			NextID: 2,
+		},
+	}
+
+	if !h.HasTask("  buy MILK ") {
+		t.Error("Expected the task to be found")
+	}
+	if h.HasTask("Buy bread") {
+		t.Error("Expected no task with another text")
+	}
+}
+
+func TestTaskHandler_Replace_KeepsIDAndReceivedPoints(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 4, Text: "Old text", UID: "abc", IsComplete: true, IsTaskPointsReceive: true}},
+			NextID: 5,
+		},
+	}
+
+	i := h.IndexByUID("abc")
+	if i != 0 {
+		t.Fatalf("Expected task with UID to be found at 0, got %d", i)
+	}
+	if h.IndexByUID("") != -1 {
+		t.Error("Tasks without UID should never match")
+	}
+
+	if err := h.Replace(i, models.Task{Text: "New text", UID: "abc", IsComplete: true}, &RewardHandler{RSystem: &models.RewardSystem{}}); err != nil {
+		t.Fatalf("Replace() returned an unexpected error: %v", err)
+	}
+
+	task := h.Todo.Tasks[0]
+	if task.ID != 4 || task.Text != "New text" || !task.IsTaskPointsReceive {
+		t.Errorf("Unexpected task after replace: %+v", task)
+	}
+}
+
+func TestTaskHandler_Replace_TwiceKeepsAssigneeAndPenalty(t *testing.T) {
+	now := time.Now()
+	due := now.AddDate(0, 0, -3)
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 1, Text: "Taxes", UID: "abc", Due: &due, Assignee: "bob"}},
+			NextID: 2,
+		},
+	}
+	r := &RewardHandler{RSystem: &models.RewardSystem{User: "alice"}, Rules: Rules{Users: []string{"bob"}, OverduePenalty: 10, AllowDebt: true}}
+
+	if tasks, _ := h.PenalizeOverdue(r, now); tasks != 1 {
+		t.Fatalf("Expected the overdue task to be charged, got %d", tasks)
+	}
+	// The other app knows neither the assignee nor the penalty.
+	for range 2 {
+		imported := models.Task{Text: "Taxes", UID: "abc", Due: &due}
+		h.Replace(h.IndexByUID("abc"), imported, r)
+		if tasks, _ := h.PenalizeOverdue(r, now); tasks != 0 {
+			t.Fatalf("Expected no second penalty after the import, got %d", tasks)
+		}
+	}
+	if task := h.Todo.Tasks[0]; task.Assignee != "bob" || !task.Penalized {
+		t.Errorf("Expected the assignee and the penalty to stay, got %+v", task)
+	}
+
+	later := now.AddDate(0, 0, 7)
+	h.Replace(0, models.Task{Text: "Taxes", UID: "abc", Due: &later}, r)
+	if h.Todo.Tasks[0].Penalized {
+		t.Error("Expected a new due date to be charged again when it passes")
+	}
+}
+
+func TestTaskHandler_Replace_RoundTripKeepsTheBalance(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 1, Text: "Run", UID: "abc", TaskPoints: 40}},
+			NextID: 2,
+		},
+	}
+	r := &RewardHandler{RSystem: &models.RewardSystem{}}
+	completedToday := func() int {
+		if r.RSystem.Streak == nil {
+			return 0
+		}
+		return r.RSystem.Streak.Days[time.Now().Format(dayLayout)]
+	}
+	if _, err := h.CompleteWithPoints(0, r); err != nil {
+		t.Fatalf("CompleteWithPoints() returned an unexpected error: %v", err)
+	}
+
+	// Reopened in the other app and imported: the points go back.
+	reopened := h.Todo.Tasks[0]
+	reopened.IsComplete, reopened.CompletedAt = false, nil
+	if err := h.Replace(0, reopened, r); err != nil {
+		t.Fatalf("Replace() returned an unexpected error: %v", err)
+	}
+	if h.Todo.Tasks[0].IsComplete || r.RSystem.UserPoints != 0 || completedToday() != 0 {
+		t.Fatalf("Expected the task reopened with its points and streak taken back, got %+v, %d points", h.Todo.Tasks[0], r.RSystem.UserPoints)
+	}
+
+	// Completing it again here pays again, reopening takes it back once.
+	if points, err := h.CompleteWithPoints(0, r); err != nil || points != 40 || r.RSystem.UserPoints != 40 {
+		t.Fatalf("Expected 40 points for completing it again, got %d (%v) and balance %d", points, err, r.RSystem.UserPoints)
+	}
+	if err := h.Replace(0, h.Todo.Tasks[0], r); err != nil || r.RSystem.UserPoints != 40 || completedToday() != 1 {
+		t.Errorf("Expected an unchanged import to keep 40 points and one completion, got %d (%v)", r.RSystem.UserPoints, err)
+	}
+	if _, err := h.NotCompletedWithPoints(0, r); err != nil || r.RSystem.UserPoints != 0 {
+		t.Errorf("Expected the balance back at 0, got %d (%v)", r.RSystem.UserPoints, err)
+	}
+}
+
+func TestTaskHandler_CompleteWithPoints_GivesPointsOnce(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks:  []models.Task{{ID: 1, Text: "Run", TaskPoints: 15}},
+			NextID: 2,
+		},
+	}
+	r := &RewardHandler{RSystem: &models.RewardSystem{UserPoints: 5}}
+
+	if points, err := h.CompleteWithPoints(0, r); err != nil || points != 15 {
+		t.Fatalf("Expected 15 points, got %d (%v)", points, err)
+	}
+	if r.RSystem.UserPoints != 20 {
+		t.Errorf("Expected balance 20, got %d", r.RSystem.UserPoints)
+	}
+
+	if points, err := h.NotCompletedWithPoints(0, r); err != nil || points != 15 {
+		t.Fatalf("Expected 15 points to be taken back, got %d (%v)", points, err)
+	}
+	if r.RSystem.UserPoints != 5 || h.Todo.Tasks[0].IsTaskPointsReceive {
+		t.Errorf("Expected balance 5 and no received points, got %d", r.RSystem.UserPoints)
+	}
+
+	if _, err := h.NotCompletedWithPoints(0, r); err == nil {
+		t.Error("Expected error for a task that is not completed")
+	}
+}
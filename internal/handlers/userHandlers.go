@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+// CheckUser returns an error unless user has an account or is configured,
+// so a typo in a name does not open an account. An empty user is the active
+// one.
+func (r *RewardHandler) CheckUser(user string) error {
+	user = utils.NormalizeUser(user)
+	if user == "" || user == r.RSystem.User {
+		return nil
+	}
+	if _, ok := r.RSystem.Accounts[user]; ok {
+		return nil
+	}
+	if slices.Contains(r.Rules.Users, user) || slices.Contains(r.Rules.Approvers, user) {
+		return nil
+	}
+
+	var known []string
+	for _, name := range utils.UserNames(r.RSystem) {
+		if name != "" {
+			known = append(known, name)
+		}
+	}
+	for _, name := range append(r.Rules.Users, r.Rules.Approvers...) {
+		if !slices.Contains(known, name) {
+			known = append(known, name)
+		}
+	}
+	sort.Strings(known)
+	if len(known) == 0 {
+		return fmt.Errorf("unknown user %q, add the users with 'todo config set users <name>,...'", user)
+	}
+	return fmt.Errorf("unknown user %q, known users are %s; add others with 'todo config set users <name>,...'", user, strings.Join(known, ", "))
+}
+
+// As runs fn with the account of user active and then switches back. An
+// empty user is the active one. An unknown user is an error and fn does not
+// run.
+func (r *RewardHandler) As(user string, fn func()) error {
+	if err := r.CheckUser(user); err != nil {
+		return err
+	}
+	user = utils.NormalizeUser(user)
+	active := r.RSystem.User
+	if user == "" || user == active {
+		fn()
+		return nil
+	}
+
+	utils.SwitchAccount(r.RSystem, user)
+	fn()
+	utils.SwitchAccount(r.RSystem, active)
+	r.UpdateIsAvailableRewards()
+	return nil
+}
+
+// Assign gives the task to user, an empty user takes it from anybody.
+func (h *TaskHandler) Assign(indexElem int, user string) {
+	h.Todo.Tasks[indexElem].Assignee = utils.NormalizeUser(user)
+}
+
+// Mine returns the indexes of the tasks assigned to user.
+func (h *TaskHandler) Mine(user string) []int {
+	user = utils.NormalizeUser(user)
+	var mine []int
+	for i, task := range h.Todo.Tasks {
+		if task.Assignee != "" && task.Assignee == user {
+			mine = append(mine, i)
+		}
+	}
+	return mine
+}
+
+// leader is a row of the leaderboard.
+type leader struct {
+	name      string
+	xp        int
+	points    int
+	completed int
+}
+
+// ShowLeaderboard writes the users by XP, the points they have now and the
+// tasks they completed.
+func (r *RewardHandler) ShowLeaderboard(writer io.Writer) {
+	var leaders []leader
+	for name, account := range utils.Accounts(r.RSystem) {
+		row := leader{name: name, points: account.UserPoints}
+		if account.Profile != nil {
+			row.xp = account.Profile.XP
+		}
+		if account.Streak != nil {
+			for _, count := range account.Streak.Days {
+				row.completed += count
+			}
+		}
+		leaders = append(leaders, row)
+	}
+	sort.Slice(leaders, func(i, j int) bool {
+		if leaders[i].xp != leaders[j].xp {
+			return leaders[i].xp > leaders[j].xp
+		}
+		return leaders[i].name < leaders[j].name
+	})
+
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "#\tUser\tXP\tPoints\tCompleted\n")
+	for i, row := range leaders {
+		name := row.name
+		if name == "" {
+			name = "(no user)"
+		}
+		if row.name == r.RSystem.User {
+			name += " (you)"
+		}
+		fmt.Fprintf(w, "%d.\t%s\t%d\t%d\t%d\n", i+1, name, row.xp, row.points, row.completed)
+	}
+	w.Flush()
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestTaskHandler_CompleteCreditsAssignee(t *testing.T) {
+	h := &TaskHandler{
+		Todo: &models.TodoList{
+			Tasks: []models.Task{{ID: 1, Text: "dishes", TaskPoints: 30}, {ID: 2, Text: "trash", TaskPoints: 10}},
+		},
+	}
+	h.Assign(0, "Bob")
+	r := &RewardHandler{RSystem: &models.RewardSystem{User: "alice", UserPoints: 5}, Rules: Rules{Users: []string{"alice", "bob"}}}
+
+	if _, err := h.CompleteWithPoints(0, r); err != nil {
+		t.Fatalf("CompleteWithPoints() returned an unexpected error: %v", err)
+	}
+	_, _ = h.CompleteWithPoints(1, r)
+	if r.RSystem.User != "alice" || r.RSystem.UserPoints != 15 {
+		t.Fatalf("Expected alice to stay active with 15 points, got %s with %d", r.RSystem.User, r.RSystem.UserPoints)
+	}
+	if bob := r.RSystem.Accounts["bob"]; bob == nil || bob.UserPoints != 30 || bob.Profile.XP != 30 {
+		t.Fatalf("Expected bob to receive 30 points and XP, got %+v", bob)
+	}
+
+	_, _ = h.NotCompletedWithPoints(0, r)
+	if bob := r.RSystem.Accounts["bob"]; bob.UserPoints != 0 {
+		t.Errorf("Expected the points to be taken back from bob, got %d", bob.UserPoints)
+	}
+
+	if mine := h.Mine("BOB"); len(mine) != 1 || mine[0] != 0 {
+		t.Errorf("Expected the first task to be bob's, got %v", mine)
+	}
+}
+
+func TestTaskHandler_CompleteRefusesUnknownAssignee(t *testing.T) {
+	h := &TaskHandler{Todo: &models.TodoList{Tasks: []models.Task{{ID: 1, Text: "dishes", TaskPoints: 30, Assignee: "bbo"}}}}
+	r := &RewardHandler{RSystem: &models.RewardSystem{User: "alice"}, Rules: Rules{Users: []string{"bob"}}}
+
+	if _, err := h.CompleteWithPoints(0, r); err == nil || !strings.Contains(err.Error(), "known users are alice, bob") {
+		t.Fatalf("Expected error for an unknown assignee, got %v", err)
+	}
+	if h.Todo.Tasks[0].IsComplete || r.RSystem.Accounts["bbo"] != nil {
+		t.Errorf("Expected neither the task completed nor an account opened, got %+v and %v", h.Todo.Tasks[0], r.RSystem.Accounts)
+	}
+}
+
+func TestRewardHandler_ShowLeaderboard(t *testing.T) {
+	r := &RewardHandler{
+		RSystem: &models.RewardSystem{
+			User:       "alice",
+			UserPoints: 20,
+			Profile:    &models.Profile{XP: 80},
+			Accounts: map[string]*models.Account{
+				"bob": {UserPoints: 5, Profile: &models.Profile{XP: 120}, Streak: &models.Streak{Days: map[string]int{"2025-01-01": 3}}},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	r.ShowLeaderboard(&out)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "1.  bob") || !strings.Contains(lines[2], "alice (you)") {
+		t.Errorf("Expected bob first and alice second:\n%s", out.String())
+	}
+}
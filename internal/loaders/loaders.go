@@ -5,22 +5,141 @@ import (
 
 	"github.com/svetsed/todo_cli_app/internal/models"
 	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
 )
 
+// LoadTodoList loads the todo list. A list of an older version gets UUIDs
+// and a NextID past all IDs once, the change is saved right away.
 func LoadTodoList(filePath string) (*models.TodoList, error) {
+	todoList, err := loadTodoList(storage.Load, filePath)
+	if err != nil || !needsTaskIdentifiers(todoList) {
+		return todoList, err
+	}
+
+	err = storage.Update([]string{filePath}, func(tx *storage.Tx) error {
+		todoList, err = LoadTodoListTx(tx, filePath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return todoList, nil
+}
+
+// LoadTodoListTx loads the todo list from a file locked by storage.Update.
+func LoadTodoListTx(tx *storage.Tx, filePath string) (*models.TodoList, error) {
+	todoList, err := loadTodoList(tx.Load, filePath)
+	if err != nil || !needsTaskIdentifiers(todoList) {
+		return todoList, err
+	}
+
+	setTaskIdentifiers(todoList)
+	if err := tx.Save(filePath, todoList); err != nil {
+		return nil, fmt.Errorf("failed to save todo list to %s: %w", filePath, err)
+	}
+	return todoList, nil
+}
+
+// LoadRewardSystem loads the reward system with the account of user active,
+// giving rewards of an older version UUIDs like LoadTodoList.
+func LoadRewardSystem(filePath, user string) (*models.RewardSystem, error) {
+	rewardSystem, err := loadRewardSystem(storage.Load, filePath, user)
+	if err != nil || !needsRewardIdentifiers(rewardSystem) {
+		return rewardSystem, err
+	}
+
+	err = storage.Update([]string{filePath}, func(tx *storage.Tx) error {
+		rewardSystem, err = LoadRewardSystemTx(tx, filePath, user)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rewardSystem, nil
+}
+
+// LoadRewardSystemTx loads the reward system from a file locked by
+// storage.Update.
+func LoadRewardSystemTx(tx *storage.Tx, filePath, user string) (*models.RewardSystem, error) {
+	rewardSystem, err := loadRewardSystem(tx.Load, filePath, user)
+	if err != nil || !needsRewardIdentifiers(rewardSystem) {
+		return rewardSystem, err
+	}
+
+	setRewardIdentifiers(rewardSystem)
+	if err := tx.Save(filePath, rewardSystem); err != nil {
+		return nil, fmt.Errorf("failed to save reward system to %s: %w", filePath, err)
+	}
+	return rewardSystem, nil
+}
+
+func loadTodoList(load func(string, any) error, filePath string) (*models.TodoList, error) {
 	var todoList models.TodoList
-	if err := storage.Load(filePath, &todoList); err != nil {
+	if err := load(filePath, &todoList); err != nil {
 		return nil, fmt.Errorf("failed to load todo list from %s: %w", filePath, err)
 	}
 
 	return &todoList, nil
 }
 
-func LoadRewardSystem(filePath string) (*models.RewardSystem, error) {
+func loadRewardSystem(load func(string, any) error, filePath, user string) (*models.RewardSystem, error) {
 	var rewardSystem models.RewardSystem
-	if err := storage.Load(filePath, &rewardSystem); err != nil {
+	if err := load(filePath, &rewardSystem); err != nil {
 		return nil, fmt.Errorf("failed to load reward system from %s: %w", filePath, err)
 	}
 
+	// The balance of a list without users goes to the first user who is
+	// set in the config.
+	user = utils.NormalizeUser(user)
+	if rewardSystem.User == "" && len(rewardSystem.Accounts) == 0 {
+		rewardSystem.User = user
+	}
+	utils.SwitchAccount(&rewardSystem, user)
 	return &rewardSystem, nil
 }
+
+// needsTaskIdentifiers reports whether a task has no UUID or NextID would
+// give out an ID which is taken, both happen to lists of older versions.
+func needsTaskIdentifiers(todoList *models.TodoList) bool {
+	for _, tasks := range [][]models.Task{todoList.Tasks, todoList.DeletedTasks} {
+		for _, task := range tasks {
+			if task.UID == "" || task.ID >= todoList.NextID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func setTaskIdentifiers(todoList *models.TodoList) {
+	for _, tasks := range [][]models.Task{todoList.Tasks, todoList.DeletedTasks} {
+		for i := range tasks {
+			if tasks[i].UID == "" {
+				tasks[i].UID = utils.NewUUID()
+			}
+			if tasks[i].ID >= todoList.NextID {
+				todoList.NextID = tasks[i].ID + 1
+			}
+		}
+	}
+}
+
+func needsRewardIdentifiers(rewardSystem *models.RewardSystem) bool {
+	for _, reward := range rewardSystem.Rewards {
+		if reward.UID == "" || reward.ID >= rewardSystem.NextID {
+			return true
+		}
+	}
+	return false
+}
+
+func setRewardIdentifiers(rewardSystem *models.RewardSystem) {
+	for i := range rewardSystem.Rewards {
+		if rewardSystem.Rewards[i].UID == "" {
+			rewardSystem.Rewards[i].UID = utils.NewUUID()
+		}
+		if rewardSystem.Rewards[i].ID >= rewardSystem.NextID {
+			rewardSystem.NextID = rewardSystem.Rewards[i].ID + 1
+		}
+	}
+}
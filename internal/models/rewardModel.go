@@ -1,15 +1,122 @@
 package models
 
+import "time"
+
 type Reward struct {
 	ID            int    `json:"id"`
 	Description   string `json:"description"`
 	PriceOfReward int    `json:"priceOfReward"`
 	IsAvailable   bool   `json:"isAvailable"`
+	UID           string `json:"uid,omitempty"`
+	// Stock is how many times the reward can still be bought, nil means
+	// without limit.
+	Stock *int `json:"stock,omitempty"`
+	// Restock is the period (week or month) after which Stock is filled up
+	// to RestockTo again.
+	Restock     string     `json:"restock,omitempty"`
+	RestockTo   int        `json:"restockTo,omitempty"`
+	RestockedAt *time.Time `json:"restockedAt,omitempty"`
+	// SingleUse rewards are removed after they are bought.
+	SingleUse bool `json:"singleUse,omitempty"`
+	// Cooldown is the time to wait after a purchase, like "24h" or "2d".
+	Cooldown string `json:"cooldown,omitempty"`
+	// Limit is how many times the reward can be bought per LimitPeriod
+	// (day, week or month), 0 means without limit.
+	Limit       int    `json:"limit,omitempty"`
+	LimitPeriod string `json:"limitPeriod,omitempty"`
+	// Purchases keeps the times of the purchases needed for the cooldown
+	// and the limit.
+	Purchases []time.Time `json:"purchases,omitempty"`
+	// RequiresApproval rewards are asked for: the points are held until an
+	// approver approves or denies the request.
+	RequiresApproval bool `json:"requiresApproval,omitempty"`
 }
 
 type RewardSystem struct {
-	Rewards            []Reward `json:"rewards"`
-	UserPoints         int      `json:"userPoints"`
-	IsUserPointsUpdate bool     `json:"isUserPointsUpdate"`
-	NextID             int      `json:"nextId"`
+	Rewards            []Reward      `json:"rewards"`
+	UserPoints         int           `json:"userPoints"`
+	IsUserPointsUpdate bool          `json:"isUserPointsUpdate"`
+	NextID             int           `json:"nextId"`
+	Goal               *Goal         `json:"goal,omitempty"`
+	Ledger             []LedgerEntry `json:"ledger,omitempty"`
+	Streak             *Streak       `json:"streak,omitempty"`
+	Profile            *Profile      `json:"profile,omitempty"`
+
+	// User owns the balance, the ledger, the goal, the streak, the profile
+	// and the purchases above, the accounts of the other users wait in
+	// Accounts. Empty is the only user of a list without users.
+	User     string              `json:"user,omitempty"`
+	Accounts map[string]*Account `json:"accounts,omitempty"`
+
+	Requests      []Request `json:"requests,omitempty"`
+	NextRequestID int       `json:"nextRequestId,omitempty"`
+}
+
+const (
+	RequestTransfer = "transfer"
+	RequestReward   = "reward"
+)
+
+// Request waits for an approver. Its points are taken from From when it is
+// made and held until it is approved or denied.
+type Request struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	From      string    `json:"from"`
+	To        string    `json:"to,omitempty"`
+	Points    int       `json:"points"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// RewardUID and Reward are the asked reward and its description.
+	RewardUID string `json:"rewardUid,omitempty"`
+	Reward    string `json:"reward,omitempty"`
+}
+
+// Account is what each user has on their own while another user is the
+// active one. Purchases are by the UUIDs of the rewards.
+type Account struct {
+	UserPoints int                    `json:"userPoints"`
+	Ledger     []LedgerEntry          `json:"ledger,omitempty"`
+	Goal       *Goal                  `json:"goal,omitempty"`
+	Streak     *Streak                `json:"streak,omitempty"`
+	Profile    *Profile               `json:"profile,omitempty"`
+	Purchases  map[string][]time.Time `json:"purchases,omitempty"`
+}
+
+// Profile is the progress over all time. XP is earned together with points
+// but never spent, XPByDay keeps it by dates like 2006-01-02.
+type Profile struct {
+	XP           int            `json:"xp"`
+	XPByDay      map[string]int `json:"xpByDay,omitempty"`
+	Bought       int            `json:"bought,omitempty"`
+	Achievements []Achievement  `json:"achievements,omitempty"`
+}
+
+// Achievement is an unlocked achievement.
+type Achievement struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+}
+
+// Streak counts the completed tasks per day, by dates like 2006-01-02, and
+// remembers the bonuses given, as the first day of the streak and the days
+// of the milestone like 2006-01-02/7.
+type Streak struct {
+	Days    map[string]int `json:"days,omitempty"`
+	Awarded []string       `json:"awarded,omitempty"`
+}
+
+// Goal is a reward the user saves for. Incoming points are reserved for it
+// and can not be spent on other rewards.
+type Goal struct {
+	RewardID int       `json:"rewardId"`
+	Reserved int       `json:"reserved"`
+	SetAt    time.Time `json:"setAt"`
+}
+
+// LedgerEntry is a change of the balance.
+type LedgerEntry struct {
+	At     time.Time `json:"at"`
+	Points int       `json:"points"`
+	Note   string    `json:"note,omitempty"`
 }
@@ -1,11 +1,30 @@
 package models
 
+import "time"
+
 type Task struct {
-	ID                  int    `json:"id"`
-	Text                string `json:"text"`
-	IsComplete          bool   `json:"isComplete"`
-	TaskPoints          int    `json:"taskPoints"`
-	IsTaskPointsReceive bool   `json:"isTaskPointsReceive"`
+	ID                  int               `json:"id"`
+	Text                string            `json:"text"`
+	IsComplete          bool              `json:"isComplete"`
+	TaskPoints          int               `json:"taskPoints"`
+	IsTaskPointsReceive bool              `json:"isTaskPointsReceive"`
+	Priority            string            `json:"priority,omitempty"`
+	CreatedAt           *time.Time        `json:"createdAt,omitempty"`
+	CompletedAt         *time.Time        `json:"completedAt,omitempty"`
+	Due                 *time.Time        `json:"due,omitempty"`
+	Projects            []string          `json:"projects,omitempty"`
+	Contexts            []string          `json:"contexts,omitempty"`
+	Extensions          map[string]string `json:"extensions,omitempty"`
+	UID                 string            `json:"uid,omitempty"`
+	Annotations         []Annotation      `json:"annotations,omitempty"`
+	Assignee            string            `json:"assignee,omitempty"`
+	// Penalized is set once the penalty for the passed due date is charged.
+	Penalized bool `json:"penalized,omitempty"`
+}
+
+type Annotation struct {
+	CreatedAt time.Time `json:"createdAt"`
+	Text      string    `json:"text"`
 }
 
 type TodoList struct {
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+// events streams a "change" server-sent event whenever the data files are
+// changed, by the API as well as by the CLI. The files are polled: it needs
+// nothing from the platform and the files are small.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) && !s.queryAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, errorBody{Error: "missing or wrong token"})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, errorBody{Error: "streaming is not supported"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	last := storage.Version(s.todoFile, s.rewardFile)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case <-ticker.C:
+			version := storage.Version(s.todoFile, s.rewardFile)
+			if version == last {
+				continue
+			}
+			last = version
+			if _, err := fmt.Fprintf(w, "event: change\ndata: %s\n\n", version); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// queryAuthorized accepts the token from the URL. Browsers cannot send
+// headers with EventSource.
+func (s *Server) queryAuthorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
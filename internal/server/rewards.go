@@ -0,0 +1,210 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+type rewardInput struct {
+	Description *string `json:"description"`
+	Price       *int    `json:"price"`
+}
+
+type purchase struct {
+	Reward  models.Reward `json:"reward"`
+	Balance int           `json:"balance"`
+	// Request is set instead of a bought reward when the reward waits for
+	// approval.
+	Request *models.Request `json:"request,omitempty"`
+}
+
+type balance struct {
+	Points int `json:"points"`
+}
+
+func (s *Server) loadRewards() (*handlers.RewardHandler, error) {
+	rewardSystem, err := loaders.LoadRewardSystem(s.rewardFile, s.user)
+	if err != nil {
+		return nil, err
+	}
+
+	rh := &handlers.RewardHandler{RSystem: rewardSystem, Rules: s.rules}
+	rh.UpdateIsAvailableRewards()
+	if rh.RSystem.Rewards == nil {
+		rh.RSystem.Rewards = []models.Reward{}
+	}
+	return rh, nil
+}
+
+func (s *Server) listRewards(r *http.Request) (int, any, error) {
+	rh, err := s.loadRewards()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, rh.RSystem.Rewards, nil
+}
+
+func (s *Server) getReward(r *http.Request) (int, any, error) {
+	rh, err := s.loadRewards()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	i, err := rewardIndex(r, rh)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, rh.RSystem.Rewards[i], nil
+}
+
+func (s *Server) balance(r *http.Request) (int, any, error) {
+	rh, err := s.loadRewards()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, balance{Points: rh.RSystem.UserPoints}, nil
+}
+
+func (s *Server) createReward(r *http.Request) (int, any, error) {
+	var input rewardInput
+	if err := decodeJSON(r, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Description == nil || strings.TrimSpace(*input.Description) == "" {
+		return 0, nil, badRequest(fmt.Errorf("description must not be empty"))
+	}
+	price := s.defaults.RewardPrice
+	if input.Price != nil {
+		if *input.Price < 0 {
+			return 0, nil, badRequest(fmt.Errorf("price must be positive"))
+		}
+		price = *input.Price
+	}
+
+	var reward models.Reward
+	err := s.updateRewards(func(rh *handlers.RewardHandler) error {
+		rh.AddReward(strings.TrimSpace(*input.Description), price)
+		reward = rh.RSystem.Rewards[len(rh.RSystem.Rewards)-1]
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, reward, nil
+}
+
+func (s *Server) updateReward(r *http.Request) (int, any, error) {
+	var input rewardInput
+	if err := decodeJSON(r, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Description != nil && strings.TrimSpace(*input.Description) == "" {
+		return 0, nil, badRequest(fmt.Errorf("description must not be empty"))
+	}
+	if input.Price != nil && *input.Price < 0 {
+		return 0, nil, badRequest(fmt.Errorf("price must be positive"))
+	}
+
+	var reward models.Reward
+	err := s.updateRewards(func(rh *handlers.RewardHandler) error {
+		i, err := rewardIndex(r, rh)
+		if err != nil {
+			return err
+		}
+		if input.Description != nil {
+			rh.EditDesrcRewards(i, strings.TrimSpace(*input.Description))
+		}
+		if input.Price != nil {
+			rh.EditPriceRewards(i, *input.Price)
+		}
+		reward = rh.RSystem.Rewards[i]
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, reward, nil
+}
+
+func (s *Server) deleteReward(r *http.Request) (int, any, error) {
+	err := s.updateRewards(func(rh *handlers.RewardHandler) error {
+		i, err := rewardIndex(r, rh)
+		if err != nil {
+			return err
+		}
+		rh.DeleteReward(i)
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) purchaseReward(r *http.Request) (int, any, error) {
+	var result purchase
+	err := s.updateRewards(func(rh *handlers.RewardHandler) error {
+		i, err := rewardIndex(r, rh)
+		if err != nil {
+			return err
+		}
+		if rh.NeedsApproval(i) {
+			request, err := rh.RequestReward(i)
+			if err != nil {
+				return conflict(fmt.Errorf("the reward is not yet available: %w", err))
+			}
+			result = purchase{Reward: rh.RSystem.Rewards[i], Balance: rh.RSystem.UserPoints, Request: &request}
+			return nil
+		}
+		reward, err := rh.BuyRewards(i)
+		if err != nil {
+			return conflict(fmt.Errorf("the reward is not yet available: %w", err))
+		}
+
+		result = purchase{Reward: reward, Balance: rh.RSystem.UserPoints}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	if result.Request != nil {
+		return http.StatusAccepted, result, nil
+	}
+	return http.StatusOK, result, nil
+}
+
+// updateRewards loads the reward system, changes it with fn and saves it
+// while the file stays locked.
+func (s *Server) updateRewards(fn func(rh *handlers.RewardHandler) error) error {
+	return storage.Update([]string{s.rewardFile}, func(tx *storage.Tx) error {
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, s.rewardFile, s.user)
+		if err != nil {
+			return err
+		}
+
+		rh := &handlers.RewardHandler{RSystem: rewardSystem, Rules: s.rules}
+		if err := fn(rh); err != nil {
+			return err
+		}
+		return tx.Save(s.rewardFile, rh.RSystem)
+	})
+}
+
+func rewardIndex(r *http.Request, rh *handlers.RewardHandler) (int, error) {
+	id, err := utils.ValidateID(r.PathValue("id"), rh.RSystem.Rewards)
+	if err != nil {
+		return -1, notFound(fmt.Errorf("reward %s was not found", r.PathValue("id")))
+	}
+	i, err := utils.CheckExistItem(id, rh.RSystem.Rewards)
+	if err != nil {
+		return -1, notFound(fmt.Errorf("reward %d was not found", id))
+	}
+	return i, nil
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// webFiles is the web page served at /. It must work offline, so it uses
+// nothing but these files.
+//
+//go:embed web
+var webFiles embed.FS
+
+// maxBodySize limits request bodies, the API only takes short JSON objects.
+const maxBodySize = 1 << 20
+
+// Server is the JSON REST API of one list and the web page using it. Every
+// request loads the data files and changes are saved with storage.Update, so
+// the CLI may use the same files while the server runs.
+type Server struct {
+	todoFile     string
+	rewardFile   string
+	defaults     config.DefaultsConfig
+	addr         string
+	user         string
+	rules        handlers.Rules
+	token        string
+	pollInterval time.Duration
+	mux          *http.ServeMux
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+func New(cfg *config.Config) *Server {
+	s := &Server{
+		todoFile:     cfg.Storage.TodoFile,
+		rewardFile:   cfg.Storage.RewardFile,
+		defaults:     cfg.Defaults,
+		addr:         cfg.Server.Addr,
+		user:         cfg.User,
+		rules:        handlers.NewRules(cfg),
+		token:        cfg.Server.Token,
+		pollInterval: time.Second,
+		mux:          http.NewServeMux(),
+		done:         make(chan struct{}),
+	}
+	s.routes()
+	return s
+}
+
+// Close ends the event streams, which would keep http.Server.Shutdown
+// waiting otherwise.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *Server) routes() {
+	web, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err)
+	}
+	s.mux.Handle("GET /", http.FileServerFS(web))
+	s.mux.HandleFunc("GET /api/events", s.events)
+
+	s.mux.HandleFunc("GET /api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	})
+
+	s.mux.Handle("GET /api/tasks", s.api(s.listTasks))
+	s.mux.Handle("POST /api/tasks", s.api(s.createTask))
+	s.mux.Handle("GET /api/tasks/{id}", s.api(s.getTask))
+	s.mux.Handle("PATCH /api/tasks/{id}", s.api(s.updateTask))
+	s.mux.Handle("DELETE /api/tasks/{id}", s.api(s.deleteTask))
+	s.mux.Handle("POST /api/tasks/{id}/complete", s.api(s.completeTask))
+	s.mux.Handle("POST /api/tasks/{id}/uncomplete", s.api(s.uncompleteTask))
+
+	s.mux.Handle("GET /api/rewards", s.api(s.listRewards))
+	s.mux.Handle("POST /api/rewards", s.api(s.createReward))
+	s.mux.Handle("GET /api/rewards/{id}", s.api(s.getReward))
+	s.mux.Handle("PATCH /api/rewards/{id}", s.api(s.updateReward))
+	s.mux.Handle("DELETE /api/rewards/{id}", s.api(s.deleteReward))
+	s.mux.Handle("POST /api/rewards/{id}/purchase", s.api(s.purchaseReward))
+
+	s.mux.Handle("GET /api/balance", s.api(s.balance))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.checkOrigin(r); err != nil {
+		writeJSON(w, http.StatusForbidden, errorBody{Error: err.Error()})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkOrigin refuses requests of other web sites. Without a token any page
+// open in the browser could send requests to the server: a foreign Host
+// comes from DNS rebinding, a foreign Origin from a cross-site request.
+func (s *Server) checkOrigin(r *http.Request) error {
+	if !s.allowedHost(r.Host) {
+		return fmt.Errorf("host %q is not served", r.Host)
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+		return fmt.Errorf("requests from %s are not allowed", origin)
+	}
+	return nil
+}
+
+// allowedHost reports whether host names the server: its address or a
+// loopback one. A server on all interfaces has a token, see CheckAddr, and
+// answers to any name.
+func (s *Server) allowedHost(host string) bool {
+	name, _, err := net.SplitHostPort(host)
+	if err != nil {
+		name = host
+	}
+	name = strings.Trim(name, "[]")
+	if name == "localhost" {
+		return true
+	}
+	if ip := net.ParseIP(name); ip != nil && ip.IsLoopback() {
+		return true
+	}
+
+	addrHost, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return false
+	}
+	if ip := net.ParseIP(addrHost); addrHost == "" || ip != nil && ip.IsUnspecified() {
+		return true
+	}
+	return strings.EqualFold(name, addrHost)
+}
+
+// CheckAddr refuses to serve other hosts without a token: anyone on the
+// network could spend the points otherwise.
+func CheckAddr(addr, token string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("incorrect address %q: %w", addr, err)
+	}
+	if token != "" || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("set server.token to listen on %s, it is not a loopback address", addr)
+}
+
+// apiFunc handles one endpoint and returns the status and the body of the
+// response. Errors are turned into JSON by api.
+type apiFunc func(r *http.Request) (int, any, error)
+
+func (s *Server) api(fn apiFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="todo"`)
+			writeJSON(w, http.StatusUnauthorized, errorBody{Error: "missing or wrong token"})
+			return
+		}
+
+		// A web page can send text/plain to another site without asking,
+		// JSON needs the permission of the server, which it never gives.
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeJSON(w, http.StatusUnsupportedMediaType, errorBody{Error: "Content-Type must be application/json"})
+				return
+			}
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		status, body, err := fn(r)
+		if err != nil {
+			status = statusOf(err)
+			if status == http.StatusInternalServerError {
+				logger.Error("request failed", err, slog.String("method", r.Method), slog.String("path", r.URL.Path))
+			}
+			body = errorBody{Error: err.Error()}
+		}
+		writeJSON(w, status, body)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// apiError carries the HTTP status of an error.
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e apiError) Error() string { return e.err.Error() }
+func (e apiError) Unwrap() error { return e.err }
+
+func badRequest(err error) error { return apiError{http.StatusBadRequest, err} }
+func notFound(err error) error   { return apiError{http.StatusNotFound, err} }
+func conflict(err error) error   { return apiError{http.StatusConflict, err} }
+
+func statusOf(err error) int {
+	var apiErr apiError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.status
+	case errors.Is(err, storage.ErrLocked):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("failed to write response", err)
+	}
+}
+
+func decodeJSON(r *http.Request, body any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(body); err != nil {
+		return badRequest(fmt.Errorf("invalid JSON body: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func newTestServer(t *testing.T, token string, configure ...func(cfg *config.Config)) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			TodoFile:   filepath.Join(dir, "todo.json"),
+			RewardFile: filepath.Join(dir, "rewards.json"),
+		},
+		Defaults: config.DefaultsConfig{TaskPoints: 20, RewardPrice: 30},
+		Server:   config.ServerConfig{Addr: "127.0.0.1:8080", Token: token},
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	ts := httptest.NewServer(New(cfg))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func doRequest(t *testing.T, ts *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest returned error: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("could not decode response of %s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestServer_CompleteTaskAndBuyReward(t *testing.T) {
+	ts := newTestServer(t, "secret")
+
+	var task struct {
+		ID         int `json:"id"`
+		TaskPoints int `json:"taskPoints"`
+	}
+	if status := doRequest(t, ts, "POST", "/api/tasks", `{"text":"Write report"}`, &task); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	if task.ID != 1 || task.TaskPoints != 20 {
+		t.Errorf("Expected task 1 with default 20 points, got %+v", task)
+	}
+
+	var done completion
+	if status := doRequest(t, ts, "POST", "/api/tasks/1/complete", "", &done); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if !done.Task.IsComplete || done.Points != 20 || done.Balance != 20 {
+		t.Errorf("Unexpected completion: %+v", done)
+	}
+	if status := doRequest(t, ts, "POST", "/api/tasks/1/complete", "", nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 for completing the task again, got %d", status)
+	}
+
+	if status := doRequest(t, ts, "POST", "/api/rewards", `{"description":"Cinema","price":15}`, nil); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	var bought purchase
+	if status := doRequest(t, ts, "POST", "/api/rewards/1/purchase", "", &bought); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if bought.Balance != 5 {
+		t.Errorf("Expected balance 5 after purchase, got %d", bought.Balance)
+	}
+	if status := doRequest(t, ts, "POST", "/api/rewards/1/purchase", "", nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 without enough points, got %d", status)
+	}
+
+	var b balance
+	doRequest(t, ts, "GET", "/api/balance", "", &b)
+	if b.Points != 5 {
+		t.Errorf("Expected balance 5, got %d", b.Points)
+	}
+}
+
+func TestServer_DueDateAndOverduePenalty(t *testing.T) {
+	ts := newTestServer(t, "secret", func(cfg *config.Config) {
+		cfg.Penalties = config.PenaltiesConfig{Overdue: 5}
+	})
+
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	var task models.Task
+	if status := doRequest(t, ts, "POST", "/api/tasks", `{"text":"Pay taxes","due":"`+yesterday+`"}`, &task); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	if task.Due == nil || task.Due.Format("2006-01-02") != yesterday {
+		t.Fatalf("Expected the task due yesterday, got %+v", task)
+	}
+	if status := doRequest(t, ts, "PATCH", "/api/tasks/1", `{"due":"someday"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an incorrect due date, got %d", status)
+	}
+
+	// Completing the task late costs the penalty.
+	var done completion
+	if status := doRequest(t, ts, "POST", "/api/tasks/1/complete", "", &done); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if done.Points != 20 || done.Balance != 15 || !done.Task.Penalized {
+		t.Errorf("Expected 20 points minus the penalty of 5, got %+v", done)
+	}
+
+	var changed models.Task
+	if status := doRequest(t, ts, "PATCH", "/api/tasks/1", `{"due":""}`, &changed); status != http.StatusOK || changed.Due != nil {
+		t.Errorf("Expected the due date to be removed, got %d and %+v", status, changed)
+	}
+}
+
+func TestServer_PurchaseNeedsApproval(t *testing.T) {
+	var rewardFile string
+	ts := newTestServer(t, "secret", func(cfg *config.Config) {
+		cfg.User = "kid"
+		cfg.Approval = config.ApprovalConfig{Approvers: []string{"mom"}}
+		rewardFile = cfg.Storage.RewardFile
+	})
+
+	doRequest(t, ts, "POST", "/api/tasks", `{"text":"Dishes"}`, nil)
+	doRequest(t, ts, "POST", "/api/tasks/1/complete", "", nil)
+	doRequest(t, ts, "POST", "/api/rewards", `{"description":"Game hour","price":15}`, nil)
+
+	rewardSystem, err := loaders.LoadRewardSystem(rewardFile, "kid")
+	if err != nil {
+		t.Fatalf("LoadRewardSystem returned error: %v", err)
+	}
+	rewardSystem.Rewards[0].RequiresApproval = true
+	if err := storage.Save(rewardFile, rewardSystem); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	var asked purchase
+	if status := doRequest(t, ts, "POST", "/api/rewards/1/purchase", "", &asked); status != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", status)
+	}
+	if asked.Request == nil || asked.Request.ID != 1 || asked.Request.Points != 15 || asked.Balance != 5 {
+		t.Errorf("Expected request 1 holding 15 points, got %+v", asked)
+	}
+}
+
+func TestServer_ErrorsAndAuth(t *testing.T) {
+	ts := newTestServer(t, "secret")
+
+	resp, err := ts.Client().Get(ts.URL + "/api/tasks")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.Client().Get(ts.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the API description to be public, got %d", resp.StatusCode)
+	}
+
+	if status := doRequest(t, ts, "GET", "/api/tasks/7", "", nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing task, got %d", status)
+	}
+	if status := doRequest(t, ts, "POST", "/api/tasks", `{"text":"x","points":-1}`, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for negative points, got %d", status)
+	}
+	if status := doRequest(t, ts, "POST", "/api/tasks", `{"title":"x"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown field, got %d", status)
+	}
+}
+
+func TestServer_RefusesOtherSites(t *testing.T) {
+	ts := newTestServer(t, "")
+
+	send := func(host, origin, contentType string) int {
+		t.Helper()
+		req, err := http.NewRequest("POST", ts.URL+"/api/tasks", strings.NewReader(`{"text":"Hacked"}`))
+		if err != nil {
+			t.Fatalf("NewRequest returned error: %v", err)
+		}
+		if host != "" {
+			req.Host = host
+		}
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := send("", "http://evil.example", "text/plain"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin write, got %d", status)
+	}
+	if status := send("", "", "text/plain"); status != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected 415 for a write which is not JSON, got %d", status)
+	}
+	if status := send("evil.example:8080", "", "application/json"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a foreign host, got %d", status)
+	}
+	if status := send("", strings.Replace(ts.URL, "127.0.0.1", "localhost", 1), "application/json"); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for an origin which is not the host, got %d", status)
+	}
+
+	var tasks []models.Task
+	doRequest(t, ts, "GET", "/api/tasks", "", &tasks)
+	if len(tasks) != 0 {
+		t.Fatalf("Expected no task from other sites, got %+v", tasks)
+	}
+	if status := send("", ts.URL, "application/json; charset=utf-8"); status != http.StatusCreated {
+		t.Errorf("Expected 201 for the page of the server, got %d", status)
+	}
+}
+
+// Every path of the API description must be served.
+func TestServer_OpenAPIPathsExist(t *testing.T) {
+	ts := newTestServer(t, "")
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	for path, operations := range spec.Paths {
+		// The event stream does not end, it is tested in web_test.go.
+		if path == "/api/events" {
+			continue
+		}
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			status := doRequest(t, ts, strings.ToUpper(method), strings.ReplaceAll(path, "{id}", "1"), "{}", nil)
+			if status == http.StatusMethodNotAllowed || (status == http.StatusNotFound && !strings.Contains(path, "{id}")) {
+				t.Errorf("%s %s is described but not served (status %d)", strings.ToUpper(method), path, status)
+			}
+		}
+	}
+}
+
+func TestCheckAddr(t *testing.T) {
+	if err := CheckAddr("127.0.0.1:8080", ""); err != nil {
+		t.Errorf("Expected loopback address without token to be allowed, got %v", err)
+	}
+	if err := CheckAddr("0.0.0.0:8080", ""); err == nil {
+		t.Error("Expected error for a public address without token")
+	}
+	if err := CheckAddr("0.0.0.0:8080", "secret"); err != nil {
+		t.Errorf("Expected public address with token to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+type taskInput struct {
+	Text   *string `json:"text"`
+	Points *int    `json:"points"`
+	// Due is a day like 2025-03-01, today, tomorrow or +3d, an empty one
+	// removes the due date.
+	Due *string `json:"due"`
+}
+
+// due parses the due date of the input, nil when it is missing or empty.
+func (input taskInput) due() (*time.Time, error) {
+	if input.Due == nil || strings.TrimSpace(*input.Due) == "" {
+		return nil, nil
+	}
+	day, err := utils.ParseDue(*input.Due, time.Now())
+	if err != nil {
+		return nil, badRequest(err)
+	}
+	return &day, nil
+}
+
+// completion is the answer to complete and uncomplete: Points were added to
+// or taken from the balance.
+type completion struct {
+	Task    models.Task `json:"task"`
+	Points  int         `json:"points"`
+	Balance int         `json:"balance"`
+}
+
+func (s *Server) listTasks(r *http.Request) (int, any, error) {
+	todoList, err := loaders.LoadTodoList(s.todoFile)
+	if err != nil {
+		return 0, nil, err
+	}
+	if todoList.Tasks == nil {
+		todoList.Tasks = []models.Task{}
+	}
+	return http.StatusOK, todoList.Tasks, nil
+}
+
+func (s *Server) getTask(r *http.Request) (int, any, error) {
+	todoList, err := loaders.LoadTodoList(s.todoFile)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	h := &handlers.TaskHandler{Todo: todoList}
+	i, err := taskIndex(r, h)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, h.Todo.Tasks[i], nil
+}
+
+func (s *Server) createTask(r *http.Request) (int, any, error) {
+	var input taskInput
+	if err := decodeJSON(r, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Text == nil || strings.TrimSpace(*input.Text) == "" {
+		return 0, nil, badRequest(fmt.Errorf("text must not be empty"))
+	}
+	points := s.defaults.TaskPoints
+	if input.Points != nil {
+		if *input.Points < 0 {
+			return 0, nil, badRequest(fmt.Errorf("count of points must be positive"))
+		}
+		points = *input.Points
+	}
+	due, err := input.due()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var task models.Task
+	err = s.updateTasks(func(h *handlers.TaskHandler) error {
+		h.Add(strings.TrimSpace(*input.Text), points)
+		if due != nil {
+			h.SetDue(len(h.Todo.Tasks)-1, due)
+		}
+		task = h.Todo.Tasks[len(h.Todo.Tasks)-1]
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, task, nil
+}
+
+func (s *Server) updateTask(r *http.Request) (int, any, error) {
+	var input taskInput
+	if err := decodeJSON(r, &input); err != nil {
+		return 0, nil, err
+	}
+	if input.Text != nil && strings.TrimSpace(*input.Text) == "" {
+		return 0, nil, badRequest(fmt.Errorf("text must not be empty"))
+	}
+	if input.Points != nil && *input.Points < 0 {
+		return 0, nil, badRequest(fmt.Errorf("count of points must be positive"))
+	}
+	due, err := input.due()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var task models.Task
+	err = s.updateTasks(func(h *handlers.TaskHandler) error {
+		i, err := taskIndex(r, h)
+		if err != nil {
+			return err
+		}
+		if input.Text != nil {
+			h.Edit(i, strings.TrimSpace(*input.Text))
+		}
+		if input.Points != nil {
+			h.EditTaskPoints(i, *input.Points)
+		}
+		if input.Due != nil {
+			h.SetDue(i, due)
+		}
+		task = h.Todo.Tasks[i]
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, task, nil
+}
+
+func (s *Server) deleteTask(r *http.Request) (int, any, error) {
+	// Deleting an uncompleted task may cost points, so both files are
+	// locked.
+	err := storage.Update([]string{s.todoFile, s.rewardFile}, func(tx *storage.Tx) error {
+		todoList, err := loaders.LoadTodoListTx(tx, s.todoFile)
+		if err != nil {
+			return err
+		}
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, s.rewardFile, s.user)
+		if err != nil {
+			return err
+		}
+
+		h := &handlers.TaskHandler{Todo: todoList}
+		rh := &handlers.RewardHandler{RSystem: rewardSystem, Rules: s.rules}
+
+		i, err := taskIndex(r, h)
+		if err != nil {
+			return err
+		}
+		if rh.PenalizeDeletion(h.Todo.Tasks[i]) > 0 {
+			if err := tx.Save(s.rewardFile, rh.RSystem); err != nil {
+				return err
+			}
+		}
+		h.Delete(i)
+		return tx.Save(s.todoFile, h.Todo)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) completeTask(r *http.Request) (int, any, error) {
+	return s.changeCompletion(r, (*handlers.TaskHandler).CompleteWithPoints)
+}
+
+func (s *Server) uncompleteTask(r *http.Request) (int, any, error) {
+	return s.changeCompletion(r, (*handlers.TaskHandler).NotCompletedWithPoints)
+}
+
+func (s *Server) changeCompletion(r *http.Request, change func(*handlers.TaskHandler, int, *handlers.RewardHandler) (int, error)) (int, any, error) {
+	var result completion
+	err := storage.Update([]string{s.todoFile, s.rewardFile}, func(tx *storage.Tx) error {
+		todoList, err := loaders.LoadTodoListTx(tx, s.todoFile)
+		if err != nil {
+			return err
+		}
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, s.rewardFile, s.user)
+		if err != nil {
+			return err
+		}
+
+		h := &handlers.TaskHandler{Todo: todoList}
+		rh := &handlers.RewardHandler{RSystem: rewardSystem, Rules: s.rules}
+
+		i, err := taskIndex(r, h)
+		if err != nil {
+			return err
+		}
+		points, err := change(h, i, rh)
+		if err != nil {
+			return conflict(err)
+		}
+		// A task completed after its due date pays for it like an open one.
+		h.PenalizeOverdue(rh, time.Now())
+
+		if err := tx.Save(s.rewardFile, rh.RSystem); err != nil {
+			return err
+		}
+		if err := tx.Save(s.todoFile, h.Todo); err != nil {
+			return err
+		}
+
+		result = completion{Task: h.Todo.Tasks[i], Points: points, Balance: rh.RSystem.UserPoints}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, result, nil
+}
+
+// updateTasks loads the todo list, changes it with fn and saves it while
+// the file stays locked.
+func (s *Server) updateTasks(fn func(h *handlers.TaskHandler) error) error {
+	return storage.Update([]string{s.todoFile}, func(tx *storage.Tx) error {
+		todoList, err := loaders.LoadTodoListTx(tx, s.todoFile)
+		if err != nil {
+			return err
+		}
+
+		h := &handlers.TaskHandler{Todo: todoList}
+		if err := fn(h); err != nil {
+			return err
+		}
+		return tx.Save(s.todoFile, h.Todo)
+	})
+}
+
+func taskIndex(r *http.Request, h *handlers.TaskHandler) (int, error) {
+	id, err := utils.ValidateID(r.PathValue("id"), h.Todo.Tasks)
+	if err != nil {
+		return -1, notFound(fmt.Errorf("task %s was not found", r.PathValue("id")))
+	}
+	i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+	if err != nil {
+		return -1, notFound(err)
+	}
+	return i, nil
+}
@@ -0,0 +1,172 @@
+"use strict";
+
+// The page talks to the REST API of 'todo serve' and reloads the data when
+// the server reports that the data files changed.
+
+const tokenKey = "todo-token";
+let events = null;
+
+function token() {
+  return localStorage.getItem(tokenKey) || "";
+}
+
+async function api(method, path, body) {
+  const headers = {};
+  if (token()) {
+    headers["Authorization"] = "Bearer " + token();
+  }
+  // The server takes changes as JSON only, even those without a body.
+  if (method !== "GET") {
+    headers["Content-Type"] = "application/json";
+  }
+
+  const resp = await fetch(path, {
+    method: method,
+    headers: headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+
+  if (resp.status === 401) {
+    const entered = prompt("Token of the server (server.token in the config):");
+    if (entered !== null) {
+      localStorage.setItem(tokenKey, entered);
+      listen();
+      return api(method, path, body);
+    }
+  }
+  if (resp.status === 204) {
+    return null;
+  }
+
+  const data = await resp.json();
+  if (!resp.ok) {
+    throw new Error(data.error || resp.statusText);
+  }
+  return data;
+}
+
+function showStatus(message) {
+  const status = document.getElementById("status");
+  status.textContent = message;
+  status.hidden = !message;
+}
+
+async function run(action) {
+  try {
+    await action();
+    showStatus("");
+  } catch (err) {
+    showStatus(err.message);
+  }
+  await refresh();
+}
+
+function element(tag, props, ...children) {
+  const el = document.createElement(tag);
+  Object.assign(el, props);
+  el.append(...children);
+  return el;
+}
+
+function renderTasks(tasks) {
+  const list = document.getElementById("tasks");
+  list.replaceChildren();
+  if (tasks.length === 0) {
+    list.append(element("li", {}, "No tasks, well done!"));
+  }
+
+  for (const task of tasks) {
+    const checkbox = element("input", { type: "checkbox", checked: task.isComplete });
+    checkbox.addEventListener("change", () => run(() =>
+      api("POST", `/api/tasks/${task.id}/${checkbox.checked ? "complete" : "uncomplete"}`)));
+
+    const del = element("button", { className: "delete", title: "Delete task" }, "✕");
+    del.addEventListener("click", () => {
+      if (confirm(`Delete task ${task.id}. ${task.text}?`)) {
+        run(() => api("DELETE", `/api/tasks/${task.id}`));
+      }
+    });
+
+    list.append(element("li", { className: task.isComplete ? "done" : "" },
+      checkbox,
+      element("span", { className: "text" }, `${task.id}. ${task.text}`),
+      element("span", { className: "points" }, `${task.taskPoints} points`),
+      del));
+  }
+}
+
+function renderRewards(rewards) {
+  const list = document.getElementById("rewards");
+  list.replaceChildren();
+  if (rewards.length === 0) {
+    list.append(element("li", {}, "The rewards was not added"));
+  }
+
+  for (const reward of rewards) {
+    const buy = element("button", { className: "buy", disabled: !reward.isAvailable }, "Buy");
+    buy.addEventListener("click", () => run(() => api("POST", `/api/rewards/${reward.id}/purchase`)));
+
+    const del = element("button", { className: "delete", title: "Delete reward" }, "✕");
+    del.addEventListener("click", () => {
+      if (confirm(`Delete reward ${reward.id}. ${reward.description}?`)) {
+        run(() => api("DELETE", `/api/rewards/${reward.id}`));
+      }
+    });
+
+    list.append(element("li", {},
+      element("span", { className: "text" }, `${reward.id}. ${reward.description}`),
+      element("span", { className: "points" }, `${reward.priceOfReward} points`),
+      buy,
+      del));
+  }
+}
+
+async function refresh() {
+  try {
+    const [tasks, rewards, balance] = await Promise.all([
+      api("GET", "/api/tasks"),
+      api("GET", "/api/rewards"),
+      api("GET", "/api/balance"),
+    ]);
+    renderTasks(tasks);
+    renderRewards(rewards);
+    document.getElementById("balance").textContent = balance.points;
+  } catch (err) {
+    showStatus(err.message);
+  }
+}
+
+function numberOrUndefined(value) {
+  return value === "" ? undefined : Number(value);
+}
+
+function listen() {
+  if (events) {
+    events.close();
+  }
+  // EventSource cannot send headers, so the token goes into the URL.
+  events = new EventSource("/api/events?token=" + encodeURIComponent(token()));
+  events.addEventListener("change", refresh);
+}
+
+document.getElementById("add-task").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const body = { text: form.text.value, points: numberOrUndefined(form.points.value) };
+  run(async () => {
+    await api("POST", "/api/tasks", body);
+    form.reset();
+  });
+});
+
+document.getElementById("add-reward").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const form = event.target;
+  const body = { description: form.description.value, price: numberOrUndefined(form.price.value) };
+  run(async () => {
+    await api("POST", "/api/rewards", body);
+    form.reset();
+  });
+});
+
+refresh().then(listen);
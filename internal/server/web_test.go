@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func TestWeb_ServesPageWithoutExternalAssets(t *testing.T) {
+	ts := newTestServer(t, "secret")
+
+	resp, err := ts.Client().Get(ts.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected the HTML page without a token, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(page), `<script src="app.js">`) {
+		t.Error("Expected the page to load app.js")
+	}
+
+	for _, asset := range []string{"/app.js", "/style.css"} {
+		resp, err := ts.Client().Get(ts.URL + asset)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", asset, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected %s to be served, got %d", asset, resp.StatusCode)
+		}
+	}
+
+	// The page must work offline: no file may load anything from the network.
+	err = fs.WalkDir(webFiles, "web", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(webFiles, path)
+		if err != nil {
+			return err
+		}
+		for _, scheme := range []string{"http://", "https://", "//cdn"} {
+			if strings.Contains(string(data), scheme) {
+				t.Errorf("%s refers to an external resource (%s)", path, scheme)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not read embedded files: %v", err)
+	}
+}
+
+func TestWeb_EventsReportChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			TodoFile:   filepath.Join(dir, "todo.json"),
+			RewardFile: filepath.Join(dir, "rewards.json"),
+		},
+		Server: config.ServerConfig{Token: "secret"},
+	}
+	s := New(cfg)
+	s.pollInterval = 10 * time.Millisecond
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	defer s.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatalf("GET /api/events failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	resp, err = ts.Client().Get(ts.URL + "/api/events?token=secret")
+	if err != nil {
+		t.Fatalf("GET /api/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	events := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "event: ") {
+				events <- strings.TrimPrefix(scanner.Text(), "event: ")
+			}
+		}
+		close(events)
+	}()
+
+	// The change comes from outside the API, like from the CLI.
+	if err := storage.Save(cfg.Storage.TodoFile, &models.TodoList{NextID: 1}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	select {
+	case event := <-events:
+		if event != "change" {
+			t.Errorf("Expected a change event, got %q", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No event after the todo file changed")
+	}
+}
@@ -0,0 +1,153 @@
+package shell
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionKey marks the commands of the session itself in their annotations:
+// they change the files of the list, not the staging ones.
+const sessionKey = "session"
+
+// dataCommands are the commands of the CLI run by the shell. The others
+// change the config or other lists, or start another UI.
+var dataCommands = []string{
+	"add", "list", "complete", "not-complete", "edit", "delete", "clear", "cancel-delete",
+	"buy-reward", "resetp", "points", "goal", "streak", "profile", "leaderboard",
+	"requests", "approve", "deny", "export", "import",
+}
+
+// configSubcommands change the defaults in the config, which are not kept
+// aside until commit.
+var configSubcommands = []string{"pointsdef", "repricedef"}
+
+// root builds the command tree for one line: cobra keeps flag values
+// between runs, so the tree is not reused. exit sets quit; warned tells that
+// the previous line was an exit refused because of uncommitted changes.
+func (s *Session) root(quit *bool, warned bool) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "todo",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	root.SetOut(s.out)
+	root.SetErr(s.out)
+	root.CompletionOptions.DisableDefaultCmd = true
+
+	for _, cmd := range s.commands(s.staged) {
+		if !slices.Contains(dataCommands, cmd.Name()) {
+			continue
+		}
+		for _, sub := range cmd.Commands() {
+			if slices.Contains(configSubcommands, sub.Name()) {
+				cmd.RemoveCommand(sub)
+			}
+		}
+		root.AddCommand(cmd)
+	}
+
+	commit := &cobra.Command{
+		Use:         "commit",
+		Short:       "Write the changes to the files",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{sessionKey: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pending := s.pending
+			if err := s.Commit(); err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "%d changes were saved\n", pending)
+			return nil
+		},
+	}
+
+	rollback := &cobra.Command{
+		Use:         "rollback",
+		Short:       "Drop the changes and load the files again",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{sessionKey: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pending := s.pending
+			if err := s.Rollback(); err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "%d changes were dropped\n", pending)
+			return nil
+		},
+	}
+
+	exit := &cobra.Command{
+		Use:         "exit",
+		Aliases:     []string{"quit"},
+		Short:       "Leave the shell, twice to drop uncommitted changes",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{sessionKey: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if s.pending > 0 && !warned {
+				s.exitWarned = true
+				fmt.Fprintf(s.out, "There are %d uncommitted changes: run commit, or exit again to drop them\n", s.pending)
+				return nil
+			}
+			*quit = true
+			return nil
+		},
+	}
+	root.AddCommand(commit, rollback, exit)
+	return root
+}
+
+// Complete returns the commands and IDs that may stand for the last word of
+// line. IDs come from the completion of the command in the CLI, which reads
+// the staging files.
+func (s *Session) Complete(line string) []string {
+	words := strings.Fields(line)
+	toComplete := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\t") {
+		toComplete = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	var quit bool
+	cmd := s.root(&quit, false)
+	var positional []string
+	for _, word := range words {
+		if strings.HasPrefix(word, "-") {
+			continue
+		}
+		if sub := subcommand(cmd, word); sub != nil && len(positional) == 0 {
+			cmd = sub
+			continue
+		}
+		positional = append(positional, word)
+	}
+
+	var candidates []string
+	if len(positional) == 0 {
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() || sub.Name() == "help" {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+	}
+	if cmd.ValidArgsFunction != nil {
+		completions, _ := cmd.ValidArgsFunction(cmd, positional, toComplete)
+		for _, completion := range completions {
+			// A completion may carry its description after a tab.
+			value, _, _ := strings.Cut(completion, "\t")
+			candidates = append(candidates, value)
+		}
+	}
+	return candidates
+}
+
+func subcommand(cmd *cobra.Command, name string) *cobra.Command {
+	for _, sub := range cmd.Commands() {
+		if sub.Name() == name || sub.HasAlias(name) {
+			return sub
+		}
+	}
+	return nil
+}
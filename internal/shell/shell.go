@@ -0,0 +1,260 @@
+// Package shell is the interactive shell of todo shell. It copies the data
+// files of the list aside once, runs the commands of the CLI on the copies
+// and writes them back on commit.
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/tui"
+)
+
+// Session is the data of one list copied to a staging directory with the
+// changes made since the last commit.
+type Session struct {
+	cfg *config.Config
+	// staged is cfg with the staging files as the storage, the commands of
+	// the CLI are built with it.
+	staged   *config.Config
+	dir      string
+	commands func(cfg *config.Config) []*cobra.Command
+	list     string
+	out      io.Writer
+
+	// version identifies the files as they were loaded, to notice changes
+	// made by other programs before commit.
+	version string
+	pending int
+	// exitWarned is set when exit was refused because of pending changes,
+	// the next exit drops them.
+	exitWarned bool
+}
+
+// New loads the list of cfg into a new session. commands builds the
+// commands of the CLI, a new tree for every line: cobra keeps flag values
+// between runs.
+func New(cfg *config.Config, out io.Writer, commands func(cfg *config.Config) []*cobra.Command) (*Session, error) {
+	dir, err := os.MkdirTemp("", "todo-shell-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create the staging directory: %w", err)
+	}
+
+	staged := *cfg
+	staged.Storage = config.StorageConfig{
+		TodoFile:   filepath.Join(dir, "todo.json"),
+		RewardFile: filepath.Join(dir, "rewards.json"),
+	}
+	// The storage knows the secret of encrypted files already, completion
+	// must not replace it with a provider which never asks.
+	staged.Encryption.Enabled = false
+
+	s := &Session{
+		cfg:      cfg,
+		staged:   &staged,
+		dir:      dir,
+		commands: commands,
+		list:     cfg.List,
+		out:      out,
+	}
+	if err := s.load(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close removes the staging files, uncommitted changes are lost.
+func (s *Session) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// load copies the files of the list to the staging files while they are
+// locked.
+func (s *Session) load() error {
+	files := []string{s.cfg.Storage.TodoFile, s.cfg.Storage.RewardFile, s.staged.Storage.TodoFile, s.staged.Storage.RewardFile}
+	err := storage.Update(files, func(tx *storage.Tx) error {
+		todoList, err := loaders.LoadTodoListTx(tx, s.cfg.Storage.TodoFile)
+		if err != nil {
+			return err
+		}
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, s.cfg.Storage.RewardFile, s.cfg.User)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(s.staged.Storage.RewardFile, rewardSystem); err != nil {
+			return fmt.Errorf("failed to copy reward file: %w", err)
+		}
+		if err := tx.Save(s.staged.Storage.TodoFile, todoList); err != nil {
+			return fmt.Errorf("failed to copy todo list: %w", err)
+		}
+		s.version = storage.Version(s.cfg.Storage.TodoFile, s.cfg.Storage.RewardFile)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.pending = 0
+	return nil
+}
+
+// Pending is the number of commands that changed the data since the last
+// commit or rollback.
+func (s *Session) Pending() int {
+	return s.pending
+}
+
+// Prompt shows the list and a star when there is something to commit.
+func (s *Session) Prompt() string {
+	if s.pending > 0 {
+		return fmt.Sprintf("todo:%s*> ", s.list)
+	}
+	return fmt.Sprintf("todo:%s> ", s.list)
+}
+
+// Commit writes the staging files over the files of the list while they are
+// locked. It refuses if another program changed them since they were
+// loaded: saving would lose its changes.
+func (s *Session) Commit() error {
+	if s.pending == 0 {
+		return nil
+	}
+	files := []string{s.cfg.Storage.TodoFile, s.cfg.Storage.RewardFile, s.staged.Storage.TodoFile, s.staged.Storage.RewardFile}
+	err := storage.Update(files, func(tx *storage.Tx) error {
+		if storage.Version(s.cfg.Storage.TodoFile, s.cfg.Storage.RewardFile) != s.version {
+			return fmt.Errorf("the files were changed by another program since they were loaded, run rollback to load them again")
+		}
+		todoList, err := loaders.LoadTodoListTx(tx, s.staged.Storage.TodoFile)
+		if err != nil {
+			return err
+		}
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, s.staged.Storage.RewardFile, s.cfg.User)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(s.cfg.Storage.RewardFile, rewardSystem); err != nil {
+			return fmt.Errorf("failed to save reward file: %w", err)
+		}
+		if err := tx.Save(s.cfg.Storage.TodoFile, todoList); err != nil {
+			return fmt.Errorf("failed to save todo list: %w", err)
+		}
+		s.version = storage.Version(s.cfg.Storage.TodoFile, s.cfg.Storage.RewardFile)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.pending = 0
+	return nil
+}
+
+// Rollback drops the changes and loads the files again.
+func (s *Session) Rollback() error {
+	return s.load()
+}
+
+// Exec runs one line. Errors are printed, the shell goes on. It reports
+// whether the shell must be closed.
+func (s *Session) Exec(line string) bool {
+	args, err := splitWords(line)
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	quit, warned := false, s.exitWarned
+	s.exitWarned = false
+	root := s.root(&quit, warned)
+	root.SetArgs(args)
+
+	before := storage.Version(s.staged.Storage.TodoFile, s.staged.Storage.RewardFile)
+	cmd, err := root.ExecuteC()
+	if err != nil {
+		fmt.Fprintf(s.out, "Error: %v\n", err)
+	}
+	// A command of the CLI which saved the staging files is a change to
+	// commit, commit and rollback write them themselves.
+	if cmd != nil && cmd.Annotations[sessionKey] == "" && storage.Version(s.staged.Storage.TodoFile, s.staged.Storage.RewardFile) != before {
+		s.pending++
+	}
+	return quit
+}
+
+// Run reads lines with the editor and runs them until exit or the end of
+// the input.
+func (s *Session) Run(editor *tui.LineEditor, in *os.File) error {
+	if editor.Complete == nil {
+		editor.Complete = s.Complete
+	}
+	for {
+		line, err := editor.ReadLine(in, s.out, s.Prompt())
+		if errors.Is(err, io.EOF) {
+			if s.pending > 0 {
+				fmt.Fprintf(s.out, "%d uncommitted changes were discarded\n", s.pending)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if s.Exec(line) {
+			return nil
+		}
+	}
+}
+
+// splitWords splits a line into arguments like a shell does: quotes keep
+// spaces, a backslash escapes the next character.
+func splitWords(line string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed quote %c", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,204 @@
+package shell_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/cmd"
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/shell"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+// TestMain keeps the commands of the CLI away from the real config and data
+// directories of the user.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "todo-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	os.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	logger.Init(slog.LevelDebug, io.Discard)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newTestSession(t *testing.T) (*shell.Session, *bytes.Buffer, *config.Config) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			TodoFile:   filepath.Join(dir, "todo.json"),
+			RewardFile: filepath.Join(dir, "rewards.json"),
+		},
+		Defaults: config.DefaultsConfig{TaskPoints: 20, RewardPrice: 30},
+		Approval: config.ApprovalConfig{Approvers: []string{"mom"}},
+		List:     "default",
+	}
+
+	out := &bytes.Buffer{}
+	s, err := shell.New(cfg, out, cmd.Commands)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, out, cfg
+}
+
+func TestSession_CommitWritesTheBatch(t *testing.T) {
+	s, out, cfg := newTestSession(t)
+
+	for _, line := range []string{
+		`add "Write report" -p 25`,
+		`add Call mom`,
+		`add reward Cinema -p 30`,
+		`complete 1`,
+		`complete 2 -d -f`,
+		`buy-reward 1`,
+	} {
+		s.Exec(line)
+	}
+	if s.Pending() != 6 {
+		t.Fatalf("Expected 6 pending changes, got %d\n%s", s.Pending(), out)
+	}
+
+	todoList, _ := loaders.LoadTodoList(cfg.Storage.TodoFile)
+	if len(todoList.Tasks) != 0 {
+		t.Fatalf("Expected nothing saved before commit, got %+v", todoList.Tasks)
+	}
+
+	s.Exec("commit")
+	todoList, _ = loaders.LoadTodoList(cfg.Storage.TodoFile)
+	rewardSystem, _ := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+	if len(todoList.Tasks) != 1 || todoList.Tasks[0].Text != "Write report" || !todoList.Tasks[0].IsComplete {
+		t.Errorf("Expected completed task 'Write report' after commit, got %+v", todoList.Tasks)
+	}
+	if rewardSystem.UserPoints != 15 {
+		t.Errorf("Expected balance 25+20-30=15, got %d", rewardSystem.UserPoints)
+	}
+	if s.Pending() != 0 || s.Prompt() != "todo:default> " {
+		t.Errorf("Expected no pending changes after commit, got %d and prompt %q", s.Pending(), s.Prompt())
+	}
+}
+
+func TestSession_RunsTheCommandsOfTheCLI(t *testing.T) {
+	s, out, cfg := newTestSession(t)
+
+	for _, line := range []string{
+		`add One`,
+		`add Two`,
+		`add Three`,
+		`complete 1-2`,
+		`add reward Cinema --stock 2 --requires-approval`,
+	} {
+		s.Exec(line)
+	}
+	s.Exec("commit")
+
+	todoList, _ := loaders.LoadTodoList(cfg.Storage.TodoFile)
+	for _, task := range todoList.Tasks {
+		if task.IsComplete != (task.ID <= 2) {
+			t.Errorf("Expected tasks 1-2 completed and 3 open, got %+v\n%s", todoList.Tasks, out)
+		}
+	}
+	rewardSystem, _ := loaders.LoadRewardSystem(cfg.Storage.RewardFile, cfg.User)
+	if len(rewardSystem.Rewards) != 1 || rewardSystem.Rewards[0].Stock == nil || *rewardSystem.Rewards[0].Stock != 2 || !rewardSystem.Rewards[0].RequiresApproval {
+		t.Errorf("Expected the reward with stock 2 which needs approval, got %+v", rewardSystem.Rewards)
+	}
+}
+
+func TestSession_RollbackAndConflict(t *testing.T) {
+	s, out, cfg := newTestSession(t)
+
+	s.Exec("add First")
+	s.Exec("rollback")
+	s.Exec("add Second")
+	s.Exec("commit")
+	todoList, _ := loaders.LoadTodoList(cfg.Storage.TodoFile)
+	if len(todoList.Tasks) != 1 || todoList.Tasks[0].Text != "Second" {
+		t.Errorf("Expected rollback to drop the first task, got %+v", todoList.Tasks)
+	}
+
+	s.Exec("add Third")
+	// Another program writes the file before commit.
+	if err := storage.Save(cfg.Storage.TodoFile, todoList); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	out.Reset()
+	s.Exec("commit")
+	if !strings.Contains(out.String(), "changed by another program") || s.Pending() != 1 {
+		t.Errorf("Expected commit to refuse after an outside change, got %q", out)
+	}
+}
+
+func TestSession_ErrorsAndExit(t *testing.T) {
+	s, out, _ := newTestSession(t)
+
+	s.Exec("complete 7")
+	s.Exec(`add "unclosed`)
+	s.Exec("frobnicate")
+	s.Exec("edit pointsdef 5")
+	if got := strings.Count(out.String(), "Error:"); got != 2 {
+		t.Errorf("Expected 2 errors, got %d:\n%s", got, out)
+	}
+	if s.Pending() != 0 {
+		t.Errorf("Expected failed commands to change nothing, got %d pending", s.Pending())
+	}
+
+	if !s.Exec("exit") {
+		t.Error("Expected exit without changes to quit")
+	}
+
+	s.Exec("add Task")
+	if s.Exec("exit") {
+		t.Error("Expected the first exit with pending changes to be refused")
+	}
+	if !s.Exec("quit") {
+		t.Error("Expected the second exit to quit")
+	}
+}
+
+func TestSession_Complete(t *testing.T) {
+	s, _, _ := newTestSession(t)
+	s.Exec("add One")
+	s.Exec("add Two")
+	s.Exec("add reward Cinema -p 0")
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"buy-reward ", []string{"1"}},
+		{"edit ", []string{"approval", "limits", "points", "redescr", "reprice", "stock", "1", "2"}},
+		{"edit reprice ", []string{"1"}},
+		{"complete 1 ", []string{"2"}},
+		{"delete ", []string{"reward", "1", "2"}},
+	}
+	for _, tt := range tests {
+		if got := s.Complete(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Complete(%q) = %q, expected %q", tt.line, got, tt.want)
+		}
+	}
+
+	commands := s.Complete("co")
+	for _, name := range []string{"commit", "complete", "exit", "rollback"} {
+		found := false
+		for _, command := range commands {
+			found = found || command == name
+		}
+		if !found {
+			t.Errorf("Expected %q in the commands, got %q", name, commands)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitWords(t *testing.T) {
+	got, err := splitWords(`add "Buy milk" -p 5 it\'s 'a "b"'`)
+	if err != nil {
+		t.Fatalf("splitWords returned error: %v", err)
+	}
+	want := []string{"add", "Buy milk", "-p", "5", "it's", `a "b"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Layout of an encrypted file: magic | salt | nonce | AES-256-GCM ciphertext.
+// The salt feeds Argon2id, so the same secret gives a different key per file.
+const (
+	encryptedMagic = "TODOENC1"
+	saltSize       = 16
+	keySize        = 32
+
+	argonTime    = 1
+	argonMemory  = 64 * 1024
+	argonThreads = 4
+)
+
+var (
+	ErrNoSecret         = errors.New("file is encrypted, but encryption is not configured (see 'todo encrypt')")
+	ErrAlreadyEncrypted = errors.New("file is already encrypted")
+	ErrNotEncrypted     = errors.New("file is not encrypted")
+)
+
+var (
+	cryptoMu       sync.Mutex
+	secretProvider func() ([]byte, error)
+	secret         []byte
+	derivedKeys    = map[string][]byte{}
+	sessionSalt    []byte
+)
+
+// SetSecretProvider enables encryption for Save. The provider is called once,
+// the first time a secret is needed, so a passphrase prompt only appears for
+// commands that actually touch the data files.
+func SetSecretProvider(provider func() ([]byte, error)) {
+	cryptoMu.Lock()
+	defer cryptoMu.Unlock()
+
+	secretProvider = provider
+	secret = nil
+	derivedKeys = map[string][]byte{}
+	sessionSalt = nil
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedMagic))
+}
+
+// SecretFromKeyFile reads the secret from a key file. Surrounding whitespace
+// is ignored, so a key written with a trailing newline still works.
+func SecretFromKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read key file: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+	return data, nil
+}
+
+// EncryptFile converts a plain data file into the encrypted format in place.
+func EncryptFile(filename string, fileSecret []byte) error {
+	return convertFile(filename, func(data []byte) ([]byte, error) {
+		if IsEncrypted(data) {
+			return nil, ErrAlreadyEncrypted
+		}
+
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		return seal(data, salt, argon2.IDKey(fileSecret, salt, argonTime, argonMemory, argonThreads, keySize))
+	})
+}
+
+// DecryptFile converts an encrypted data file back into plain JSON in place.
+func DecryptFile(filename string, fileSecret []byte) error {
+	return convertFile(filename, func(data []byte) ([]byte, error) {
+		if !IsEncrypted(data) {
+			return nil, ErrNotEncrypted
+		}
+
+		salt, err := saltOf(data)
+		if err != nil {
+			return nil, err
+		}
+		return open(data, argon2.IDKey(fileSecret, salt, argonTime, argonMemory, argonThreads, keySize))
+	})
+}
+
+func convertFile(filename string, convert func([]byte) ([]byte, error)) error {
+	lock, err := lockFile(filename, false)
+	if err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	converted, err := convert(data)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filename, converted)
+}
+
+// encode encrypts data when a secret provider is configured and returns it
+// unchanged otherwise.
+func encode(data []byte) ([]byte, error) {
+	cryptoMu.Lock()
+	defer cryptoMu.Unlock()
+
+	if secretProvider == nil {
+		return data, nil
+	}
+
+	if sessionSalt == nil {
+		sessionSalt = make([]byte, saltSize)
+		if _, err := rand.Read(sessionSalt); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := keyForSalt(sessionSalt)
+	if err != nil {
+		return nil, err
+	}
+	return seal(data, sessionSalt, key)
+}
+
+// decode decrypts data written by encode. Plain JSON passes through, so
+// existing files keep working until they are converted with 'todo encrypt'.
+func decode(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	cryptoMu.Lock()
+	defer cryptoMu.Unlock()
+
+	if secretProvider == nil {
+		return nil, ErrNoSecret
+	}
+
+	salt, err := saltOf(data)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keyForSalt(salt)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, key)
+}
+
+// keyForSalt must be called with cryptoMu held. Argon2 is deliberately slow,
+// so derived keys are cached for the lifetime of the process.
+func keyForSalt(salt []byte) ([]byte, error) {
+	if key, ok := derivedKeys[string(salt)]; ok {
+		return key, nil
+	}
+
+	if secret == nil {
+		s, err := secretProvider()
+		if err != nil {
+			return nil, fmt.Errorf("could not get encryption secret: %w", err)
+		}
+		if len(s) == 0 {
+			return nil, fmt.Errorf("encryption secret is empty")
+		}
+		secret = s
+	}
+
+	key := argon2.IDKey(secret, salt, argonTime, argonMemory, argonThreads, keySize)
+	derivedKeys[string(salt)] = key
+	return key, nil
+}
+
+func seal(plain, salt, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encryptedMagic)+len(salt)+len(nonce))
+	header = append(header, encryptedMagic...)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+
+	// The header is authenticated as additional data, so tampering with the
+	// salt or nonce is detected as well.
+	return gcm.Seal(header, nonce, plain, header), nil
+}
+
+func open(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	headerSize := len(encryptedMagic) + saltSize + gcm.NonceSize()
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("encrypted file is truncated")
+	}
+
+	header := data[:headerSize]
+	nonce := header[len(encryptedMagic)+saltSize:]
+
+	plain, err := gcm.Open(nil, nonce, data[headerSize:], header)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt file (wrong passphrase or key file?)")
+	}
+	return plain, nil
+}
+
+func saltOf(data []byte) ([]byte, error) {
+	if len(data) < len(encryptedMagic)+saltSize {
+		return nil, fmt.Errorf("encrypted file is truncated")
+	}
+	return data[len(encryptedMagic) : len(encryptedMagic)+saltSize], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/internal/logger"
+)
+
+type testData struct {
+	Text string `json:"text"`
+}
+
+func useSecret(t *testing.T, s string) {
+	t.Helper()
+	SetSecretProvider(func() ([]byte, error) { return []byte(s), nil })
+	t.Cleanup(func() { SetSecretProvider(nil) })
+}
+
+func TestSaveLoad_EncryptedRoundTrip(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+	file := filepath.Join(t.TempDir(), "todo.json")
+	useSecret(t, "correct horse battery staple")
+
+	if err := Save(file, testData{Text: "customer Jane Doe"}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Could not read file: %v", err)
+	}
+	if !IsEncrypted(raw) {
+		t.Fatal("Expected file to be written encrypted")
+	}
+	if strings.Contains(string(raw), "Jane") {
+		t.Error("Encrypted file should not contain plain text")
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatalf("Could not stat file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected file mode 0600, got %o", perm)
+	}
+
+	var got testData
+	if err := Load(file, &got); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if got.Text != "customer Jane Doe" {
+		t.Errorf("Expected decrypted text 'customer Jane Doe', got '%s'", got.Text)
+	}
+}
+
+func TestLoad_WrongSecretOrNoSecret(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+	file := filepath.Join(t.TempDir(), "todo.json")
+
+	useSecret(t, "right")
+	if err := Save(file, testData{Text: "secret"}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	useSecret(t, "wrong")
+	var got testData
+	if err := Load(file, &got); err == nil {
+		t.Error("Expected error when loading with a wrong secret")
+	}
+
+	SetSecretProvider(nil)
+	if err := Load(file, &got); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Expected ErrNoSecret without a secret provider, got %v", err)
+	}
+}
+
+func TestEncryptDecryptFile(t *testing.T) {
+	logger.Init(slog.LevelDebug, io.Discard)
+	file := filepath.Join(t.TempDir(), "rewards.json")
+	plain := []byte(`{"text":"plain"}`)
+	if err := os.WriteFile(file, plain, 0644); err != nil {
+		t.Fatalf("Could not write file: %v", err)
+	}
+
+	if err := EncryptFile(file, []byte("key")); err != nil {
+		t.Fatalf("EncryptFile returned error: %v", err)
+	}
+	if err := EncryptFile(file, []byte("key")); !errors.Is(err, ErrAlreadyEncrypted) {
+		t.Errorf("Expected ErrAlreadyEncrypted on second run, got %v", err)
+	}
+
+	if err := DecryptFile(file, []byte("other key")); err == nil {
+		t.Error("Expected error when decrypting with another key")
+	}
+	if err := DecryptFile(file, []byte("key")); err != nil {
+		t.Fatalf("DecryptFile returned error: %v", err)
+	}
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Could not read file: %v", err)
+	}
+	if string(got) != string(plain) {
+		t.Errorf("Expected '%s' after decryption, got '%s'", plain, got)
+	}
+}
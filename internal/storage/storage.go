@@ -1,38 +1,61 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/gofrs/flock"
 	"github.com/svetsed/todo_cli_app/internal/logger"
 )
 
+// The CLI and 'todo serve' may use the same files at the same time, so a
+// locked file is waited for a while before giving up.
+var (
+	LockTimeout    = 5 * time.Second
+	lockRetryDelay = 20 * time.Millisecond
+)
+
+var ErrLocked = errors.New("file is locked by another process")
+
 func Save(filename string, data any) error {
-	lock := flock.New(filename + ".lock")
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
 
-	locked, err := lock.TryLock()
+	lock, err := lockFile(filename, false)
 	if err != nil {
 		return err
 	}
+	defer unlockFile(lock)
 
-	if !locked {
-		return fmt.Errorf("file is locked by another process")
-	}
-	defer func() {
-		if err := lock.Unlock(); err != nil {
-			logger.Error("failed to unlock: %w", err)
-		}
-	}()
+	return save(filename, data)
+}
 
+func save(filename string, data any) error {
 	fileData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return err
 	}
 
+	fileData, err = encode(fileData)
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filename, fileData)
+}
+
+// writeFile replaces filename atomically. Data files may hold personal
+// information, so they are only readable by the owner.
+func writeFile(filename string, fileData []byte) error {
 	tmpFile := filename + ".tmp"
-	if err := os.WriteFile(tmpFile, fileData, 0644); err != nil {
+	if err := os.WriteFile(tmpFile, fileData, 0600); err != nil {
 		return err
 	}
 
@@ -40,23 +63,21 @@ func Save(filename string, data any) error {
 }
 
 func Load(filename string, data any) error {
-	lock := flock.New(filename + ".lock")
+	// Nothing was saved yet, possibly not even the data directory exists.
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
 
-	locked, err := lock.TryRLock()
+	lock, err := lockFile(filename, true)
 	if err != nil {
 		return err
 	}
+	defer unlockFile(lock)
 
-	if !locked {
-		return fmt.Errorf("file is locked by another process")
-	}
-
-	defer func() {
-		if err := lock.Unlock(); err != nil {
-			logger.Error("failed to unlock: %w", err)
-		}
-	}()
+	return load(filename, data)
+}
 
+func load(filename string, data any) error {
 	fileData, err := os.ReadFile(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -67,5 +88,102 @@ func Load(filename string, data any) error {
 	if len(fileData) == 0 {
 		return nil
 	}
+
+	fileData, err = decode(fileData)
+	if err != nil {
+		return err
+	}
 	return json.Unmarshal(fileData, data)
 }
+
+// Tx gives access to the files locked by Update.
+type Tx struct {
+	files map[string]bool
+}
+
+func (tx *Tx) Load(filename string, data any) error {
+	if !tx.files[filename] {
+		return fmt.Errorf("file %s is not locked by this update", filename)
+	}
+	return load(filename, data)
+}
+
+func (tx *Tx) Save(filename string, data any) error {
+	if !tx.files[filename] {
+		return fmt.Errorf("file %s is not locked by this update", filename)
+	}
+	return save(filename, data)
+}
+
+// Update holds exclusive locks of the files while fn loads, changes and saves
+// them, so nobody else can change a file between loading and saving it. The
+// files are locked in sorted order, so two updates never wait for each other.
+func Update(filenames []string, fn func(tx *Tx) error) error {
+	sorted := append([]string(nil), filenames...)
+	sort.Strings(sorted)
+
+	tx := &Tx{files: map[string]bool{}}
+	for _, filename := range sorted {
+		if tx.files[filename] {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			return err
+		}
+
+		lock, err := lockFile(filename, false)
+		if err != nil {
+			return err
+		}
+		defer unlockFile(lock)
+		tx.files[filename] = true
+	}
+
+	return fn(tx)
+}
+
+// Version changes whenever one of the files is written, so a program can
+// tell that another one changed them. A missing file has a version too.
+func Version(files ...string) string {
+	version := ""
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			version += fmt.Sprintf("%d-%d.", info.ModTime().UnixNano(), info.Size())
+		} else {
+			version += "none."
+		}
+	}
+	return version
+}
+
+// lockFile waits up to LockTimeout for the lock next to filename. Locks are
+// per open file, so they also keep apart goroutines of one process.
+func lockFile(filename string, shared bool) (*flock.Flock, error) {
+	lock := flock.New(filename + ".lock")
+
+	ctx, cancel := context.WithTimeout(context.Background(), LockTimeout)
+	defer cancel()
+
+	var (
+		locked bool
+		err    error
+	)
+	if shared {
+		locked, err = lock.TryRLockContext(ctx, lockRetryDelay)
+	} else {
+		locked, err = lock.TryLockContext(ctx, lockRetryDelay)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || (err == nil && !locked) {
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+func unlockFile(lock *flock.Flock) {
+	if err := lock.Unlock(); err != nil {
+		logger.Error("failed to unlock", err)
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdate_HoldsLockUntilSaved(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "todo.json")
+	if err := Save(file, map[string]int{"count": 1}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- Update([]string{file}, func(tx *Tx) error {
+			var data map[string]int
+			if err := tx.Load(file, &data); err != nil {
+				return err
+			}
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			data["count"]++
+			return tx.Save(file, data)
+		})
+	}()
+
+	<-started
+	var data map[string]int
+	if err := Load(file, &data); err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if data["count"] != 2 {
+		t.Errorf("Load should wait for the update and see count 2, got %d", data["count"])
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Update returned error: %v", err)
+	}
+}
+
+func TestUpdate_TimesOutOnLockedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "todo.json")
+	timeout := LockTimeout
+	LockTimeout = 50 * time.Millisecond
+	defer func() { LockTimeout = timeout }()
+
+	err := Update([]string{file}, func(tx *Tx) error {
+		return Save(file, map[string]int{})
+	})
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+	}
+
+	other := filepath.Join(t.TempDir(), "rewards.json")
+	err = Update([]string{file}, func(tx *Tx) error {
+		return tx.Save(other, map[string]int{})
+	})
+	if err == nil {
+		t.Error("Expected error when saving a file that the update did not lock")
+	}
+}
+
+func TestVersion_ChangesWhenAFileIsWritten(t *testing.T) {
+	dir := t.TempDir()
+	todoFile, rewardFile := filepath.Join(dir, "todo.json"), filepath.Join(dir, "rewards.json")
+
+	missing := Version(todoFile, rewardFile)
+	if err := Save(todoFile, map[string]int{"nextId": 1}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	saved := Version(todoFile, rewardFile)
+	if saved == missing {
+		t.Fatal("Expected the version to change when a file is created")
+	}
+	if Version(todoFile, rewardFile) != saved {
+		t.Error("Expected the same version while nothing is written")
+	}
+
+	if err := Save(rewardFile, map[string]int{"userPoints": 5}); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if Version(todoFile, rewardFile) == saved {
+		t.Error("Expected the version to change when the second file is written")
+	}
+}
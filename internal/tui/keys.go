@@ -0,0 +1,88 @@
+package tui
+
+import "unicode/utf8"
+
+// Names of the keys that are not printable. Printable keys are their own
+// text, e.g. "a" or "ж".
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyTab       = "tab"
+	keyEnter     = "enter"
+	keyEsc       = "esc"
+	keyBackspace = "backspace"
+	keySpace     = " "
+	keyCtrlA     = "ctrl+a"
+	keyCtrlC     = "ctrl+c"
+	keyCtrlD     = "ctrl+d"
+	keyCtrlE     = "ctrl+e"
+	keyCtrlU     = "ctrl+u"
+)
+
+// parseKeys splits the bytes read from a terminal in raw mode into keys.
+// Escape sequences of arrow keys come in one read, so a lone ESC byte at the
+// end of the input is the Esc key.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b:
+			if len(b) >= 3 && (b[1] == '[' || b[1] == 'O') {
+				switch b[2] {
+				case 'A':
+					keys = append(keys, keyUp)
+				case 'B':
+					keys = append(keys, keyDown)
+				case 'C':
+					keys = append(keys, keyRight)
+				case 'D':
+					keys = append(keys, keyLeft)
+				}
+				// Skip the rest of longer sequences like "ESC [ 3 ~".
+				i := 2
+				for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+					i++
+				}
+				b = b[min(i+1, len(b)):]
+				continue
+			}
+			keys = append(keys, keyEsc)
+			b = b[1:]
+		case c == 0x01:
+			keys = append(keys, keyCtrlA)
+			b = b[1:]
+		case c == 0x03:
+			keys = append(keys, keyCtrlC)
+			b = b[1:]
+		case c == 0x04:
+			keys = append(keys, keyCtrlD)
+			b = b[1:]
+		case c == 0x05:
+			keys = append(keys, keyCtrlE)
+			b = b[1:]
+		case c == 0x15:
+			keys = append(keys, keyCtrlU)
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, keyTab)
+			b = b[1:]
+		case c == '\r' || c == '\n':
+			keys = append(keys, keyEnter)
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, keyBackspace)
+			b = b[1:]
+		case c < 0x20:
+			b = b[1:]
+		default:
+			r, size := utf8.DecodeRune(b)
+			if r != utf8.RuneError {
+				keys = append(keys, string(r))
+			}
+			b = b[size:]
+		}
+	}
+	return keys
+}
@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// LineEditor reads lines from a terminal with editing, history on the up and
+// down arrows and completion on Tab. Other input is read line by line, so
+// commands may be piped in.
+type LineEditor struct {
+	History []string
+	// Complete returns the words that may stand for the last word of line,
+	// the text before the cursor.
+	Complete func(line string) []string
+
+	reader *bufio.Reader
+}
+
+// ReadLine reads one line. It returns io.EOF after Ctrl-D on an empty line
+// or at the end of the input.
+func (e *LineEditor) ReadLine(in *os.File, out io.Writer, prompt string) (string, error) {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return e.readPlainLine(in)
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("could not switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	s := &lineState{editor: e, history: len(e.History)}
+	buf := make([]byte, 256)
+	for {
+		fmt.Fprint(out, s.render(prompt))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			done, err := s.handleKey(key, out)
+			if err != nil {
+				fmt.Fprint(out, "\r\n")
+				return "", err
+			}
+			if done {
+				fmt.Fprint(out, s.render(prompt)+"\r\n")
+				line := string(s.line)
+				e.remember(line)
+				return line, nil
+			}
+		}
+	}
+}
+
+func (e *LineEditor) readPlainLine(in io.Reader) (string, error) {
+	if e.reader == nil {
+		e.reader = bufio.NewReader(in)
+	}
+	line, err := e.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// remember adds the line to the history, skipping blank lines and repeats.
+func (e *LineEditor) remember(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if len(e.History) > 0 && e.History[len(e.History)-1] == line {
+		return
+	}
+	e.History = append(e.History, line)
+}
+
+// lineState is the line being edited. It is kept apart from the terminal to
+// be tested without one.
+type lineState struct {
+	editor *LineEditor
+	line   []rune
+	pos    int
+	// history is the index of the shown history entry, len(History) for the
+	// new line, which is kept in draft while browsing.
+	history int
+	draft   []rune
+}
+
+// handleKey changes the line for one key. It reports whether the line is
+// done; out gets the list of completions when there are several.
+func (s *lineState) handleKey(key string, out io.Writer) (bool, error) {
+	switch key {
+	case keyEnter:
+		return true, nil
+	case keyCtrlC:
+		s.line, s.pos = nil, 0
+		return true, nil
+	case keyCtrlD:
+		if len(s.line) == 0 {
+			return false, io.EOF
+		}
+	case keyBackspace:
+		if s.pos > 0 {
+			s.line = slices.Delete(s.line, s.pos-1, s.pos)
+			s.pos--
+		}
+	case keyLeft:
+		if s.pos > 0 {
+			s.pos--
+		}
+	case keyRight:
+		if s.pos < len(s.line) {
+			s.pos++
+		}
+	case keyCtrlA:
+		s.pos = 0
+	case keyCtrlE:
+		s.pos = len(s.line)
+	case keyCtrlU:
+		s.line, s.pos = s.line[s.pos:], 0
+	case keyUp:
+		s.browse(-1)
+	case keyDown:
+		s.browse(1)
+	case keyTab:
+		s.complete(out)
+	case keyEsc:
+	default:
+		runes := []rune(key)
+		s.line = slices.Insert(s.line, s.pos, runes...)
+		s.pos += len(runes)
+	}
+	return false, nil
+}
+
+func (s *lineState) browse(step int) {
+	history := s.editor.History
+	next := s.history + step
+	if next < 0 || next > len(history) {
+		return
+	}
+	if s.history == len(history) {
+		s.draft = s.line
+	}
+	s.history = next
+	if next == len(history) {
+		s.line = s.draft
+	} else {
+		s.line = []rune(history[next])
+	}
+	s.pos = len(s.line)
+}
+
+// complete extends the word before the cursor to the longest prefix shared
+// by all completions and lists them if that adds nothing.
+func (s *lineState) complete(out io.Writer) {
+	if s.editor.Complete == nil {
+		return
+	}
+	before := string(s.line[:s.pos])
+	word := before[strings.LastIndexAny(before, " \t")+1:]
+
+	var candidates []string
+	for _, candidate := range s.editor.Complete(before) {
+		if strings.HasPrefix(candidate, word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	insert := commonPrefix(candidates)[len(word):]
+	if len(candidates) == 1 {
+		insert += " "
+	}
+	if insert == "" {
+		fmt.Fprint(out, "\r\n"+strings.Join(candidates, "  ")+"\r\n")
+		return
+	}
+	runes := []rune(insert)
+	s.line = slices.Insert(s.line, s.pos, runes...)
+	s.pos += len(runes)
+}
+
+func (s *lineState) render(prompt string) string {
+	text := "\r" + prompt + string(s.line) + "\x1b[K"
+	if back := len(s.line) - s.pos; back > 0 {
+		text += fmt.Sprintf("\x1b[%dD", back)
+	}
+	return text
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestLineState_HistoryAndCompletion(t *testing.T) {
+	editor := &LineEditor{
+		History: []string{"list", "complete 3"},
+		Complete: func(line string) []string {
+			return []string{"commit", "complete", "list"}
+		},
+	}
+	s := &lineState{editor: editor, history: len(editor.History)}
+	out := &bytes.Buffer{}
+	keys := func(keys ...string) {
+		for _, key := range keys {
+			if _, err := s.handleKey(key, out); err != nil {
+				t.Fatalf("handleKey(%q) returned error: %v", key, err)
+			}
+		}
+	}
+
+	keys("c", "o", keyTab)
+	if string(s.line) != "com" || out.Len() != 0 {
+		t.Errorf("Expected 'co' to be extended to 'com', got %q", string(s.line))
+	}
+	keys(keyTab)
+	if out.String() != "\r\ncommit  complete\r\n" {
+		t.Errorf("Expected the completions to be listed, got %q", out)
+	}
+	keys("p", keyTab)
+	if string(s.line) != "complete " {
+		t.Errorf("Expected 'complete ', got %q", string(s.line))
+	}
+
+	keys(keyUp)
+	if string(s.line) != "complete 3" {
+		t.Errorf("Expected the last history entry, got %q", string(s.line))
+	}
+	keys(keyUp, keyDown, keyDown)
+	if string(s.line) != "complete " {
+		t.Errorf("Expected the draft back after browsing, got %q", string(s.line))
+	}
+
+	keys(keyCtrlA, "x", keyCtrlE, keyBackspace)
+	if string(s.line) != "xcomplete" {
+		t.Errorf("Expected editing at both ends, got %q", string(s.line))
+	}
+
+	keys(keyCtrlE, keyCtrlU)
+	if _, err := s.handleKey(keyCtrlD, out); err != io.EOF {
+		t.Errorf("Expected io.EOF for Ctrl-D on an empty line, got %v", err)
+	}
+}
@@ -0,0 +1,659 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/models"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+type pane int
+
+const (
+	paneTasks pane = iota
+	paneRewards
+)
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeInput
+	modeConfirm
+)
+
+// maxUndo is how many changes can be undone.
+const maxUndo = 50
+
+// snapshot is the data before one change, for undo.
+type snapshot struct {
+	todo    models.TodoList
+	rewards models.RewardSystem
+}
+
+// Model is the state of the full-screen UI. It knows nothing about the
+// terminal: keys come in through HandleKey and the screen is View, so it is
+// tested without one.
+//
+// Every change loads the data files, changes them with the handlers and saves
+// them with storage.Update, so the CLI and todo serve may use the same files.
+type Model struct {
+	todoFile   string
+	rewardFile string
+	defaults   config.DefaultsConfig
+	list       string
+	user       string
+	rules      handlers.Rules
+
+	todo    *models.TodoList
+	rewards *models.RewardSystem
+	version string
+
+	pane   pane
+	cursor [2]int
+	filter string
+
+	mode      mode
+	prompt    string
+	input     []rune
+	onInput   func(text string) error
+	onConfirm func() error
+
+	undo   []snapshot
+	status string
+}
+
+func New(cfg *config.Config) (*Model, error) {
+	m := &Model{
+		todoFile:   cfg.Storage.TodoFile,
+		rewardFile: cfg.Storage.RewardFile,
+		defaults:   cfg.Defaults,
+		list:       cfg.List,
+		user:       cfg.User,
+		rules:      handlers.NewRules(cfg),
+	}
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Model) reload() error {
+	todoList, err := loaders.LoadTodoList(m.todoFile)
+	if err != nil {
+		return err
+	}
+	rewardSystem, err := loaders.LoadRewardSystem(m.rewardFile, m.user)
+	if err != nil {
+		return err
+	}
+	m.todo, m.rewards = todoList, rewardSystem
+	m.version = storage.Version(m.todoFile, m.rewardFile)
+	return nil
+}
+
+// ReloadIfChanged loads the data again when another program changed the
+// files since the last load. The undo history is dropped then: undoing would
+// write over the changes of the other program.
+func (m *Model) ReloadIfChanged() error {
+	if storage.Version(m.todoFile, m.rewardFile) == m.version {
+		return nil
+	}
+	m.undo = nil
+	return m.reload()
+}
+
+// change loads both files, changes them with fn and saves them while they
+// stay locked. The data before the change is kept for undo, the older
+// changes can not be undone any more when another program changed the files
+// in between.
+func (m *Model) change(fn func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error) error {
+	files := []string{m.todoFile, m.rewardFile}
+	return storage.Update(files, func(tx *storage.Tx) error {
+		changedElsewhere := storage.Version(m.todoFile, m.rewardFile) != m.version
+		todoList, err := loaders.LoadTodoListTx(tx, m.todoFile)
+		if err != nil {
+			return err
+		}
+		rewardSystem, err := loaders.LoadRewardSystemTx(tx, m.rewardFile, m.user)
+		if err != nil {
+			return err
+		}
+
+		var before snapshot
+		if err := clone(todoList, &before.todo); err != nil {
+			return err
+		}
+		if err := clone(rewardSystem, &before.rewards); err != nil {
+			return err
+		}
+
+		h := &handlers.TaskHandler{Todo: todoList}
+		rh := &handlers.RewardHandler{RSystem: rewardSystem, Rules: m.rules}
+		if err := fn(h, rh); err != nil {
+			return err
+		}
+
+		if err := tx.Save(m.rewardFile, rh.RSystem); err != nil {
+			return err
+		}
+		if err := tx.Save(m.todoFile, h.Todo); err != nil {
+			return err
+		}
+
+		if changedElsewhere {
+			m.undo = nil
+		}
+		m.undo = append(m.undo, before)
+		if len(m.undo) > maxUndo {
+			m.undo = m.undo[1:]
+		}
+		m.todo, m.rewards = h.Todo, rh.RSystem
+		m.version = storage.Version(m.todoFile, m.rewardFile)
+		return nil
+	})
+}
+
+// errChangedElsewhere is returned by Undo when another program changed the
+// files since the last change of the UI.
+var errChangedElsewhere = errors.New("the files were changed by another program, undo would lose their changes")
+
+// Undo saves the data as it was before the last change. It refuses like the
+// commit of the shell when another program changed the files since then, the
+// data is loaded again and the undo history is dropped.
+func (m *Model) Undo() error {
+	if len(m.undo) == 0 {
+		return fmt.Errorf("nothing to undo")
+	}
+	last := m.undo[len(m.undo)-1]
+
+	err := storage.Update([]string{m.todoFile, m.rewardFile}, func(tx *storage.Tx) error {
+		if storage.Version(m.todoFile, m.rewardFile) != m.version {
+			return errChangedElsewhere
+		}
+		if err := tx.Save(m.rewardFile, &last.rewards); err != nil {
+			return err
+		}
+		return tx.Save(m.todoFile, &last.todo)
+	})
+	if errors.Is(err, errChangedElsewhere) {
+		m.undo = nil
+		if reloadErr := m.reload(); reloadErr != nil {
+			return reloadErr
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	m.undo = m.undo[:len(m.undo)-1]
+	m.todo, m.rewards = &last.todo, &last.rewards
+	m.version = storage.Version(m.todoFile, m.rewardFile)
+	return nil
+}
+
+// clone copies the data through JSON, the way it is stored.
+func clone(src, dst any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// visibleTasks are the tasks matching the filter.
+func (m *Model) visibleTasks() []models.Task {
+	var tasks []models.Task
+	for _, task := range m.todo.Tasks {
+		if matches(task.Text, m.filter) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// visibleRewards are the rewards matching the filter.
+func (m *Model) visibleRewards() []models.Reward {
+	var rewards []models.Reward
+	for _, reward := range m.rewards.Rewards {
+		if matches(reward.Description, m.filter) {
+			rewards = append(rewards, reward)
+		}
+	}
+	return rewards
+}
+
+func matches(text, filter string) bool {
+	return strings.Contains(strings.ToLower(text), strings.ToLower(filter))
+}
+
+// count is the number of visible items in the pane.
+func (m *Model) count(p pane) int {
+	if p == paneTasks {
+		return len(m.visibleTasks())
+	}
+	return len(m.visibleRewards())
+}
+
+// selected returns the ID of the item under the cursor in the active pane.
+func (m *Model) selected() (int, bool) {
+	m.clampCursors()
+	i := m.cursor[m.pane]
+	if m.pane == paneTasks {
+		tasks := m.visibleTasks()
+		if i >= len(tasks) {
+			return 0, false
+		}
+		return tasks[i].ID, true
+	}
+	rewards := m.visibleRewards()
+	if i >= len(rewards) {
+		return 0, false
+	}
+	return rewards[i].ID, true
+}
+
+func (m *Model) clampCursors() {
+	for _, p := range []pane{paneTasks, paneRewards} {
+		if n := m.count(p); m.cursor[p] >= n {
+			m.cursor[p] = max(n-1, 0)
+		}
+	}
+}
+
+// HandleKey changes the state for one key. It reports whether the UI must
+// be closed.
+func (m *Model) HandleKey(key string) bool {
+	switch m.mode {
+	case modeInput:
+		m.handleInputKey(key)
+		return false
+	case modeConfirm:
+		onConfirm := m.onConfirm
+		m.mode, m.onConfirm = modeNormal, nil
+		if key == "y" || key == "Y" {
+			m.report(onConfirm())
+		} else {
+			m.status = "Cancelled"
+		}
+		return false
+	}
+
+	m.status = ""
+	switch key {
+	case "q", keyCtrlC:
+		return true
+	case keyUp, "k":
+		if m.cursor[m.pane] > 0 {
+			m.cursor[m.pane]--
+		}
+	case keyDown, "j":
+		if m.cursor[m.pane] < m.count(m.pane)-1 {
+			m.cursor[m.pane]++
+		}
+	case keyTab, keyLeft, keyRight:
+		m.pane = 1 - m.pane
+	case keySpace, "x":
+		if m.pane == paneTasks {
+			m.toggleTask()
+		}
+	case keyEnter, "b":
+		if m.pane == paneRewards {
+			m.buyReward()
+		}
+	case "a":
+		m.startAdd()
+	case "e":
+		m.startEdit()
+	case "p":
+		m.startEditPoints()
+	case "t":
+		if m.pane == paneTasks {
+			m.startEditDue()
+		}
+	case "d":
+		m.startDelete()
+	case "/":
+		m.ask("Filter: ", m.filter, func(text string) error {
+			m.filter = strings.TrimSpace(text)
+			m.cursor = [2]int{}
+			return nil
+		})
+	case keyEsc:
+		m.filter = ""
+	case "u":
+		if err := m.Undo(); err != nil {
+			m.report(err)
+		} else {
+			m.status = "Last change undone"
+		}
+	case "r":
+		m.report(m.ReloadIfChanged())
+	}
+	return false
+}
+
+func (m *Model) handleInputKey(key string) {
+	switch key {
+	case keyEsc, keyCtrlC:
+		m.mode, m.onInput = modeNormal, nil
+		m.status = "Cancelled"
+	case keyEnter:
+		onInput, text := m.onInput, string(m.input)
+		m.mode, m.onInput = modeNormal, nil
+		m.report(onInput(text))
+	case keyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case keyUp, keyDown, keyLeft, keyRight, keyTab:
+	default:
+		m.input = append(m.input, []rune(key)...)
+	}
+}
+
+// ask shows a prompt with initial text. onInput is called with the text
+// after Enter and may ask again.
+func (m *Model) ask(prompt, initial string, onInput func(text string) error) {
+	m.mode = modeInput
+	m.prompt = prompt
+	m.input = []rune(initial)
+	m.onInput = onInput
+}
+
+func (m *Model) confirm(prompt string, onConfirm func() error) {
+	m.mode = modeConfirm
+	m.prompt = prompt
+	m.onConfirm = onConfirm
+}
+
+// report shows err in the status line. Handlers set the status themselves
+// when they succeed.
+func (m *Model) report(err error) {
+	if err != nil {
+		m.status = "Error: " + err.Error()
+	}
+}
+
+func (m *Model) toggleTask() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+	err := m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+		i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+		if err != nil {
+			return err
+		}
+		if h.Todo.Tasks[i].IsComplete {
+			points, err := h.NotCompletedWithPoints(i, rh)
+			if err != nil {
+				return err
+			}
+			m.status = fmt.Sprintf("Task %d is not completed (-%d points)", id, points)
+			return nil
+		}
+		points, err := h.CompleteWithPoints(i, rh)
+		if err != nil {
+			return err
+		}
+		h.PenalizeOverdue(rh, time.Now())
+		m.status = fmt.Sprintf("Task %d completed (+%d points)", id, points)
+		if len(rh.Notices) > 0 {
+			m.status += ". " + strings.Join(rh.Notices, ". ")
+		}
+		return nil
+	})
+	m.report(err)
+}
+
+func (m *Model) buyReward() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+	err := m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+		i, err := utils.CheckExistItem(id, rh.RSystem.Rewards)
+		if err != nil {
+			return err
+		}
+		if rh.NeedsApproval(i) {
+			request, err := rh.RequestReward(i)
+			if err != nil {
+				return err
+			}
+			rh.UpdateIsAvailableRewards()
+			m.status = fmt.Sprintf("Request %d for '%s' waits for approval, %d points held", request.ID, request.Reward, request.Points)
+			return nil
+		}
+		reward, err := rh.BuyRewards(i)
+		if err != nil {
+			return err
+		}
+		rh.UpdateIsAvailableRewards()
+		m.status = fmt.Sprintf("Bought '%s', balance %d points", reward.Description, rh.RSystem.UserPoints)
+		if len(rh.Notices) > 0 {
+			m.status += ". " + strings.Join(rh.Notices, ". ")
+		}
+		return nil
+	})
+	m.report(err)
+}
+
+func (m *Model) startAdd() {
+	if m.pane == paneTasks {
+		m.ask("New task: ", "", func(text string) error {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				return fmt.Errorf("text of the task must not be empty")
+			}
+			m.ask(fmt.Sprintf("Points for '%s': ", text), fmt.Sprint(m.defaults.TaskPoints), func(count string) error {
+				points, err := utils.ValidatePointsOrPrice(strings.TrimSpace(count))
+				if err != nil {
+					return err
+				}
+				return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+					h.Add(text, points)
+					m.status = fmt.Sprintf("Task %d added", h.Todo.Tasks[len(h.Todo.Tasks)-1].ID)
+					return nil
+				})
+			})
+			return nil
+		})
+		return
+	}
+
+	m.ask("New reward: ", "", func(text string) error {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return fmt.Errorf("description of the reward must not be empty")
+		}
+		m.ask(fmt.Sprintf("Price of '%s': ", text), fmt.Sprint(m.defaults.RewardPrice), func(count string) error {
+			price, err := utils.ValidatePointsOrPrice(strings.TrimSpace(count))
+			if err != nil {
+				return err
+			}
+			return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+				rh.AddReward(text, price)
+				m.status = fmt.Sprintf("Reward %d added", rh.RSystem.Rewards[len(rh.RSystem.Rewards)-1].ID)
+				return nil
+			})
+		})
+		return nil
+	})
+}
+
+func (m *Model) startEdit() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+
+	if m.pane == paneTasks {
+		i, _ := utils.CheckExistItem(id, m.todo.Tasks)
+		m.ask(fmt.Sprintf("Text of task %d: ", id), m.todo.Tasks[i].Text, func(text string) error {
+			text = strings.TrimSpace(text)
+			if text == "" {
+				return fmt.Errorf("text of the task must not be empty")
+			}
+			return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+				i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+				if err != nil {
+					return err
+				}
+				h.Edit(i, text)
+				m.status = fmt.Sprintf("Task %d changed", id)
+				return nil
+			})
+		})
+		return
+	}
+
+	i, _ := utils.CheckExistItem(id, m.rewards.Rewards)
+	m.ask(fmt.Sprintf("Description of reward %d: ", id), m.rewards.Rewards[i].Description, func(text string) error {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return fmt.Errorf("description of the reward must not be empty")
+		}
+		return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+			i, err := utils.CheckExistItem(id, rh.RSystem.Rewards)
+			if err != nil {
+				return err
+			}
+			rh.EditDesrcRewards(i, text)
+			m.status = fmt.Sprintf("Reward %d changed", id)
+			return nil
+		})
+	})
+}
+
+func (m *Model) startEditPoints() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+
+	if m.pane == paneTasks {
+		i, _ := utils.CheckExistItem(id, m.todo.Tasks)
+		m.ask(fmt.Sprintf("Points for task %d: ", id), fmt.Sprint(m.todo.Tasks[i].TaskPoints), func(count string) error {
+			points, err := utils.ValidatePointsOrPrice(strings.TrimSpace(count))
+			if err != nil {
+				return err
+			}
+			return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+				i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+				if err != nil {
+					return err
+				}
+				h.EditTaskPoints(i, points)
+				m.status = fmt.Sprintf("Task %d gives %d points", id, points)
+				return nil
+			})
+		})
+		return
+	}
+
+	i, _ := utils.CheckExistItem(id, m.rewards.Rewards)
+	m.ask(fmt.Sprintf("Price of reward %d: ", id), fmt.Sprint(m.rewards.Rewards[i].PriceOfReward), func(count string) error {
+		price, err := utils.ValidatePointsOrPrice(strings.TrimSpace(count))
+		if err != nil {
+			return err
+		}
+		return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+			i, err := utils.CheckExistItem(id, rh.RSystem.Rewards)
+			if err != nil {
+				return err
+			}
+			rh.EditPriceRewards(i, price)
+			m.status = fmt.Sprintf("Reward %d costs %d points", id, price)
+			return nil
+		})
+	})
+}
+
+// startEditDue asks for the due date of the selected task, an empty one
+// removes it.
+func (m *Model) startEditDue() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+
+	i, _ := utils.CheckExistItem(id, m.todo.Tasks)
+	initial := ""
+	if due := m.todo.Tasks[i].Due; due != nil {
+		initial = due.Format("2006-01-02")
+	}
+	m.ask(fmt.Sprintf("Due date of task %d (empty to remove): ", id), initial, func(text string) error {
+		var due *time.Time
+		if text = strings.TrimSpace(text); text != "" {
+			day, err := utils.ParseDue(text, time.Now())
+			if err != nil {
+				return err
+			}
+			due = &day
+		}
+		return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+			i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+			if err != nil {
+				return err
+			}
+			h.SetDue(i, due)
+			if due != nil {
+				m.status = fmt.Sprintf("Task %d is due %s", id, due.Format("2006-01-02"))
+			} else {
+				m.status = fmt.Sprintf("Task %d has no due date", id)
+			}
+			return nil
+		})
+	})
+}
+
+func (m *Model) startDelete() {
+	id, ok := m.selected()
+	if !ok {
+		return
+	}
+
+	if m.pane == paneTasks {
+		i, _ := utils.CheckExistItem(id, m.todo.Tasks)
+		m.confirm(fmt.Sprintf("Delete task %d. %s? (y/n)", id, m.todo.Tasks[i].Text), func() error {
+			return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+				i, err := utils.CheckExistItem(id, h.Todo.Tasks)
+				if err != nil {
+					return err
+				}
+				penalty := rh.PenalizeDeletion(h.Todo.Tasks[i])
+				h.Delete(i)
+				m.status = fmt.Sprintf("Task %d deleted", id)
+				if penalty > 0 {
+					m.status += fmt.Sprintf(", penalty -%d points", penalty)
+				}
+				return nil
+			})
+		})
+		return
+	}
+
+	i, _ := utils.CheckExistItem(id, m.rewards.Rewards)
+	m.confirm(fmt.Sprintf("Delete reward %d. %s? (y/n)", id, m.rewards.Rewards[i].Description), func() error {
+		return m.change(func(h *handlers.TaskHandler, rh *handlers.RewardHandler) error {
+			i, err := utils.CheckExistItem(id, rh.RSystem.Rewards)
+			if err != nil {
+				return err
+			}
+			rh.DeleteReward(i)
+			m.status = fmt.Sprintf("Reward %d deleted", id)
+			return nil
+		})
+	})
+}
@@ -0,0 +1,249 @@
+package tui
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/svetsed/todo_cli_app/internal/config"
+	"github.com/svetsed/todo_cli_app/internal/handlers"
+	"github.com/svetsed/todo_cli_app/internal/loaders"
+	"github.com/svetsed/todo_cli_app/internal/storage"
+)
+
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Storage: config.StorageConfig{
+			TodoFile:   filepath.Join(dir, "todo.json"),
+			RewardFile: filepath.Join(dir, "rewards.json"),
+		},
+		Defaults: config.DefaultsConfig{TaskPoints: 20, RewardPrice: 30},
+		List:     "default",
+	}
+
+	m, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return m
+}
+
+func press(m *Model, keys ...string) {
+	for _, key := range keys {
+		m.HandleKey(key)
+	}
+}
+
+func typeText(m *Model, text string) {
+	for _, r := range text {
+		m.HandleKey(string(r))
+	}
+}
+
+func TestModel_AddCompleteBuyAndUndo(t *testing.T) {
+	m := newTestModel(t)
+
+	press(m, "a")
+	typeText(m, "Write report")
+	press(m, keyEnter, keyBackspace, keyBackspace)
+	typeText(m, "25")
+	press(m, keyEnter)
+	if len(m.todo.Tasks) != 1 || m.todo.Tasks[0].Text != "Write report" || m.todo.Tasks[0].TaskPoints != 25 {
+		t.Fatalf("Expected task 'Write report' for 25 points, got %+v (status %q)", m.todo.Tasks, m.status)
+	}
+
+	press(m, keySpace)
+	if !m.todo.Tasks[0].IsComplete || m.rewards.UserPoints != 25 {
+		t.Fatalf("Expected completed task and 25 points, got %+v and %d", m.todo.Tasks[0], m.rewards.UserPoints)
+	}
+
+	press(m, keyTab, "a")
+	typeText(m, "Cinema")
+	press(m, keyEnter, keyBackspace, keyBackspace)
+	typeText(m, "15")
+	press(m, keyEnter, "b")
+	if m.rewards.UserPoints != 10 {
+		t.Fatalf("Expected balance 10 after purchase, got %d (status %q)", m.rewards.UserPoints, m.status)
+	}
+	press(m, "b")
+	if m.rewards.UserPoints != 10 || !strings.HasPrefix(m.status, "Error:") {
+		t.Errorf("Expected an error without enough points, got balance %d and status %q", m.rewards.UserPoints, m.status)
+	}
+
+	press(m, "u")
+	if m.rewards.UserPoints != 25 {
+		t.Errorf("Expected undo to give back the points, got %d", m.rewards.UserPoints)
+	}
+
+	// Undo is saved, not only shown.
+	rewardSystem, err := loaders.LoadRewardSystem(m.rewardFile, m.user)
+	if err != nil {
+		t.Fatalf("LoadRewardSystem returned error: %v", err)
+	}
+	if rewardSystem.UserPoints != 25 {
+		t.Errorf("Expected 25 points in the file after undo, got %d", rewardSystem.UserPoints)
+	}
+}
+
+func TestModel_UndoKeepsChangesOfOthers(t *testing.T) {
+	m := newTestModel(t)
+	press(m, "a")
+	typeText(m, "Mine")
+	press(m, keyEnter, keyEnter)
+
+	// The CLI adds a task while the UI is open.
+	todoList, err := loaders.LoadTodoList(m.todoFile)
+	if err != nil {
+		t.Fatalf("LoadTodoList returned error: %v", err)
+	}
+	h := &handlers.TaskHandler{Todo: todoList}
+	h.Add("From the CLI", 5)
+	if err := storage.Save(m.todoFile, h.Todo); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	press(m, "u")
+	if !strings.HasPrefix(m.status, "Error: the files were changed by another program") {
+		t.Errorf("Expected undo to be refused, got status %q", m.status)
+	}
+	if len(m.todo.Tasks) != 2 {
+		t.Errorf("Expected both tasks after the refused undo, got %+v", m.todo.Tasks)
+	}
+	press(m, "u")
+	if m.status != "Error: nothing to undo" {
+		t.Errorf("Expected the undo history to be dropped, got %q", m.status)
+	}
+
+	todoList, err = loaders.LoadTodoList(m.todoFile)
+	if err != nil {
+		t.Fatalf("LoadTodoList returned error: %v", err)
+	}
+	if len(todoList.Tasks) != 2 {
+		t.Errorf("Expected the task of the CLI to stay in the file, got %+v", todoList.Tasks)
+	}
+}
+
+func TestModel_EditDeleteAndFilter(t *testing.T) {
+	m := newTestModel(t)
+	for _, text := range []string{"Buy milk", "Call mom", "Buy bread"} {
+		press(m, "a")
+		typeText(m, text)
+		press(m, keyEnter, keyEnter)
+	}
+
+	press(m, "/")
+	typeText(m, "buy")
+	press(m, keyEnter)
+	if got := len(m.visibleTasks()); got != 2 {
+		t.Fatalf("Expected 2 tasks matching 'buy', got %d", got)
+	}
+
+	// The cursor moves over the filtered tasks only.
+	press(m, keyDown, "e")
+	for range "Buy bread" {
+		press(m, keyBackspace)
+	}
+	typeText(m, "Buy rye bread")
+	press(m, keyEnter)
+	if m.todo.Tasks[2].Text != "Buy rye bread" {
+		t.Errorf("Expected task 3 to be edited, got %+v", m.todo.Tasks)
+	}
+
+	press(m, "d", "n")
+	if len(m.todo.Tasks) != 3 {
+		t.Errorf("Expected no deletion after 'n', got %d tasks", len(m.todo.Tasks))
+	}
+	press(m, "d", "y")
+	if len(m.todo.Tasks) != 2 || m.todo.Tasks[1].Text != "Call mom" {
+		t.Errorf("Expected task 3 to be deleted, got %+v", m.todo.Tasks)
+	}
+
+	press(m, keyEsc)
+	if m.filter != "" || len(m.visibleTasks()) != 2 {
+		t.Errorf("Expected Esc to clear the filter, got %q", m.filter)
+	}
+
+	screen := m.View(80, 24)
+	if !strings.Contains(screen, "Call mom") || !strings.Contains(screen, "Balance: 0 points") {
+		t.Errorf("Unexpected screen:\n%s", screen)
+	}
+}
+
+func TestModel_EditDue(t *testing.T) {
+	m := newTestModel(t)
+	press(m, "a")
+	typeText(m, "Pay taxes")
+	press(m, keyEnter, keyEnter)
+
+	press(m, "t")
+	typeText(m, "2030-04-15")
+	press(m, keyEnter)
+	if due := m.todo.Tasks[0].Due; due == nil || due.Format("2006-01-02") != "2030-04-15" {
+		t.Fatalf("Expected the due date 2030-04-15, got %v (status %q)", due, m.status)
+	}
+	if screen := m.View(80, 24); !strings.Contains(screen, "due 04-15") {
+		t.Errorf("Expected the due date on the screen:\n%s", screen)
+	}
+
+	// The current date is the initial text, removing it removes the date.
+	press(m, "t")
+	for range "2030-04-15" {
+		press(m, keyBackspace)
+	}
+	press(m, keyEnter)
+	if m.todo.Tasks[0].Due != nil {
+		t.Errorf("Expected the due date to be removed, got %v", m.todo.Tasks[0].Due)
+	}
+}
+
+func TestModel_InvalidInput(t *testing.T) {
+	m := newTestModel(t)
+
+	press(m, "a", keyEnter)
+	if len(m.todo.Tasks) != 0 || !strings.HasPrefix(m.status, "Error:") {
+		t.Errorf("Expected an error for empty text, got %+v and status %q", m.todo.Tasks, m.status)
+	}
+
+	press(m, "a")
+	typeText(m, "Task")
+	press(m, keyEnter)
+	typeText(m, "x")
+	press(m, keyEnter)
+	if len(m.todo.Tasks) != 0 || !strings.HasPrefix(m.status, "Error:") {
+		t.Errorf("Expected an error for points '20x', got %+v and status %q", m.todo.Tasks, m.status)
+	}
+
+	press(m, "u")
+	if m.status != "Error: nothing to undo" {
+		t.Errorf("Expected nothing to undo, got %q", m.status)
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("a\x1b[A\x1b[B\tж\r\x7f\x1b[3~ \x1b"))
+	want := []string{"a", keyUp, keyDown, keyTab, "ж", keyEnter, keyBackspace, keySpace, keyEsc}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		count, cursor, size int
+		start, end          int
+	}{
+		{3, 0, 5, 0, 3},
+		{10, 0, 4, 0, 4},
+		{10, 5, 4, 3, 7},
+		{10, 9, 4, 6, 10},
+	}
+	for _, tt := range tests {
+		start, end := window(tt.count, tt.cursor, tt.size)
+		if start != tt.start || end != tt.end {
+			t.Errorf("window(%d, %d, %d) = %d, %d, expected %d, %d", tt.count, tt.cursor, tt.size, start, end, tt.start, tt.end)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Package tui is the full-screen terminal UI of todo tui. It draws with ANSI
+// escape sequences and needs nothing but golang.org/x/term.
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Run shows the UI of m on the terminal until the user quits.
+func Run(m *Model, in *os.File, out io.Writer) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("todo tui needs a terminal")
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("could not switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	// Alternative screen and hidden cursor, the old screen comes back on exit.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 256)
+	for {
+		if err := m.ReloadIfChanged(); err != nil {
+			m.report(err)
+		}
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+m.View(width, height))
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			if m.HandleKey(key) {
+				return nil
+			}
+		}
+	}
+}
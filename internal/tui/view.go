@@ -0,0 +1,177 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/svetsed/todo_cli_app/internal/utils"
+)
+
+const (
+	reverse = "\x1b[7m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	reset   = "\x1b[0m"
+)
+
+const help = "↑↓ move  Tab switch  Space complete  b buy  a add  e edit  p points  t due  d delete  / filter  u undo  q quit"
+
+// View draws the whole screen. Lines end with "\r\n": the terminal is in raw
+// mode and does not return the carriage by itself.
+func (m *Model) View(width, height int) string {
+	m.clampCursors()
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+
+	var lines []string
+	header := fmt.Sprintf("todo — list '%s'", m.list)
+	balance := fmt.Sprintf("Balance: %d points", m.rewards.UserPoints)
+	lines = append(lines, bold+pad(header, width-runeLen(balance))+balance+reset)
+	if m.filter != "" {
+		lines = append(lines, dim+fmt.Sprintf("Filter: %q (Esc to clear)", m.filter)+reset)
+	}
+
+	// Header, titles of both panes, a blank line and three lines at the bottom.
+	room := height - len(lines) - 6
+	taskRows := max(room/2, 1)
+	rewardRows := max(room-taskRows, 1)
+
+	lines = append(lines, m.title("Tasks", paneTasks))
+	lines = append(lines, m.taskLines(width, taskRows)...)
+	lines = append(lines, "")
+	lines = append(lines, m.title(fmt.Sprintf("Reward shop (balance %d)", m.rewards.UserPoints), paneRewards))
+	lines = append(lines, m.rewardLines(width, rewardRows)...)
+
+	for len(lines) < height-3 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, m.status)
+	switch m.mode {
+	case modeInput:
+		lines = append(lines, m.prompt+string(m.input)+"█")
+		lines = append(lines, dim+"Enter accept  Esc cancel"+reset)
+	case modeConfirm:
+		lines = append(lines, m.prompt, "")
+	default:
+		lines = append(lines, "", dim+truncate(help, width)+reset)
+	}
+
+	return strings.Join(lines, "\r\n")
+}
+
+func (m *Model) title(text string, p pane) string {
+	if m.pane == p {
+		return bold + "▸ " + text + reset
+	}
+	return "  " + text
+}
+
+func (m *Model) taskLines(width, rows int) []string {
+	tasks := m.visibleTasks()
+	if len(tasks) == 0 {
+		if m.filter != "" {
+			return []string{dim + "  No tasks match the filter" + reset}
+		}
+		return []string{dim + "  No tasks, well done!" + reset}
+	}
+
+	start, end := window(len(tasks), m.cursor[paneTasks], rows)
+	var lines []string
+	for i := start; i < end; i++ {
+		task := tasks[i]
+		status := " "
+		if task.IsComplete {
+			status = "✓"
+		}
+		text := fmt.Sprintf("  [%s] %d. %s", status, task.ID, task.Text)
+		points := fmt.Sprintf("%d points", task.TaskPoints)
+		if task.Due != nil && !task.IsComplete {
+			points = fmt.Sprintf("%d points, due %s", task.TaskPoints, task.Due.Format("01-02"))
+		}
+		lines = append(lines, m.row(paneTasks, i, text, points, width))
+	}
+	return lines
+}
+
+func (m *Model) rewardLines(width, rows int) []string {
+	rewards := m.visibleRewards()
+	if len(rewards) == 0 {
+		if m.filter != "" {
+			return []string{dim + "  No rewards match the filter" + reset}
+		}
+		return []string{dim + "  The rewards was not added" + reset}
+	}
+
+	start, end := window(len(rewards), m.cursor[paneRewards], rows)
+	var lines []string
+	for i := start; i < end; i++ {
+		reward := rewards[i]
+		need, available := utils.CalculateIsAvailableReward(utils.SpendablePoints(m.rewards, reward), reward.PriceOfReward)
+		price := fmt.Sprintf("%d points", reward.PriceOfReward)
+		if !utils.InStock(reward) {
+			available = false
+			price = fmt.Sprintf("%d points, out of stock", reward.PriceOfReward)
+		} else if next := utils.NextAvailable(reward, time.Now()); next.After(time.Now()) {
+			available = false
+			price = fmt.Sprintf("%d points, again at %s", reward.PriceOfReward, next.Format("01-02 15:04"))
+		} else if !available {
+			price = fmt.Sprintf("%d points, need %d more", reward.PriceOfReward, need)
+		}
+		mark := "  "
+		if available {
+			mark = "$ "
+		}
+		text := fmt.Sprintf("  %s%d. %s", mark, reward.ID, reward.Description)
+		lines = append(lines, m.row(paneRewards, i, text, price, width))
+	}
+	return lines
+}
+
+// row draws one item with the right column aligned to the screen edge and
+// highlights the item under the cursor of the active pane.
+func (m *Model) row(p pane, i int, text, right string, width int) string {
+	line := pad(truncate(text, width-runeLen(right)-1), width-runeLen(right)) + right
+	if m.pane == p && m.cursor[p] == i {
+		return reverse + line + reset
+	}
+	return line
+}
+
+// window returns the range of count items to show in size rows so that the
+// cursor is visible.
+func window(count, cursor, size int) (int, int) {
+	if count <= size {
+		return 0, count
+	}
+	start := max(cursor-size/2, 0)
+	start = min(start, count-size)
+	return start, start + size
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+func pad(s string, width int) string {
+	if n := runeLen(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if runeLen(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max(width-1, 0)]) + "…"
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// NormalizeUser makes user names match regardless of case and spaces.
+func NormalizeUser(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SwitchAccount makes the account of user the active one: its balance,
+// ledger, goal, streak, profile and purchases move into the fields of the
+// reward system and those of the previous user go to Accounts.
+func SwitchAccount(rs *models.RewardSystem, user string) {
+	user = NormalizeUser(user)
+	if user == rs.User {
+		return
+	}
+
+	if rs.Accounts == nil {
+		rs.Accounts = map[string]*models.Account{}
+	}
+	rs.Accounts[rs.User] = takeAccount(rs)
+
+	next := rs.Accounts[user]
+	delete(rs.Accounts, user)
+	if next == nil {
+		next = &models.Account{}
+	}
+	putAccount(rs, next)
+	rs.User = user
+	// The rewards which are available depend on the balance.
+	rs.IsUserPointsUpdate = true
+}
+
+// Accounts returns the accounts of all users by name, the active one too.
+func Accounts(rs *models.RewardSystem) map[string]*models.Account {
+	accounts := make(map[string]*models.Account, len(rs.Accounts)+1)
+	for name, account := range rs.Accounts {
+		accounts[name] = account
+	}
+	accounts[rs.User] = takeAccount(rs)
+	return accounts
+}
+
+// UserNames returns the names of all users sorted.
+func UserNames(rs *models.RewardSystem) []string {
+	names := make([]string, 0, len(rs.Accounts)+1)
+	for name := range Accounts(rs) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func takeAccount(rs *models.RewardSystem) *models.Account {
+	account := &models.Account{
+		UserPoints: rs.UserPoints,
+		Ledger:     rs.Ledger,
+		Goal:       rs.Goal,
+		Streak:     rs.Streak,
+		Profile:    rs.Profile,
+	}
+	for i := range rs.Rewards {
+		if len(rs.Rewards[i].Purchases) == 0 {
+			continue
+		}
+		if account.Purchases == nil {
+			account.Purchases = map[string][]time.Time{}
+		}
+		account.Purchases[rs.Rewards[i].UID] = rs.Rewards[i].Purchases
+	}
+	return account
+}
+
+func putAccount(rs *models.RewardSystem, account *models.Account) {
+	rs.UserPoints = account.UserPoints
+	rs.Ledger = account.Ledger
+	rs.Goal = account.Goal
+	rs.Streak = account.Streak
+	rs.Profile = account.Profile
+	for i := range rs.Rewards {
+		rs.Rewards[i].Purchases = account.Purchases[rs.Rewards[i].UID]
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestSwitchAccount(t *testing.T) {
+	bought := time.Now()
+	rs := &models.RewardSystem{
+		User:       "alice",
+		UserPoints: 50,
+		Goal:       &models.Goal{RewardID: 1},
+		Rewards:    []models.Reward{{ID: 1, UID: "r-1", Purchases: []time.Time{bought}}},
+	}
+
+	SwitchAccount(rs, " Bob ")
+	if rs.User != "bob" || rs.UserPoints != 0 || rs.Goal != nil || rs.Rewards[0].Purchases != nil {
+		t.Fatalf("Expected an empty account of bob, got %+v", rs)
+	}
+	rs.UserPoints = 7
+
+	SwitchAccount(rs, "alice")
+	if rs.UserPoints != 50 || rs.Goal == nil || len(rs.Rewards[0].Purchases) != 1 {
+		t.Fatalf("Expected the account of alice back, got %+v", rs)
+	}
+	if accounts := Accounts(rs); accounts["bob"].UserPoints != 7 || accounts["alice"].UserPoints != 50 {
+		t.Errorf("Expected both accounts, got %+v", accounts)
+	}
+	if names := UserNames(rs); len(names) != 2 || names[0] != "alice" {
+		t.Errorf("Expected alice and bob, got %v", names)
+	}
+}
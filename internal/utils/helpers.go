@@ -1,8 +1,11 @@
 package utils
 
 import (
+	"crypto/rand"
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
 )
@@ -18,44 +21,119 @@ func ValidatePointsOrPrice(countString string) (int, error) {
 	return newCount, nil
 }
 
-func ValidateID(idString string, maxIndex int) (int, error) {
+// minUIDPrefix is the shortest prefix of a UUID accepted as an ID, shorter
+// ones would be taken for display IDs or match too much.
+const minUIDPrefix = 4
+
+// ValidateID resolves an ID given by the user: the short display ID or a
+// unique prefix of the UUID of an item in someSliceOfItems ([]models.Task or
+// []models.Reward). It returns the display ID. A number is a display ID first;
+// whether such an item exists is checked by CheckExistItem.
+func ValidateID(idString string, someSliceOfItems any) (int, error) {
+	ids, uids, err := identifiers(someSliceOfItems)
+	if err != nil {
+		return -1, err
+	}
+
+	idString = strings.TrimSpace(idString)
 	id, err := strconv.Atoi(idString)
-	if err != nil || id < 1 || id > maxIndex {
-		return -1, fmt.Errorf("incorrect id")
+	isDisplayID := err == nil && id >= 1
+	if isDisplayID && slices.Contains(ids, id) {
+		return id, nil
 	}
-	return id, nil
-}
 
-func CalculateIsAvailableReward(userPoints int, price int) (int, bool) {
-	if userPoints >= price {
-		return userPoints - price, true
+	if len(idString) >= minUIDPrefix {
+		found := -1
+		for i, uid := range uids {
+			if uid == "" || !strings.HasPrefix(strings.ToLower(uid), strings.ToLower(idString)) {
+				continue
+			}
+			if found != -1 {
+				return -1, fmt.Errorf("id %s is ambiguous, give more characters of the uuid", idString)
+			}
+			found = i
+		}
+		if found != -1 {
+			return ids[found], nil
+		}
 	}
-	return price - userPoints, false
+
+	if isDisplayID {
+		return id, nil
+	}
+	return -1, fmt.Errorf("incorrect id")
 }
 
-func CheckExistItem(id int, someSliceOfItems any) (int, error) {
-	var indexElem int = -1
+// NewUUID returns a random UUID (version 4), the permanent identifier of a
+// task or a reward.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
 
+// identifiers returns the display IDs and the UUIDs of the items.
+func identifiers(someSliceOfItems any) ([]int, []string, error) {
+	var (
+		ids  []int
+		uids []string
+	)
 	switch sliceOfItems := someSliceOfItems.(type) {
 	case []models.Task:
-		for i, item := range sliceOfItems {
-			if item.ID == id {
-				indexElem = i
-				break
-			}
+		for _, item := range sliceOfItems {
+			ids = append(ids, item.ID)
+			uids = append(uids, item.UID)
 		}
 	case []models.Reward:
-		for i, item := range sliceOfItems {
-			if item.ID == id {
-				indexElem = i
-				break
-			}
+		for _, item := range sliceOfItems {
+			ids = append(ids, item.ID)
+			uids = append(uids, item.UID)
 		}
 	default:
-		return -1, fmt.Errorf("give not available type of slice")
+		return nil, nil, fmt.Errorf("give not available type of slice")
+	}
+	return ids, uids, nil
+}
+
+func CalculateIsAvailableReward(userPoints int, price int) (int, bool) {
+	if userPoints >= price {
+		return userPoints - price, true
 	}
+	return price - userPoints, false
+}
 
+// SpendablePoints returns the points that can be spent on the reward: the
+// points reserved for the goal only count for the reward of the goal.
+func SpendablePoints(rewardSystem *models.RewardSystem, reward models.Reward) int {
+	goal := rewardSystem.Goal
+	if goal == nil || goal.RewardID == reward.ID {
+		return rewardSystem.UserPoints
+	}
+	return rewardSystem.UserPoints - goal.Reserved
+}
+
+// InStock reports whether the reward can still be bought, rewards without a
+// stock are never sold out.
+func InStock(reward models.Reward) bool {
+	return reward.Stock == nil || *reward.Stock > 0
+}
+
+// CheckExistItem returns the index of the item with the display ID.
+func CheckExistItem(id int, someSliceOfItems any) (int, error) {
+	ids, _, err := identifiers(someSliceOfItems)
+	if err != nil {
+		return -1, err
+	}
+
+	indexElem := slices.Index(ids, id)
 	if indexElem == -1 {
+		if _, ok := someSliceOfItems.([]models.Reward); ok {
+			return -1, fmt.Errorf("reward %d was not found", id)
+		}
 		return -1, fmt.Errorf("task %d was not found", id)
 	}
 
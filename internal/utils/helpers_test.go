@@ -1,58 +1,71 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/svetsed/todo_cli_app/internal/models"
 )
 
 func TestValidateID(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, UID: "3f2a9c1e-0000-4000-8000-000000000001"},
+		{ID: 5, UID: "3f2a7d40-0000-4000-8000-000000000005"},
+		{ID: 10, UID: "a91c0b2e-0000-4000-8000-000000000010"},
+	}
+
 	testCases := []struct {
 		name      string
 		inputID   string
-		maxIndex  int
 		wantID    int
 		shouldErr bool
 	}{
 		{
 			name:      "Correct ID in the middle of the range",
 			inputID:   "5",
-			maxIndex:  10,
 			wantID:    5,
 			shouldErr: false,
 		},
 		{
 			name:      "Limit value: min ID",
 			inputID:   "1",
-			maxIndex:  10,
 			wantID:    1,
 			shouldErr: false,
 		},
 		{
-			name:      "Limit value: max ID",
-			inputID:   "10",
-			maxIndex:  10,
+			name:      "Not existing ID is left to CheckExistItem",
+			inputID:   "11",
+			wantID:    11,
+			shouldErr: false,
+		},
+		{
+			name:      "Unique prefix of the UUID",
+			inputID:   "a91c",
 			wantID:    10,
 			shouldErr: false,
 		},
 		{
-			name:      "Incorrect ID: zero",
-			inputID:   "0",
-			maxIndex:  10,
+			name:      "Prefix of the UUID in upper case",
+			inputID:   "3F2A7",
+			wantID:    5,
+			shouldErr: false,
+		},
+		{
+			name:      "Ambiguous prefix of the UUID",
+			inputID:   "3f2a",
 			wantID:    -1,
 			shouldErr: true,
 		},
 		{
-			name:      "Incorrect ID: more then max index",
-			inputID:   "11",
-			maxIndex:  10,
+			name:      "Incorrect ID: zero",
+			inputID:   "0",
 			wantID:    -1,
 			shouldErr: true,
 		},
 		{
 			name:      "Incorrect value: not a number",
 			inputID:   "abc",
-			maxIndex:  10,
 			wantID:    -1,
 			shouldErr: true,
 		},
@@ -60,7 +73,7 @@ func TestValidateID(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			gotID, err := ValidateID(tc.inputID, tc.maxIndex)
+			gotID, err := ValidateID(tc.inputID, tasks)
 
 			if (err != nil) != tc.shouldErr {
 				t.Fatalf("ValidateID() return = %v, expected shouldErr=%v", err, tc.shouldErr)
@@ -73,6 +86,13 @@ func TestValidateID(t *testing.T) {
 	}
 }
 
+func TestNewUUID(t *testing.T) {
+	first, second := NewUUID(), NewUUID()
+	if len(first) != 36 || first[14] != '4' || first == second {
+		t.Errorf("Expected two different version 4 UUIDs, got %q and %q", first, second)
+	}
+}
+
 func TestValidatePointsOrPrice(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -131,6 +151,10 @@ func TestCheckExistItem(t *testing.T) {
 		{ID: 2, Description: "Посмотреть сериал"},
 		{ID: 3, Description: "Порисовать"},
 	}
+	for i := range sliceOfTasks {
+		sliceOfTasks[i].UID = fmt.Sprintf("c0ffee%02d-0000-4000-8000-000000000000", i+1)
+		sliceOfRewards[i].UID = sliceOfTasks[i].UID
+	}
 
 	testCases := []struct {
 		name          string
@@ -205,6 +229,9 @@ func TestCheckExistItem(t *testing.T) {
 			if !tc.shouldErr && gotIndexRewards != tc.expectedIndex {
 				t.Errorf("CheckExistItem() return index %d, expected %d", gotIndexRewards, tc.expectedIndex)
 			}
+			if tc.shouldErr && !strings.HasPrefix(err.Error(), "reward ") {
+				t.Errorf("CheckExistItem() return error %q, expected it about a reward", err)
+			}
 		})
 	}
 }
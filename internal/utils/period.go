@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// Periods of restocks and purchase limits of rewards.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// ParsePeriod accepts day, week and month, also as daily, weekly and
+// monthly, and returns the name kept in the files.
+func ParsePeriod(period string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(period)) {
+	case "day", "daily":
+		return PeriodDay, nil
+	case "week", "weekly":
+		return PeriodWeek, nil
+	case "month", "monthly":
+		return PeriodMonth, nil
+	}
+	return "", fmt.Errorf("unknown period %q, use day, week or month", period)
+}
+
+// PeriodStart returns the local midnight the period containing t began at.
+// Weeks begin on Monday.
+func PeriodStart(period string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// NextPeriodStart returns the local midnight the period after the one
+// containing t begins at.
+func NextPeriodStart(period string, t time.Time) time.Time {
+	start := PeriodStart(period, t)
+	switch period {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// ParseCooldown parses a cooldown like "90m", "12h" or "2d".
+func ParseCooldown(cooldown string) (time.Duration, error) {
+	cooldown = strings.TrimSpace(cooldown)
+	if days, ok := strings.CutSuffix(cooldown, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("incorrect cooldown %q", cooldown)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(cooldown)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("incorrect cooldown %q, use e.g. 90m, 12h or 2d", cooldown)
+	}
+	return d, nil
+}
+
+// ParseLimit parses a purchase limit like "2/week" or "1/day".
+func ParseLimit(limit string) (int, string, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(limit), "/")
+	if !ok {
+		return 0, "", fmt.Errorf("incorrect limit %q, use e.g. 2/week", limit)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n < 1 {
+		return 0, "", fmt.Errorf("incorrect count in limit %q", limit)
+	}
+	period, err = ParsePeriod(period)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, period, nil
+}
+
+// NextAvailable returns when the cooldown and the limit of the reward let
+// it be bought again, or now if they do already.
+func NextAvailable(reward models.Reward, now time.Time) time.Time {
+	next := now
+	if len(reward.Purchases) == 0 {
+		return next
+	}
+
+	if cooldown, err := ParseCooldown(reward.Cooldown); err == nil && reward.Cooldown != "" {
+		last := reward.Purchases[len(reward.Purchases)-1]
+		if until := last.Add(cooldown); until.After(next) {
+			next = until
+		}
+	}
+
+	if reward.Limit > 0 && reward.LimitPeriod != "" {
+		start := PeriodStart(reward.LimitPeriod, now)
+		bought := 0
+		for _, purchase := range reward.Purchases {
+			if !purchase.Before(start) {
+				bought++
+			}
+		}
+		if until := NextPeriodStart(reward.LimitPeriod, now); bought >= reward.Limit && until.After(next) {
+			next = until
+		}
+	}
+	return next
+}
+
+// RecentPurchases keeps the last purchase and those of the current limit
+// period, older ones matter neither for the cooldown nor for the limit.
+func RecentPurchases(reward models.Reward, now time.Time) []time.Time {
+	if len(reward.Purchases) == 0 {
+		return nil
+	}
+	var start time.Time
+	if reward.Limit > 0 && reward.LimitPeriod != "" {
+		start = PeriodStart(reward.LimitPeriod, now)
+	}
+
+	last := len(reward.Purchases) - 1
+	var recent []time.Time
+	for i, purchase := range reward.Purchases {
+		if i == last || (!start.IsZero() && !purchase.Before(start)) {
+			recent = append(recent, purchase)
+		}
+	}
+	return recent
+}
+
+// ParseDue parses a due date: a day like 2025-03-01, today, tomorrow or a
+// number of days from now like +3d. The task is overdue after that day.
+func ParseDue(due string, now time.Time) (time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	due = strings.ToLower(strings.TrimSpace(due))
+	switch due {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if days, ok := strings.CutPrefix(due, "+"); ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(days, "d"))
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("incorrect due date %q", due)
+		}
+		return today.AddDate(0, 0, n), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", due, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("incorrect due date %q, use e.g. 2025-03-01, today, tomorrow or +3d", due)
+	}
+	return day, nil
+}
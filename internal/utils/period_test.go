@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2025, time.January, 15, 18, 30, 0, 0, time.Local)
+
+	testCases := []struct {
+		period    string
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{PeriodDay, time.Date(2025, time.January, 15, 0, 0, 0, 0, time.Local), time.Date(2025, time.January, 16, 0, 0, 0, 0, time.Local)},
+		{PeriodWeek, time.Date(2025, time.January, 13, 0, 0, 0, 0, time.Local), time.Date(2025, time.January, 20, 0, 0, 0, 0, time.Local)},
+		{PeriodMonth, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.Local), time.Date(2025, time.February, 1, 0, 0, 0, 0, time.Local)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.period, func(t *testing.T) {
+			if got := PeriodStart(tc.period, now); !got.Equal(tc.wantStart) {
+				t.Errorf("PeriodStart() = %v, expected %v", got, tc.wantStart)
+			}
+			if got := NextPeriodStart(tc.period, now); !got.Equal(tc.wantNext) {
+				t.Errorf("NextPeriodStart() = %v, expected %v", got, tc.wantNext)
+			}
+		})
+	}
+
+	if period, err := ParsePeriod("Weekly"); err != nil || period != PeriodWeek {
+		t.Errorf("ParsePeriod(Weekly) = %q, %v, expected week", period, err)
+	}
+	if _, err := ParsePeriod("yearly"); err == nil {
+		t.Error("Expected error for unknown period")
+	}
+}
+
+func TestNextAvailable(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 18, 30, 0, 0, time.Local)
+
+	reward := models.Reward{Cooldown: "12h", Purchases: []time.Time{now.Add(-2 * time.Hour)}}
+	if got, want := NextAvailable(reward, now), now.Add(10*time.Hour); !got.Equal(want) {
+		t.Errorf("Expected cooldown to end at %v, got %v", want, got)
+	}
+
+	reward = models.Reward{Limit: 2, LimitPeriod: PeriodWeek, Purchases: []time.Time{now.AddDate(0, 0, -7), now.Add(-time.Hour)}}
+	if got := NextAvailable(reward, now); !got.Equal(now) {
+		t.Errorf("Expected a purchase of the last week not to count, got %v", got)
+	}
+	reward.Purchases = append(reward.Purchases, now)
+	if got, want := NextAvailable(reward, now), time.Date(2025, time.January, 20, 0, 0, 0, 0, time.Local); !got.Equal(want) {
+		t.Errorf("Expected the limit to end at %v, got %v", want, got)
+	}
+	if recent := RecentPurchases(reward, now); len(recent) != 2 {
+		t.Errorf("Expected the purchase of the last week to be dropped, got %v", recent)
+	}
+
+	if count, period, err := ParseLimit("2/weekly"); err != nil || count != 2 || period != PeriodWeek {
+		t.Errorf("ParseLimit(2/weekly) = %d, %q, %v", count, period, err)
+	}
+	if d, err := ParseCooldown("2d"); err != nil || d != 48*time.Hour {
+		t.Errorf("ParseCooldown(2d) = %v, %v", d, err)
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	now := time.Date(2025, time.January, 15, 18, 30, 0, 0, time.Local)
+
+	testCases := []struct {
+		due       string
+		want      time.Time
+		shouldErr bool
+	}{
+		{due: "2025-03-01", want: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local)},
+		{due: "Today", want: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.Local)},
+		{due: "tomorrow", want: time.Date(2025, time.January, 16, 0, 0, 0, 0, time.Local)},
+		{due: "+3d", want: time.Date(2025, time.January, 18, 0, 0, 0, 0, time.Local)},
+		{due: "next week", shouldErr: true},
+		{due: "+-1d", shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.due, func(t *testing.T) {
+			got, err := ParseDue(tc.due, now)
+			if (err != nil) != tc.shouldErr {
+				t.Fatalf("Expected error: %v, got %v", tc.shouldErr, err)
+			}
+			if !tc.shouldErr && !got.Equal(tc.want) {
+				t.Errorf("ParseDue() = %v, expected %v", got, tc.want)
+			}
+		})
+	}
+}
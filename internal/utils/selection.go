@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+// ParseIDList parses IDs given as lists and ranges, e.g. "3,5,8-12". Several
+// arguments are joined, so "3 5" works too. An ID may be a prefix of a UUID
+// of an item in someSliceOfItems, ranges are of display IDs only and take
+// the IDs of the items in them: the IDs of deleted items are skipped.
+// Repeated IDs are kept once, in the order of the first occurrence.
+func ParseIDList(specs []string, someSliceOfItems any) ([]int, error) {
+	existing, _, err := identifiers(someSliceOfItems)
+	if err != nil {
+		return nil, err
+	}
+	existing = slices.Clone(existing)
+	slices.Sort(existing)
+
+	var ids []int
+	seen := make(map[int]bool)
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, spec := range specs {
+		for _, part := range strings.Split(spec, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			from, to, isRange := strings.Cut(part, "-")
+			if isRange && !isNumber(from) || isRange && !isNumber(to) {
+				isRange = false
+			}
+			if !isRange {
+				id, err := ValidateID(part, someSliceOfItems)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", err, part)
+				}
+				add(id)
+				continue
+			}
+
+			first, err := ValidateID(strings.TrimSpace(from), someSliceOfItems)
+			if err != nil {
+				return nil, fmt.Errorf("%w in range %s", err, part)
+			}
+			last, err := ValidateID(strings.TrimSpace(to), someSliceOfItems)
+			if err != nil {
+				return nil, fmt.Errorf("%w in range %s", err, part)
+			}
+			if first > last {
+				return nil, fmt.Errorf("incorrect range %s: start is after end", part)
+			}
+			found := false
+			for _, id := range existing {
+				if id >= first && id <= last {
+					add(id)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("no existing id in range %s", part)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no id given")
+	}
+	return ids, nil
+}
+
+// ParseWhere parses a filter of tasks. Terms are separated by commas or
+// spaces and all of them must match:
+//
+//	done, open         completed or not completed tasks
+//	overdue            not completed tasks with a due date before today
+//	points>N           also points<N, points>=N, points<=N, points=N
+//	text~word          text contains the word, ignoring case
+//	+project, @context task has the project or the context
+//	pri:A              task has the priority
+func ParseWhere(expr string) (func(task models.Task) bool, error) {
+	terms := strings.FieldsFunc(expr, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+
+	var filters []func(task models.Task) bool
+	for _, term := range terms {
+		filter, err := parseWhereTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return func(task models.Task) bool {
+		for _, filter := range filters {
+			if !filter(task) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseWhereTerm(term string) (func(task models.Task) bool, error) {
+	switch lower := strings.ToLower(term); {
+	case lower == "done" || lower == "completed":
+		return func(task models.Task) bool { return task.IsComplete }, nil
+	case lower == "open" || lower == "undone":
+		return func(task models.Task) bool { return !task.IsComplete }, nil
+	case lower == "overdue":
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return func(task models.Task) bool {
+			return !task.IsComplete && task.Due != nil && task.Due.Before(today)
+		}, nil
+	case strings.HasPrefix(lower, "points"):
+		return parsePointsTerm(term, strings.TrimPrefix(lower, "points"))
+	case strings.HasPrefix(lower, "text~"):
+		word := strings.TrimPrefix(lower, "text~")
+		return func(task models.Task) bool { return strings.Contains(strings.ToLower(task.Text), word) }, nil
+	case strings.HasPrefix(term, "+") && len(term) > 1:
+		return func(task models.Task) bool { return contains(task.Projects, term[1:]) }, nil
+	case strings.HasPrefix(term, "@") && len(term) > 1:
+		return func(task models.Task) bool { return contains(task.Contexts, term[1:]) }, nil
+	case strings.HasPrefix(lower, "pri:") && len(term) > 4:
+		priority := strings.ToUpper(term[4:])
+		return func(task models.Task) bool { return task.Priority == priority }, nil
+	}
+	return nil, fmt.Errorf("unknown filter term %q", term)
+}
+
+func parsePointsTerm(term, condition string) (func(task models.Task) bool, error) {
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		value, ok := strings.CutPrefix(condition, op)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect count of points in filter term %q", term)
+		}
+		return func(task models.Task) bool {
+			switch op {
+			case ">=":
+				return task.TaskPoints >= n
+			case "<=":
+				return task.TaskPoints <= n
+			case ">":
+				return task.TaskPoints > n
+			case "<":
+				return task.TaskPoints < n
+			}
+			return task.TaskPoints == n
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown filter term %q", term)
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(s))
+	return err == nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/svetsed/todo_cli_app/internal/models"
+)
+
+func TestParseIDList(t *testing.T) {
+	tasks := []models.Task{
+		{ID: 1, UID: "0e1f7a3c-0000-4000-8000-000000000001"},
+		{ID: 2, UID: "5b6c7d8e-0000-4000-8000-000000000002"},
+		{ID: 3},
+		{ID: 5},
+		{ID: 8},
+		{ID: 10},
+	}
+
+	testCases := []struct {
+		name      string
+		specs     []string
+		wantIDs   []int
+		shouldErr bool
+	}{
+		{
+			name:    "List and range",
+			specs:   []string{"3,5,8-10"},
+			wantIDs: []int{3, 5, 8, 10},
+		},
+		{
+			name:    "Range over deleted IDs",
+			specs:   []string{"2-6"},
+			wantIDs: []int{2, 3, 5},
+		},
+		{
+			name:      "Range without existing IDs",
+			specs:     []string{"11-20"},
+			shouldErr: true,
+		},
+		{
+			name:    "Several arguments and repeated IDs",
+			specs:   []string{"2", "1-3", "2,"},
+			wantIDs: []int{2, 1, 3},
+		},
+		{
+			name:    "Prefixes of UUIDs",
+			specs:   []string{"5b6c7d8e-0000,0e1f", "9"},
+			wantIDs: []int{2, 1, 9},
+		},
+		{
+			name:      "Reversed range",
+			specs:     []string{"5-3"},
+			shouldErr: true,
+		},
+		{
+			name:      "Not a number",
+			specs:     []string{"1-x"},
+			shouldErr: true,
+		},
+		{
+			name:      "Nothing given",
+			specs:     []string{","},
+			shouldErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ids, err := ParseIDList(tc.specs, tasks)
+			if (err != nil) != tc.shouldErr {
+				t.Fatalf("Expected error: %v, got %v", tc.shouldErr, err)
+			}
+			if !tc.shouldErr && !reflect.DeepEqual(ids, tc.wantIDs) {
+				t.Errorf("Expected %v, got %v", tc.wantIDs, ids)
+			}
+		})
+	}
+}
+
+func TestParseWhere(t *testing.T) {
+	yesterday := time.Now().AddDate(0, 0, -1)
+	tasks := []models.Task{
+		{ID: 1, Text: "Buy milk", TaskPoints: 5, IsComplete: true},
+		{ID: 2, Text: "Write report", TaskPoints: 30, Projects: []string{"Work"}, Priority: "A"},
+		{ID: 3, Text: "Buy bread", TaskPoints: 10, Contexts: []string{"shop"}, Due: &yesterday},
+	}
+
+	testCases := []struct {
+		expr      string
+		wantIDs   []int
+		shouldErr bool
+	}{
+		{expr: "done", wantIDs: []int{1}},
+		{expr: "open", wantIDs: []int{2, 3}},
+		{expr: "overdue", wantIDs: []int{3}},
+		{expr: "points>=10", wantIDs: []int{2, 3}},
+		{expr: "points<10", wantIDs: []int{1}},
+		{expr: "text~BUY, open", wantIDs: []int{3}},
+		{expr: "+work", wantIDs: []int{2}},
+		{expr: "@shop points=10", wantIDs: []int{3}},
+		{expr: "pri:a", wantIDs: []int{2}},
+		{expr: "points>x", shouldErr: true},
+		{expr: "finished", shouldErr: true},
+		{expr: " ", shouldErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			filter, err := ParseWhere(tc.expr)
+			if (err != nil) != tc.shouldErr {
+				t.Fatalf("Expected error: %v, got %v", tc.shouldErr, err)
+			}
+			if tc.shouldErr {
+				return
+			}
+
+			var ids []int
+			for _, task := range tasks {
+				if filter(task) {
+					ids = append(ids, task.ID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tc.wantIDs) {
+				t.Errorf("Expected %v, got %v", tc.wantIDs, ids)
+			}
+		})
+	}
+}
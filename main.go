@@ -1,23 +1,33 @@
 package main
 
 import (
+	"errors"
 	"log/slog"
 	"os"
 
 	"github.com/svetsed/todo_cli_app/cmd"
+	"github.com/svetsed/todo_cli_app/cmd/security"
 	"github.com/svetsed/todo_cli_app/internal/config"
 	"github.com/svetsed/todo_cli_app/internal/logger"
+	"github.com/svetsed/todo_cli_app/internal/storage"
 )
 
 func main() {
 	logger.Init(slog.LevelError, os.Stdout)
 
+	// An invalid config is reported by the commands themselves, so that
+	// 'todo config' can still be used to fix it.
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	var validationErrs config.ValidationErrors
+	if err != nil && !errors.As(err, &validationErrs) {
 		logger.Error("failed to load configuration", err)
 		os.Exit(1)
 	}
 
+	if cfg.Encryption.Enabled {
+		storage.SetSecretProvider(security.SecretProvider(cfg))
+	}
+
 	rootCmd := cmd.RootCmd(cfg)
 	if err := rootCmd.Execute(); err != nil {
 		logger.Error("failed to execute root command", err)